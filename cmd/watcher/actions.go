@@ -0,0 +1,486 @@
+// Copyright (2023 -- present) Shahruk Hossain <shahruk10@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//		 http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// ==============================================================================
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/shahruk10/watcher/internal/fileutil"
+	"github.com/shahruk10/watcher/internal/imageinfo"
+	"github.com/shahruk10/watcher/internal/notify"
+	"github.com/shahruk10/watcher/internal/pdfinfo"
+	"github.com/shahruk10/watcher/internal/webhook"
+	"github.com/sirupsen/logrus"
+)
+
+// Types of built-in actions.
+const (
+	actionAlert      = "alert"
+	actionLog        = "log"
+	actionMove       = "move"
+	actionCopy       = "copy"
+	actionQuarantine = "quarantine"
+	actionMarker     = "write-marker"
+	actionExec       = "exec"
+	actionWebhook    = "webhook"
+)
+
+var actionTypes = []string{
+	actionAlert,
+	actionLog,
+	actionMove,
+	actionCopy,
+	actionQuarantine,
+	actionMarker,
+	actionExec,
+	actionWebhook,
+}
+
+// ActionConfig configures a single action to carry out for a verdict. Which
+// fields are used depends on the type of the action. All string fields except
+// Type and Level are Go templates, executed with the file's path, op, verdict
+// and extracted attributes, e.g. "{{.Dir}}/{{.File.frame_size}}".
+type ActionConfig struct {
+	Type string `yaml:"type"`
+
+	// Title and Message of alerts, log lines and markers; they default to the
+	// title and message of the verdict (the file path for log lines).
+	Title   string `yaml:"title"`
+	Message string `yaml:"message"`
+
+	// Level of log lines; defaults to "info".
+	Level string `yaml:"level"`
+
+	// Dest is the folder to move, copy or quarantine the file into. Moves
+	// default to the correct folder for the file, and quarantines to the
+	// folder in the quarantine config.
+	Dest   string `yaml:"dest"`
+	DryRun bool   `yaml:"dry_run"`
+
+	// Path of the marker file to write.
+	Path string `yaml:"path"`
+
+	// Command to execute, along with its arguments and extra environment
+	// variables. Commands are killed once the timeout is reached; a failing
+	// command is only reported as an error if FailOnError is set.
+	Command     string            `yaml:"command"`
+	Args        []string          `yaml:"args"`
+	Env         map[string]string `yaml:"env"`
+	Timeout     time.Duration     `yaml:"timeout"`
+	FailOnError bool              `yaml:"fail_on_error"`
+
+	// URL to post a JSON document describing the verdict to; defaults to the
+	// URLs in the webhook config.
+	URL string `yaml:"url"`
+}
+
+func (cfg *ActionConfig) Validate() error {
+	known := false
+	for _, t := range actionTypes {
+		known = known || t == cfg.Type
+	}
+
+	if !known {
+		return fmt.Errorf("unknown action type %q", cfg.Type)
+	}
+
+	switch {
+	case cfg.Type == actionCopy && cfg.Dest == "":
+		return fmt.Errorf("%s action: dest must be specified", cfg.Type)
+	case cfg.Type == actionMarker && cfg.Path == "":
+		return fmt.Errorf("%s action: path must be specified", cfg.Type)
+	case cfg.Type == actionExec && cfg.Command == "":
+		return fmt.Errorf("%s action: command must be specified", cfg.Type)
+	}
+
+	if cfg.Level != "" {
+		if _, err := logrus.ParseLevel(cfg.Level); err != nil {
+			return fmt.Errorf("%s action: %w", cfg.Type, err)
+		}
+	}
+
+	templates := append([]string{cfg.Title, cfg.Message, cfg.Dest, cfg.Path, cfg.Command, cfg.URL}, cfg.Args...)
+	for _, text := range cfg.Env {
+		templates = append(templates, text)
+	}
+
+	for _, text := range templates {
+		if _, err := parseTemplate(text); err != nil {
+			return fmt.Errorf("%s action: %w", cfg.Type, err)
+		}
+	}
+
+	return nil
+}
+
+// Action is carried out for a file once its verdict is known.
+type Action = func(ctx context.Context, logger *logrus.Logger, r *Result) error
+
+// ActionOutcome records an action carried out for a result, and the error it
+// failed with, if any.
+type ActionOutcome struct {
+	Type  string `json:"type"`
+	Error string `json:"error,omitempty"`
+}
+
+type pipelineStep struct {
+	actionType string
+	run        Action
+}
+
+// Pipeline holds the actions to carry out for each verdict, in order.
+type Pipeline map[Verdict][]pipelineStep
+
+// NewPipeline builds the actions configured for each verdict. Verdicts without
+// any configured actions get the default ones: problems are alerted, files in
+// the correct folder are logged. Verdicts listed in the webhook config are
+// also posted to the configured webhooks.
+func NewPipeline(cfg Config, notifier notify.Notifier, webhooks *webhook.Sender) (Pipeline, error) {
+	pipeline := make(Pipeline)
+	execSlots := make(chan struct{}, cfg.Exec.MaxConcurrentOrDefault())
+
+	for _, v := range verdicts {
+		actionCfgs, ok := cfg.Actions[v]
+		if !ok {
+			actionCfgs = defaultActions(cfg, v)
+		}
+
+		if cfg.Webhook.Posts(v) {
+			actionCfgs = append(append([]ActionConfig{}, actionCfgs...), ActionConfig{Type: actionWebhook})
+		}
+
+		for i, ac := range actionCfgs {
+			action, err := newAction(cfg, ac, notifier, execSlots, webhooks)
+			if err != nil {
+				return nil, fmt.Errorf("%s action[%d]: %w", v, i, err)
+			}
+
+			pipeline[v] = append(pipeline[v], pipelineStep{actionType: ac.Type, run: action})
+		}
+	}
+
+	return pipeline, nil
+}
+
+func defaultActions(cfg Config, v Verdict) []ActionConfig {
+	switch v {
+	case VerdictCorrect:
+		return []ActionConfig{{Type: actionLog, Level: "debug"}}
+	case VerdictWrongFolder:
+		if cfg.Move.Enabled {
+			return []ActionConfig{{Type: actionMove}}
+		}
+	case VerdictUnknownType, VerdictInvalidFileName, VerdictCorruptFile, VerdictMislabelledFile:
+		if cfg.Quarantine.Enabled {
+			return []ActionConfig{{Type: actionQuarantine}, {Type: actionAlert}}
+		}
+	}
+
+	return []ActionConfig{{Type: actionAlert}}
+}
+
+// Run carries out all actions for the verdict of the result. Every action is
+// attempted even if earlier ones fail.
+func (p Pipeline) Run(ctx context.Context, logger *logrus.Logger, r *Result) error {
+	errs := make([]string, 0)
+
+	for _, step := range p[r.Verdict] {
+		outcome := ActionOutcome{Type: step.actionType}
+
+		if err := step.run(ctx, logger, r); err != nil {
+			errs = append(errs, fmt.Sprintf("%s action: %v", step.actionType, err))
+			outcome.Error = err.Error()
+		}
+
+		r.Actions = append(r.Actions, outcome)
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+
+	return nil
+}
+
+func newAction(cfg Config, ac ActionConfig, notifier notify.Notifier, execSlots chan struct{}, webhooks *webhook.Sender) (Action, error) {
+	if err := ac.Validate(); err != nil {
+		return nil, err
+	}
+
+	title, _ := parseTemplate(ac.Title)
+	message, _ := parseTemplate(ac.Message)
+	dest, _ := parseTemplate(ac.Dest)
+
+	switch ac.Type {
+	case actionAlert:
+		return func(ctx context.Context, logger *logrus.Logger, r *Result) error {
+			n := r.Notification()
+			n.Title = renderOr(title, r, r.Title)
+			if text := renderOr(message, r, ""); text != "" {
+				n.Fields = []notify.Field{{Value: text}}
+			}
+
+			return alert(ctx, logger, cfg.Move, notifier, r, n)
+		}, nil
+
+	case actionLog:
+		level := logrus.InfoLevel
+		if ac.Level != "" {
+			level, _ = logrus.ParseLevel(ac.Level)
+		}
+
+		return func(ctx context.Context, logger *logrus.Logger, r *Result) error {
+			logger.Logf(level, "%s: %q", renderOr(title, r, r.Title), renderOr(message, r, r.Path))
+			return nil
+		}, nil
+
+	case actionMove:
+		moveCfg := cfg.Move
+		moveCfg.DryRun = moveCfg.DryRun || ac.DryRun
+
+		return func(ctx context.Context, logger *logrus.Logger, r *Result) error {
+			dir, ok := renderOr(dest, r, ""), true
+			if dir == "" {
+				dir, ok = correctFolderPath(r.Path, r.CorrectDirNames)
+			}
+
+			if ok {
+				moved, err := moveFile(logger, moveCfg, r.Path, dir)
+				if moved {
+					r.moved(filepath.Join(dir, filepath.Base(r.Path)))
+					return nil
+				}
+
+				if err != nil {
+					logger.Errorf("failed to move misplaced file: %v", err)
+				}
+			}
+
+			// The file could not be moved, so fall back to alerting.
+			return alert(ctx, logger, moveCfg, notifier, r, r.Notification())
+		}, nil
+
+	case actionCopy:
+		return func(ctx context.Context, logger *logrus.Logger, r *Result) error {
+			dir, err := render(dest, r)
+			if err != nil {
+				return err
+			}
+
+			if err := os.MkdirAll(dir, 0o755); err != nil {
+				return fmt.Errorf("create folder: %w", err)
+			}
+
+			dst := filepath.Join(dir, filepath.Base(r.Path))
+			if ac.DryRun {
+				logger.Infof("[dry run] would copy %q to %q", r.Path, dst)
+				return nil
+			}
+
+			if err := fileutil.Copy(r.Path, dst); err != nil {
+				return err
+			}
+
+			logger.Infof("copied %q to %q", r.Path, dst)
+
+			return nil
+		}, nil
+
+	case actionQuarantine:
+		return func(ctx context.Context, logger *logrus.Logger, r *Result) error {
+			quarantineCfg := cfg.Quarantine
+			if dir := renderOr(dest, r, ""); dir != "" {
+				quarantineCfg.Dir = dir
+			}
+
+			if quarantineCfg.Dir == "" {
+				return fmt.Errorf("quarantine folder not specified")
+			}
+
+			dst, err := quarantineFile(logger, quarantineCfg, r.Path, r.Title, r.Message())
+			if err != nil {
+				return err
+			}
+
+			r.Fields = append(r.Fields, notify.Field{Label: "🔒 quarantined", Value: dst})
+			r.moved(dst)
+
+			return nil
+		}, nil
+
+	case actionMarker:
+		path, _ := parseTemplate(ac.Path)
+
+		return func(ctx context.Context, logger *logrus.Logger, r *Result) error {
+			markerPath, err := render(path, r)
+			if err != nil {
+				return err
+			}
+
+			if err := os.MkdirAll(filepath.Dir(markerPath), 0o755); err != nil {
+				return fmt.Errorf("create folder: %w", err)
+			}
+
+			content := renderOr(message, r, r.Message())
+
+			return os.WriteFile(markerPath, []byte(content+"\n"), 0o644)
+		}, nil
+
+	case actionExec:
+		return newExecAction(ac, execSlots), nil
+
+	case actionWebhook:
+		return newWebhookAction(ac, webhooks), nil
+	}
+
+	return nil, fmt.Errorf("unknown action type %q", ac.Type)
+}
+
+// alert delivers the notification about the result. For misplaced files, the
+// operator is offered to move the file to each folder it could belong in, and
+// the chosen move is carried out and recorded in the journal.
+func alert(ctx context.Context, logger *logrus.Logger, moveCfg MoveConfig, notifier notify.Notifier, r *Result, n notify.Notification) error {
+	if r.Verdict != VerdictWrongFolder {
+		return notifier.Notify(ctx, n)
+	}
+
+	dirs := correctFolderPaths(r.Path, r.CorrectDirNames)
+	if len(dirs) == 0 {
+		return notifier.Notify(ctx, n)
+	}
+
+	choices := make([]string, len(dirs))
+	for i, dir := range dirs {
+		choices[i] = fmt.Sprintf("Move to '%s'", filepath.Base(dir))
+	}
+
+	choice, err := notify.Prompt(ctx, notifier, n, choices)
+	if err != nil || choice == notify.NoChoice {
+		return err
+	}
+
+	logger.Infof("operator chose to move %q to %q", r.Path, dirs[choice])
+
+	moved, err := moveFile(logger, moveCfg, r.Path, dirs[choice])
+	if err != nil {
+		return fmt.Errorf("failed to move misplaced file: %w", err)
+	}
+
+	if moved {
+		r.moved(filepath.Join(dirs[choice], filepath.Base(r.Path)))
+	}
+
+	return nil
+}
+
+// webhookDocument is the JSON document posted to webhooks.
+type webhookDocument struct {
+	Time    time.Time `json:"timestamp"`
+	Verdict Verdict   `json:"verdict"`
+	Op      string    `json:"op"`
+	File    string    `json:"file"`
+	Folder  string    `json:"folder"`
+
+	FileAttr   map[string]string `json:"file_attributes"`
+	FolderAttr map[string]string `json:"folder_attributes"`
+
+	// CorrectFolder holds the suggested folder name(s), joined with " OR " if
+	// there are several; CorrectFolderPath is only set if the folder is
+	// unambiguous and exists.
+	CorrectFolder     string `json:"correct_folder,omitempty"`
+	CorrectFolderPath string `json:"correct_folder_path,omitempty"`
+
+	Image *imageinfo.Info `json:"image,omitempty"`
+	PDF   *pdfinfo.Info   `json:"pdf,omitempty"`
+
+	Title   string `json:"title"`
+	Message string `json:"message"`
+}
+
+func newWebhookDocument(r *Result) webhookDocument {
+	doc := webhookDocument{
+		Time:          r.Time,
+		Verdict:       r.Verdict,
+		Op:            r.Op,
+		File:          absPath(r.Path),
+		Folder:        absPath(filepath.Dir(r.Path)),
+		FileAttr:      r.FileAttr,
+		FolderAttr:    r.DirAttr,
+		CorrectFolder: r.CorrectDirName(),
+		Image:         r.Image,
+		PDF:           r.PDF,
+		Title:         r.Title,
+		Message:       r.Message(),
+	}
+
+	if dir, ok := correctFolderPath(r.Path, r.CorrectDirNames); ok {
+		doc.CorrectFolderPath = absPath(dir)
+	}
+
+	return doc
+}
+
+func newWebhookAction(ac ActionConfig, webhooks *webhook.Sender) Action {
+	url, _ := parseTemplate(ac.URL)
+
+	return func(ctx context.Context, logger *logrus.Logger, r *Result) error {
+		if webhooks == nil {
+			return fmt.Errorf("webhooks not configured")
+		}
+
+		var urls []string
+		if target := renderOr(url, r, ""); target != "" {
+			urls = append(urls, target)
+		}
+
+		return webhooks.Send(newWebhookDocument(r), urls...)
+	}
+}
+
+func parseTemplate(text string) (*template.Template, error) {
+	t, err := template.New("").Option("missingkey=zero").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("parse template %q: %w", text, err)
+	}
+
+	return t, nil
+}
+
+func render(t *template.Template, r *Result) (string, error) {
+	var sb strings.Builder
+	if err := t.Execute(&sb, r.templateData()); err != nil {
+		return "", fmt.Errorf("execute template: %w", err)
+	}
+
+	return sb.String(), nil
+}
+
+// renderOr renders the template, returning the fallback if the template is
+// empty or fails to execute.
+func renderOr(t *template.Template, r *Result, fallback string) string {
+	s, err := render(t, r)
+	if err != nil || s == "" {
+		return fallback
+	}
+
+	return s
+}
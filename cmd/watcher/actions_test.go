@@ -0,0 +1,353 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/shahruk10/watcher/internal/notify"
+	"github.com/sirupsen/logrus"
+)
+
+// scriptedResponder answers prompts with the given choice, recording the
+// choices it was offered.
+type scriptedResponder struct {
+	answer  int
+	offered []string
+}
+
+func (s *scriptedResponder) Notify(ctx context.Context, n notify.Notification) error {
+	return nil
+}
+
+func (s *scriptedResponder) CanPrompt() bool {
+	return true
+}
+
+func (s *scriptedResponder) Prompt(ctx context.Context, n notify.Notification, choices []string) (int, error) {
+	s.offered = choices
+	return s.answer, nil
+}
+
+func TestAlertMoveChoice(t *testing.T) {
+	tests := []struct {
+		name      string
+		answer    int
+		wantDir   string
+		wantMoved bool
+	}{
+		{name: "move to second", answer: 1, wantDir: "8x12", wantMoved: true},
+		{name: "ignore", answer: notify.NoChoice, wantDir: "12x12"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			root := t.TempDir()
+			for _, dir := range []string{"12x12", "8x10", "8x12"} {
+				if err := os.Mkdir(filepath.Join(root, dir), 0o755); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			filePath := filepath.Join(root, "12x12", "order_8x.jpg")
+			if err := os.WriteFile(filePath, []byte("jpg"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			moveCfg := MoveConfig{Journal: filepath.Join(root, "journal.jsonl")}
+			responder := &scriptedResponder{answer: tc.answer}
+			r := &Result{Path: filePath, Verdict: VerdictWrongFolder, CorrectDirNames: []string{"8x10", "8x12", "missing"}}
+
+			if err := alert(context.Background(), logrus.New(), moveCfg, responder, r, r.Notification()); err != nil {
+				t.Fatalf("got unexpected error, want=nil, got=%v", err)
+			}
+
+			if len(responder.offered) != 2 || responder.offered[1] != "Move to '8x12'" {
+				t.Errorf("got unexpected choices, got=%q", responder.offered)
+			}
+
+			if _, err := os.Stat(filepath.Join(root, tc.wantDir, "order_8x.jpg")); err != nil {
+				t.Errorf("got file not in expected folder %q: %v", tc.wantDir, err)
+			}
+
+			f, err := os.Open(moveCfg.Journal)
+			if !tc.wantMoved {
+				if err == nil {
+					f.Close()
+					t.Errorf("got journal written without a move")
+				}
+
+				return
+			} else if err != nil {
+				t.Fatalf("got no journal after move: %v", err)
+			}
+
+			defer f.Close()
+
+			entry := JournalEntry{}
+			scanner := bufio.NewScanner(f)
+			if !scanner.Scan() {
+				t.Fatal("got empty journal after move")
+			}
+
+			if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+				t.Fatal(err)
+			}
+
+			if entry.From != filePath || entry.To != filepath.Join(root, "8x12", "order_8x.jpg") {
+				t.Errorf("got unexpected journal entry, got=%+v", entry)
+			}
+		})
+	}
+}
+
+func TestPipelineActionOutcomes(t *testing.T) {
+	failing := func(ctx context.Context, logger *logrus.Logger, r *Result) error { return errors.New("no space left") }
+	passing := func(ctx context.Context, logger *logrus.Logger, r *Result) error { return nil }
+
+	pipeline := Pipeline{
+		VerdictWrongFolder: {{actionType: actionMove, run: failing}, {actionType: actionAlert, run: passing}},
+	}
+
+	r := &Result{Verdict: VerdictWrongFolder}
+	if err := pipeline.Run(context.Background(), logrus.New(), r); err == nil {
+		t.Errorf("got no error for failed action")
+	}
+
+	want := []ActionOutcome{{Type: actionMove, Error: "no space left"}, {Type: actionAlert}}
+	if !reflect.DeepEqual(r.Actions, want) {
+		t.Errorf("got unexpected action outcomes, want=%+v, got=%+v", want, r.Actions)
+	}
+}
+
+func TestDefaultActions(t *testing.T) {
+	tests := []struct {
+		verdict    Verdict
+		move       bool
+		quarantine bool
+		want       []string
+	}{
+		{verdict: VerdictCorrect, want: []string{actionLog}},
+		{verdict: VerdictWrongFolder, want: []string{actionAlert}},
+		{verdict: VerdictWrongFolder, move: true, want: []string{actionMove}},
+		{verdict: VerdictInvalidFileName, want: []string{actionAlert}},
+		{verdict: VerdictInvalidFileName, quarantine: true, want: []string{actionQuarantine, actionAlert}},
+		{verdict: VerdictCorruptFile, quarantine: true, want: []string{actionQuarantine, actionAlert}},
+		{verdict: VerdictLowResolution, move: true, quarantine: true, want: []string{actionAlert}},
+	}
+
+	for _, tc := range tests {
+		cfg := Config{Move: MoveConfig{Enabled: tc.move}, Quarantine: QuarantineConfig{Enabled: tc.quarantine}}
+
+		var got []string
+		for _, ac := range defaultActions(cfg, tc.verdict) {
+			got = append(got, ac.Type)
+		}
+
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s (move=%v, quarantine=%v): got unexpected actions, want=%q, got=%q", tc.verdict, tc.move, tc.quarantine, tc.want, got)
+		}
+	}
+}
+
+func TestNewPipeline(t *testing.T) {
+	cfg := Config{
+		Actions: map[Verdict][]ActionConfig{
+			VerdictCorrect:     {{Type: actionLog}, {Type: actionMarker, Path: "{{.Path}}.ok"}},
+			VerdictUnknownType: {},
+		},
+	}
+	cfg.Webhook.Verdicts = []Verdict{VerdictWrongFolder}
+
+	pipeline, err := NewPipeline(cfg, &scriptedResponder{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Configured verdicts get their actions, even if there are none, the rest
+	// the default ones, with webhooks added after them.
+	want := map[Verdict][]string{
+		VerdictCorrect:         {actionLog, actionMarker},
+		VerdictUnknownType:     nil,
+		VerdictWrongFolder:     {actionAlert, actionWebhook},
+		VerdictInvalidFileName: {actionAlert},
+	}
+
+	for v, types := range want {
+		var got []string
+		for _, step := range pipeline[v] {
+			got = append(got, step.actionType)
+		}
+
+		if !reflect.DeepEqual(got, types) {
+			t.Errorf("%s: got unexpected pipeline, want=%q, got=%q", v, types, got)
+		}
+	}
+
+	for _, v := range verdicts {
+		if _, ok := cfg.Actions[v]; !ok && len(pipeline[v]) == 0 {
+			t.Errorf("%s: got no default actions", v)
+		}
+	}
+
+	// Invalid actions are rejected.
+	for _, ac := range []ActionConfig{{Type: "unknown"}, {Type: actionCopy}, {Type: actionMarker}, {Type: actionLog, Title: "{{.Path"}} {
+		cfg.Actions = map[Verdict][]ActionConfig{VerdictCorrect: {ac}}
+		if _, err := NewPipeline(cfg, &scriptedResponder{}, nil); err == nil {
+			t.Errorf("got unexpected error for invalid action %+v, want=error, got=%v", ac, err)
+		}
+	}
+}
+
+func TestActions(t *testing.T) {
+	root := t.TempDir()
+	logger := logrus.New()
+
+	for _, dir := range []string{"8x10 framed", "11x14 framed"} {
+		if err := os.Mkdir(filepath.Join(root, dir), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cfg := Config{
+		Move:       MoveConfig{Enabled: true, Journal: filepath.Join(root, "move.journal")},
+		Quarantine: QuarantineConfig{Enabled: true, Dir: filepath.Join(root, "quarantine")},
+		Actions: map[Verdict][]ActionConfig{
+			VerdictCorrect: {
+				{Type: actionCopy, Dest: filepath.Join(root, "archive", "{{.File.frame_size}}")},
+				{Type: actionMarker, Path: "{{.Dir}}/{{.Name}}.done", Message: "{{.Verdict}} in {{.DirName}}"},
+			},
+		},
+	}
+
+	pipeline, err := NewPipeline(cfg, &scriptedResponder{answer: notify.NoChoice}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	writeFile := func(path string) string {
+		path = filepath.Join(root, path)
+		if err := os.WriteFile(path, []byte("jpg"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		return path
+	}
+
+	run := func(r *Result) {
+		if err := pipeline.Run(context.Background(), logger, r); err != nil {
+			t.Fatalf("%s: got unexpected error, want=nil, got=%v", r.Verdict, err)
+		}
+	}
+
+	// Correct files are copied and get a marker.
+	correct := writeFile("8x10 framed/1_fr_8x10.jpg")
+	run(&Result{Path: correct, Verdict: VerdictCorrect, FileAttr: map[string]string{attrFrameSize: "8x10"}})
+
+	if _, err := os.Stat(filepath.Join(root, "archive", "8x10", "1_fr_8x10.jpg")); err != nil {
+		t.Errorf("got file not copied: %v", err)
+	}
+
+	if data, err := os.ReadFile(correct + ".done"); err != nil || string(data) != "correct in 8x10 framed\n" {
+		t.Errorf("got unexpected marker, want=%q, got=%q (%v)", "correct in 8x10 framed\n", data, err)
+	}
+
+	// Misplaced files are moved to their correct folder by default.
+	misplaced := writeFile("8x10 framed/2_fr_11x14.jpg")
+	r := &Result{Path: misplaced, Verdict: VerdictWrongFolder, CorrectDirNames: []string{"11x14 framed"}}
+	run(r)
+
+	moved := filepath.Join(root, "11x14 framed", "2_fr_11x14.jpg")
+	if _, err := os.Stat(moved); err != nil || r.MovedTo != moved {
+		t.Errorf("got file not moved to %q, got=%q (%v)", moved, r.MovedTo, err)
+	}
+
+	entries, err := readJournal(cfg.Move.Journal)
+	if err != nil || len(entries) != 1 || entries[0].From != misplaced || entries[0].To != moved {
+		t.Errorf("got unexpected journal, got=%+v (%v)", entries, err)
+	}
+
+	// Files which could belong in more than one folder are left in place.
+	ambiguous := writeFile("8x10 framed/3_any_11x14.jpg")
+	r = &Result{Path: ambiguous, Verdict: VerdictWrongFolder, CorrectDirNames: []string{"11x14 black framed", "11x14 white framed"}}
+	run(r)
+
+	if _, err := os.Stat(ambiguous); err != nil || r.MovedTo != "" {
+		t.Errorf("got ambiguous file moved, got=%q (%v)", r.MovedTo, err)
+	}
+
+	// Files with invalid names are quarantined and alerted.
+	invalid := writeFile("8x10 framed/scan.jpg")
+	r = &Result{Path: invalid, Verdict: VerdictInvalidFileName, Title: "INVALID FILE NAME"}
+	run(r)
+
+	want := []ActionOutcome{{Type: actionQuarantine}, {Type: actionAlert}}
+	if !reflect.DeepEqual(r.Actions, want) {
+		t.Errorf("got unexpected action outcomes, want=%+v, got=%+v", want, r.Actions)
+	}
+
+	if _, err := os.Stat(r.MovedTo); err != nil || r.MovedTo != quarantinePath(cfg.Quarantine, invalid) {
+		t.Errorf("got file not quarantined, got=%q (%v)", r.MovedTo, err)
+	}
+}
+
+// TestPipelineAfterMove checks actions after a move find the file where it was
+// moved to.
+func TestPipelineAfterMove(t *testing.T) {
+	root := t.TempDir()
+	logger := logrus.New()
+
+	for _, dir := range []string{"8x10 framed", "11x14 framed"} {
+		if err := os.Mkdir(filepath.Join(root, dir), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cfg := Config{
+		Move: MoveConfig{Enabled: true, Journal: filepath.Join(root, "move.journal")},
+		Actions: map[Verdict][]ActionConfig{
+			VerdictWrongFolder: {
+				{Type: actionMove},
+				{Type: actionCopy, Dest: filepath.Join(root, "archive")},
+				{Type: actionMarker, Path: filepath.Join(root, "moved.txt"), Message: "{{.MovedFrom}} -> {{.Path}}"},
+			},
+		},
+	}
+
+	pipeline, err := NewPipeline(cfg, &scriptedResponder{answer: notify.NoChoice}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	misplaced := filepath.Join(root, "8x10 framed", "1_fr_11x14.jpg")
+	if err := os.WriteFile(misplaced, []byte("jpg"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	r := &Result{Path: misplaced, Verdict: VerdictWrongFolder, CorrectDirNames: []string{"11x14 framed"}}
+	if err := pipeline.Run(context.Background(), logger, r); err != nil {
+		t.Fatalf("got unexpected error, want=nil, got=%v", err)
+	}
+
+	moved := filepath.Join(root, "11x14 framed", "1_fr_11x14.jpg")
+	if r.Path != moved || r.MovedTo != moved || r.CheckedPath() != misplaced {
+		t.Errorf("got unexpected paths after move, want=%q from %q, got=%q (moved to %q) from %q", moved, misplaced, r.Path, r.MovedTo, r.CheckedPath())
+	}
+
+	if _, err := os.Stat(filepath.Join(root, "archive", "1_fr_11x14.jpg")); err != nil {
+		t.Errorf("got moved file not copied: %v", err)
+	}
+
+	if data, err := os.ReadFile(filepath.Join(root, "moved.txt")); err != nil || string(data) != misplaced+" -> "+moved+"\n" {
+		t.Errorf("got unexpected marker, want=%q, got=%q (%v)", misplaced+" -> "+moved+"\n", data, err)
+	}
+
+	if entry := newAuditEntry(r); entry.Path != misplaced || entry.MovedTo != moved {
+		t.Errorf("got unexpected audit paths, want=%q to %q, got=%q to %q", misplaced, moved, entry.Path, entry.MovedTo)
+	}
+}
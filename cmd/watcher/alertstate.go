@@ -0,0 +1,402 @@
+// Copyright (2023 -- present) Shahruk Hossain <shahruk10@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//		 http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// ==============================================================================
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/peterbourgon/ff/v3/ffcli"
+	"github.com/shahruk10/watcher/internal/fileutil"
+	"github.com/shahruk10/watcher/internal/notify"
+	"github.com/sirupsen/logrus"
+)
+
+const defaultAlertStatePath = "watcher-alerts.json"
+
+// AlertState remembers which alerts have been raised, and which files and
+// folders have been muted. It is saved to a file so that it survives restarts,
+// and is reloaded whenever the file changes, so mutes added from the command
+// line take effect in a running watcher.
+type AlertState struct {
+	mu      sync.Mutex
+	path    string
+	snooze  time.Duration
+	modTime time.Time
+	data    alertStateData
+}
+
+type alertStateData struct {
+	// Alerts raised, keyed by verdict and path.
+	Alerts map[string]raisedAlert `json:"alerts"`
+	// Mutes holds the time until which alerts about each file or folder are
+	// muted.
+	Mutes map[string]time.Time `json:"mutes"`
+}
+
+// raisedAlert records when an alert was raised, and the size and modification
+// time of the file at the time, so that the alert is raised again if the file
+// changes.
+type raisedAlert struct {
+	RaisedAt time.Time `json:"raised_at"`
+	Size     int64     `json:"size"`
+	ModTime  time.Time `json:"mod_time"`
+}
+
+// OpenAlertState loads the alert state from the configured state file, if it
+// exists.
+func OpenAlertState(cfg AlertsConfig) (*AlertState, error) {
+	s := &AlertState{path: cfg.StatePath(), snooze: cfg.Snooze}
+	if err := s.reload(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// reload reads the state file if it has changed since it was last read.
+func (s *AlertState) reload() error {
+	info, err := os.Stat(s.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		if s.data.Alerts == nil {
+			s.data = alertStateData{Alerts: make(map[string]raisedAlert), Mutes: make(map[string]time.Time)}
+		}
+
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("read alert state: %w", err)
+	}
+
+	if info.ModTime().Equal(s.modTime) {
+		return nil
+	}
+
+	raw, err := os.ReadFile(s.path)
+	if err != nil {
+		return fmt.Errorf("read alert state: %w", err)
+	}
+
+	data := alertStateData{}
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return fmt.Errorf("read alert state %q: %w", s.path, err)
+	}
+
+	if data.Alerts == nil {
+		data.Alerts = make(map[string]raisedAlert)
+	}
+
+	if data.Mutes == nil {
+		data.Mutes = make(map[string]time.Time)
+	}
+
+	s.data, s.modTime = data, info.ModTime()
+
+	return nil
+}
+
+// save writes the state file, dropping expired alerts and mutes.
+func (s *AlertState) save(now time.Time) error {
+	for key, alert := range s.data.Alerts {
+		if now.Sub(alert.RaisedAt) >= s.snooze {
+			delete(s.data.Alerts, key)
+		}
+	}
+
+	for path, until := range s.data.Mutes {
+		if !now.Before(until) {
+			delete(s.data.Mutes, path)
+		}
+	}
+
+	raw, err := json.MarshalIndent(s.data, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode alert state: %w", err)
+	}
+
+	if err := fileutil.WriteFile(s.path, raw, 0o644); err != nil {
+		return fmt.Errorf("write alert state: %w", err)
+	}
+
+	if info, err := os.Stat(s.path); err == nil {
+		s.modTime = info.ModTime()
+	}
+
+	return nil
+}
+
+// Mute stops alerts about the file, or any file in the folder, until the given
+// time.
+func (s *AlertState) Mute(path string, until time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.reload(); err != nil {
+		return err
+	}
+
+	s.data.Mutes[absPath(path)] = until
+
+	return s.save(time.Now())
+}
+
+// Unmute removes the mute on the file or folder, returning false if it wasn't
+// muted.
+func (s *AlertState) Unmute(path string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.reload(); err != nil {
+		return false, err
+	}
+
+	path = absPath(path)
+	if _, ok := s.data.Mutes[path]; !ok {
+		return false, nil
+	}
+
+	delete(s.data.Mutes, path)
+
+	return true, s.save(time.Now())
+}
+
+// Mutes returns the muted files and folders, sorted by path.
+func (s *AlertState) Mutes() ([]string, map[string]time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.reload(); err != nil {
+		return nil, nil, err
+	}
+
+	now := time.Now()
+	paths := make([]string, 0, len(s.data.Mutes))
+	mutes := make(map[string]time.Time, len(s.data.Mutes))
+
+	for path, until := range s.data.Mutes {
+		if now.Before(until) {
+			paths = append(paths, path)
+			mutes[path] = until
+		}
+	}
+
+	sort.Strings(paths)
+
+	return paths, mutes, nil
+}
+
+// mutedBy returns the muted file or folder which covers the path, if any.
+func (s *AlertState) mutedBy(path string, now time.Time) (string, bool) {
+	for muted, until := range s.data.Mutes {
+		if !now.Before(until) {
+			continue
+		}
+
+		if isWithin(path, muted) {
+			return muted, true
+		}
+	}
+
+	return "", false
+}
+
+// shouldRaise returns false if an alert about the path should not be raised,
+// either because it is muted or because the same alert was raised within the
+// snooze period and the file hasn't changed since. Otherwise verdict alerts are
+// recorded as raised, which is the only time the state file is written here;
+// other alerts, such as failed actions, are only checked against the mutes.
+func (s *AlertState) shouldRaise(path, verdict string, now time.Time) (bool, string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.reload(); err != nil {
+		return true, "", err
+	}
+
+	path = absPath(path)
+	if muted, ok := s.mutedBy(path, now); ok {
+		return false, fmt.Sprintf("muted by %q until %s", muted, s.data.Mutes[muted].Format(time.RFC3339)), nil
+	}
+
+	if s.snooze <= 0 || verdict == "" {
+		return true, "", nil
+	}
+
+	current := raisedAlert{RaisedAt: now}
+	if info, err := os.Stat(path); err == nil {
+		current.Size, current.ModTime = info.Size(), info.ModTime()
+	}
+
+	key := verdict + ":" + path
+	if prev, ok := s.data.Alerts[key]; ok && now.Sub(prev.RaisedAt) < s.snooze &&
+		prev.Size == current.Size && prev.ModTime.Equal(current.ModTime) {
+		return false, fmt.Sprintf("already raised at %s and file unchanged", prev.RaisedAt.Format(time.RFC3339)), nil
+	}
+
+	s.data.Alerts[key] = current
+
+	return true, "", s.save(now)
+}
+
+// alertFilter drops alerts about muted files and folders, and alerts which
+// were already raised within the snooze period for files which are unchanged.
+type alertFilter struct {
+	logger *logrus.Logger
+	state  *AlertState
+	next   notify.Notifier
+}
+
+func (f *alertFilter) Notify(ctx context.Context, n notify.Notification) error {
+	if n.Path == "" {
+		return f.next.Notify(ctx, n)
+	}
+
+	raise, reason, err := f.state.shouldRaise(n.Path, n.Verdict, time.Now())
+	if err != nil {
+		f.logger.Errorf("failed to update alert state: %v", err)
+	}
+
+	if !raise {
+		f.logger.Debugf("not raising %s alert for %q: %s", n.Verdict, n.Path, reason)
+		return nil
+	}
+
+	return f.next.Notify(ctx, n)
+}
+
+func (f *alertFilter) CanPrompt() bool {
+	p, ok := f.next.(notify.Prompter)
+	return ok && p.CanPrompt()
+}
+
+func (f *alertFilter) Prompt(ctx context.Context, n notify.Notification, choices []string) (int, error) {
+	raise, reason, err := f.state.shouldRaise(n.Path, n.Verdict, time.Now())
+	if err != nil {
+		f.logger.Errorf("failed to update alert state: %v", err)
+	}
+
+	if !raise {
+		f.logger.Debugf("not raising %s alert for %q: %s", n.Verdict, n.Path, reason)
+		return notify.NoChoice, nil
+	}
+
+	return notify.Prompt(ctx, f.next, n, choices)
+}
+
+// filter wraps the notifier so that it skips snoozed and muted alerts.
+func (s *AlertState) filter(logger *logrus.Logger, n notify.Notifier) notify.Notifier {
+	return &alertFilter{logger: logger, state: s, next: n}
+}
+
+func newMuteCmd(logger *logrus.Logger, cfgPath *string) *ffcli.Command {
+	var (
+		muteFlagSet = flag.NewFlagSet("watcher mute", flag.ExitOnError)
+		forFlag     = muteFlagSet.Duration("for", 0, "How long to mute alerts for (default: alerts.mute_for from the config, or 8h).")
+	)
+
+	return &ffcli.Command{
+		Name:       "mute",
+		ShortUsage: "watcher [flags] mute [-for <duration>] [<file or folder> ...]",
+		ShortHelp:  "Mute alerts about files or folders, or list muted files and folders.",
+		FlagSet:    muteFlagSet,
+		Exec: func(ctx context.Context, args []string) error {
+			cfg, err := loadConfig(logger, *cfgPath)
+			if err != nil {
+				return err
+			}
+
+			state, err := OpenAlertState(cfg.Alerts)
+			if err != nil {
+				return err
+			}
+
+			if len(args) == 0 {
+				paths, mutes, err := state.Mutes()
+				if err != nil {
+					return err
+				}
+
+				for _, path := range paths {
+					fmt.Printf("%s\tuntil %s\n", path, mutes[path].Format(time.RFC3339))
+				}
+
+				return nil
+			}
+
+			muteFor := *forFlag
+			if muteFor <= 0 {
+				muteFor = cfg.Alerts.MuteForOrDefault()
+			}
+
+			until := time.Now().Add(muteFor)
+			for _, path := range args {
+				if err := state.Mute(path, until); err != nil {
+					return err
+				}
+
+				logger.Infof("muted alerts about %q until %s", absPath(path), until.Format(time.RFC3339))
+			}
+
+			return nil
+		},
+	}
+}
+
+func newUnmuteCmd(logger *logrus.Logger, cfgPath *string) *ffcli.Command {
+	return &ffcli.Command{
+		Name:       "unmute",
+		ShortUsage: "watcher [flags] unmute <file or folder> ...",
+		ShortHelp:  "Unmute alerts about files or folders.",
+		Exec: func(ctx context.Context, args []string) error {
+			if len(args) == 0 {
+				return fmt.Errorf("unmute: expected at least one file or folder")
+			}
+
+			cfg, err := loadConfig(logger, *cfgPath)
+			if err != nil {
+				return err
+			}
+
+			state, err := OpenAlertState(cfg.Alerts)
+			if err != nil {
+				return err
+			}
+
+			for _, path := range args {
+				unmuted, err := state.Unmute(path)
+				if err != nil {
+					return err
+				}
+
+				if !unmuted {
+					logger.Warnf("alerts about %q were not muted", absPath(path))
+					continue
+				}
+
+				logger.Infof("unmuted alerts about %q", absPath(path))
+			}
+
+			return nil
+		},
+	}
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestAlertStateSnooze(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "a.jpg")
+	if err := os.WriteFile(file, []byte("a"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	state, err := OpenAlertState(AlertsConfig{Snooze: time.Hour, StateFile: filepath.Join(dir, "state.json")})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	steps := []struct {
+		name    string
+		verdict Verdict
+		at      time.Time
+		change  bool
+		want    bool
+	}{
+		{name: "first alert", verdict: VerdictWrongFolder, at: now, want: true},
+		{name: "unchanged file", verdict: VerdictWrongFolder, at: now.Add(time.Minute), want: false},
+		{name: "other verdict", verdict: VerdictInvalidFileName, at: now.Add(time.Minute), want: true},
+		{name: "changed file", verdict: VerdictWrongFolder, at: now.Add(2 * time.Minute), change: true, want: true},
+		{name: "snooze over", verdict: VerdictWrongFolder, at: now.Add(2 * time.Hour), want: true},
+	}
+
+	for _, step := range steps {
+		if step.change {
+			mtime := now.Add(-time.Hour)
+			if err := os.Chtimes(file, mtime, mtime); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		got, _, err := state.shouldRaise(file, string(step.verdict), step.at)
+		if err != nil {
+			t.Fatalf("%s: got unexpected error, want=nil, got=%v", step.name, err)
+		}
+
+		if got != step.want {
+			t.Errorf("%s: got unexpected result, want=%v, got=%v", step.name, step.want, got)
+		}
+	}
+}
+
+func TestAlertStateMute(t *testing.T) {
+	dir := t.TempDir()
+	cfg := AlertsConfig{StateFile: filepath.Join(dir, "state.json")}
+	file := filepath.Join(dir, "12x12", "a.jpg")
+
+	state, err := OpenAlertState(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := state.Mute(filepath.Dir(file), time.Now().Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	// Mutes are persisted, so they apply after a restart.
+	state, err = OpenAlertState(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if raise, _, _ := state.shouldRaise(file, string(VerdictWrongFolder), time.Now()); raise {
+		t.Errorf("got alert raised for file in muted folder")
+	}
+
+	if raise, _, _ := state.shouldRaise(filepath.Join(dir, "12x12x", "a.jpg"), string(VerdictWrongFolder), time.Now()); !raise {
+		t.Errorf("got alert muted for file outside muted folder")
+	}
+
+	if raise, _, _ := state.shouldRaise(file, string(VerdictWrongFolder), time.Now().Add(2*time.Hour)); !raise {
+		t.Errorf("got alert muted after mute expired")
+	}
+
+	if unmuted, err := state.Unmute(filepath.Dir(file)); err != nil || !unmuted {
+		t.Fatalf("got unexpected result unmuting folder, want=true, got=%v (%v)", unmuted, err)
+	}
+
+	if raise, _, _ := state.shouldRaise(file, string(VerdictWrongFolder), time.Now()); !raise {
+		t.Errorf("got alert muted after unmuting folder")
+	}
+}
+
+func TestAlertStateSavesOnlySnoozes(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "state.json")
+	file := filepath.Join(dir, "a.jpg")
+
+	state, err := OpenAlertState(AlertsConfig{Snooze: time.Hour, StateFile: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Alerts without a verdict, such as failed actions, are neither snoozed
+	// nor written to the state file.
+	for i := 0; i < 2; i++ {
+		if raise, _, err := state.shouldRaise(file, "", time.Now()); err != nil || !raise {
+			t.Fatalf("got unexpected result for alert without verdict, want=true, got=%v (%v)", raise, err)
+		}
+	}
+
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("got state file written for alert without verdict, err=%v", err)
+	}
+
+	if _, _, err := state.shouldRaise(file, string(VerdictWrongFolder), time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("got state file not written for snoozed alert, err=%v", err)
+	}
+}
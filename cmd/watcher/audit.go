@@ -0,0 +1,305 @@
+// Copyright (2023 -- present) Shahruk Hossain <shahruk10@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//		 http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// ==============================================================================
+
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shahruk10/watcher/internal/fileutil"
+	"github.com/shahruk10/watcher/internal/watcher"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	defaultAuditPath = "watcher-audit.jsonl"
+
+	// auditVersion is the version of the audit entry schema, described in
+	// docs/audit-log.schema.json. It changes whenever fields are renamed or
+	// removed.
+	auditVersion = 1
+
+	// auditTimeFormat names rotated audit logs by the time they were rotated.
+	auditTimeFormat = "20060102T150405.000000"
+)
+
+// AuditConfig controls the audit log, an append-only JSON lines file with an
+// entry for every verdict reached, file skipped and file removed or renamed.
+type AuditConfig struct {
+	Enabled bool   `yaml:"enabled"`
+	File    string `yaml:"file"`
+	// MaxSizeMB is the size in megabytes the log may grow to before it is
+	// rotated.
+	MaxSizeMB int `yaml:"max_size_mb"`
+	// MaxAge is how long entries are added to the log before it is rotated.
+	MaxAge time.Duration `yaml:"max_age"`
+	// MaxBackups is the number of rotated logs kept; zero keeps all of them.
+	MaxBackups int `yaml:"max_backups"`
+}
+
+func (cfg *AuditConfig) Validate() error {
+	if cfg.MaxSizeMB < 0 || cfg.MaxAge < 0 || cfg.MaxBackups < 0 {
+		return fmt.Errorf("validate audit: max_size_mb, max_age and max_backups must not be negative")
+	}
+
+	return nil
+}
+
+func (cfg *AuditConfig) Path() string {
+	if cfg.File == "" {
+		return defaultAuditPath
+	}
+
+	return cfg.File
+}
+
+func (cfg *AuditConfig) MaxSizeOrDefault() int64 {
+	if cfg.MaxSizeMB <= 0 {
+		return 100 << 20
+	}
+
+	return int64(cfg.MaxSizeMB) << 20
+}
+
+func (cfg *AuditConfig) MaxAgeOrDefault() time.Duration {
+	if cfg.MaxAge <= 0 {
+		return 7 * 24 * time.Hour
+	}
+
+	return cfg.MaxAge
+}
+
+// AuditEntry is a line of the audit log.
+type AuditEntry struct {
+	Version       int               `json:"version"`
+	Time          time.Time         `json:"time"`
+	Op            string            `json:"op"`
+	Path          string            `json:"path"`
+	Owner         string            `json:"owner,omitempty"`
+	File          map[string]string `json:"file,omitempty"`
+	Folder        map[string]string `json:"folder,omitempty"`
+	FilePattern   int               `json:"file_pattern"`
+	FolderPattern int               `json:"folder_pattern"`
+	Verdict       Verdict           `json:"verdict,omitempty"`
+	Title         string            `json:"title,omitempty"`
+	Skipped       bool              `json:"skipped,omitempty"`
+	CorrectFolder string            `json:"correct_folder,omitempty"`
+	Actions       []ActionOutcome   `json:"actions"`
+	MovedTo       string            `json:"moved_to,omitempty"`
+}
+
+func newAuditEntry(r *Result) AuditEntry {
+	actions := r.Actions
+	if actions == nil {
+		actions = []ActionOutcome{}
+	}
+
+	return AuditEntry{
+		Version:       auditVersion,
+		Time:          r.Time,
+		Op:            r.Op,
+		Path:          r.CheckedPath(),
+		Owner:         r.Owner,
+		File:          r.FileAttr,
+		Folder:        r.DirAttr,
+		FilePattern:   r.FilePattern,
+		FolderPattern: r.FolderPattern,
+		Verdict:       r.Verdict,
+		Title:         r.Title,
+		CorrectFolder: r.CorrectDirName(),
+		Actions:       actions,
+		MovedTo:       r.MovedTo,
+	}
+}
+
+// AuditLog appends an entry for each result, skipped file and removed file to
+// the audit log, rotating it once it grows too large or old.
+type AuditLog struct {
+	mu  sync.Mutex
+	cfg AuditConfig
+
+	f       *os.File
+	size    int64
+	started time.Time
+}
+
+// OpenAudit opens the audit log, creating it if needed.
+func OpenAudit(cfg AuditConfig) (*AuditLog, error) {
+	a := &AuditLog{cfg: cfg}
+	if err := a.open(time.Now()); err != nil {
+		return nil, err
+	}
+
+	return a, nil
+}
+
+func (a *AuditLog) open(now time.Time) error {
+	f, err := os.OpenFile(a.cfg.Path(), os.O_RDWR|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return fmt.Errorf("open audit log: %w", err)
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("open audit log: %w", err)
+	}
+
+	a.f, a.size, a.started = f, info.Size(), now
+
+	// The age of an existing log is that of its first entry.
+	var first AuditEntry
+	if line, err := bufio.NewReader(f).ReadBytes('\n'); err == nil && json.Unmarshal(line, &first) == nil {
+		a.started = first.Time
+	}
+
+	return nil
+}
+
+// Record appends an entry for the result to the audit log.
+func (a *AuditLog) Record(r *Result) error {
+	return a.write(newAuditEntry(r))
+}
+
+// Skipped appends an entry for a file which wasn't checked, because it is an
+// override file or an override file disables validating its name.
+func (a *AuditLog) Skipped(path, op string) error {
+	return a.write(AuditEntry{Version: auditVersion, Time: time.Now(), Op: op, Path: path, Skipped: true, Actions: []ActionOutcome{}})
+}
+
+// Removed appends an entry for a file which was removed or renamed.
+func (a *AuditLog) Removed(path, op string) error {
+	return a.write(AuditEntry{Version: auditVersion, Time: time.Now(), Op: op, Path: path, Actions: []ActionOutcome{}})
+}
+
+func (a *AuditLog) write(entry AuditEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("encode audit entry: %w", err)
+	}
+
+	data = append(data, '\n')
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	// A failed rotation leaves the current log open, so the entry is still
+	// written and rotation is tried again with the next one.
+	var rotateErr error
+
+	now := time.Now()
+	if a.size > 0 && (a.size+int64(len(data)) > a.cfg.MaxSizeOrDefault() || now.Sub(a.started) > a.cfg.MaxAgeOrDefault()) {
+		rotateErr = a.rotate(now)
+	}
+
+	if a.f == nil {
+		if err := a.open(now); err != nil {
+			return err
+		}
+	}
+
+	if a.size == 0 {
+		a.started = now
+	}
+
+	n, err := a.f.Write(data)
+	a.size += int64(n)
+	if err != nil {
+		return fmt.Errorf("write audit log: %w", err)
+	}
+
+	return rotateErr
+}
+
+// rotate renames the log, named by the time it is rotated, and starts a new
+// one, removing the oldest rotated logs beyond the number to keep.
+func (a *AuditLog) rotate(now time.Time) error {
+	path := a.cfg.Path()
+	ext := filepath.Ext(path)
+	prefix := strings.TrimSuffix(path, ext) + "-"
+
+	err := a.f.Close()
+	a.f = nil
+	if err != nil {
+		return fmt.Errorf("close audit log: %w", err)
+	}
+
+	if err := fileutil.Move(path, prefix+now.Format(auditTimeFormat)+ext); err != nil {
+		// Keep appending to the current log rather than losing entries.
+		if err := a.open(a.started); err != nil {
+			return err
+		}
+
+		return fmt.Errorf("rotate audit log: %w", err)
+	}
+
+	if err := a.open(now); err != nil {
+		return err
+	}
+
+	if a.cfg.MaxBackups == 0 {
+		return nil
+	}
+
+	backups, err := filepath.Glob(prefix + "*" + ext)
+	if err != nil {
+		return fmt.Errorf("rotate audit log: %w", err)
+	}
+
+	// The names sort by the time the logs were rotated.
+	sort.Strings(backups)
+
+	for len(backups) > a.cfg.MaxBackups {
+		if err := os.Remove(backups[0]); err != nil {
+			return fmt.Errorf("remove rotated audit log: %w", err)
+		}
+
+		backups = backups[1:]
+	}
+
+	return nil
+}
+
+func (a *AuditLog) Close() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.f == nil {
+		return nil
+	}
+
+	return a.f.Close()
+}
+
+// AuditRemovedFiles appends an entry to the audit log for files which are
+// removed or renamed.
+func AuditRemovedFiles(audit *AuditLog) watcher.Callback {
+	return func(ctx context.Context, logger *logrus.Logger, e watcher.Event) error {
+		if !e.HasOp(watcher.RemoveOp) && !e.HasOp(watcher.RenameOp) {
+			return nil
+		}
+
+		return audit.Removed(e.Name, e.Op.String())
+	}
+}
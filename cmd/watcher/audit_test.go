@@ -0,0 +1,261 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/shahruk10/watcher/internal/watcher"
+	"github.com/sirupsen/logrus"
+)
+
+func TestAuditLog(t *testing.T) {
+	dir := t.TempDir()
+	cfg := AuditConfig{Enabled: true, File: filepath.Join(dir, "audit.jsonl"), MaxSizeMB: 1, MaxBackups: 2}
+
+	audit, err := OpenAudit(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer audit.Close()
+
+	r := &Result{
+		Time:            time.Now(),
+		Op:              "CREATE",
+		Path:            "/hot/8x10 framed/1_fr_11x14.jpg",
+		Owner:           "operator",
+		FileAttr:        map[string]string{attrFrameType: "fr", attrFrameSize: "11x14"},
+		DirAttr:         map[string]string{attrFrameType: "framed", attrFrameSize: "8x10"},
+		FilePattern:     1,
+		FolderPattern:   2,
+		Verdict:         VerdictWrongFolder,
+		Title:           "WRONG FOLDER",
+		CorrectDirNames: []string{"11x14 framed"},
+		Actions:         []ActionOutcome{{Type: actionMove, Error: "file exists"}, {Type: actionAlert}},
+	}
+
+	if err := audit.Record(r); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(cfg.File)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var entry AuditEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		t.Fatalf("got audit entry not valid JSON: %v\n%s", err, data)
+	}
+
+	want := newAuditEntry(r)
+	want.Time = entry.Time
+	if !reflect.DeepEqual(entry, want) || !entry.Time.Equal(r.Time) {
+		t.Errorf("got unexpected audit entry,\nwant=%+v\n got=%+v", want, entry)
+	}
+
+	// The log is rotated once it's too old.
+	audit.started = time.Now().Add(-cfg.MaxAgeOrDefault() - time.Minute)
+	if err := audit.Record(r); err != nil {
+		t.Fatal(err)
+	}
+
+	// And once it's too large, keeping only the newest rotated logs.
+	r.Title = strings.Repeat("x", 400<<10)
+	for i := 0; i < 8; i++ {
+		if err := audit.Record(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	backups, err := filepath.Glob(filepath.Join(dir, "audit-*.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(backups) != cfg.MaxBackups {
+		t.Errorf("got unexpected rotated logs, want=%d, got=%q", cfg.MaxBackups, backups)
+	}
+
+	for _, path := range append(backups, cfg.File) {
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if info.Size() > cfg.MaxSizeOrDefault() {
+			t.Errorf("got %q larger than the maximum size, got=%d", path, info.Size())
+		}
+	}
+}
+
+func TestAuditLogFailedRotation(t *testing.T) {
+	dir := t.TempDir()
+	cfg := AuditConfig{Enabled: true, File: filepath.Join(dir, "audit.jsonl")}
+
+	audit, err := OpenAudit(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer audit.Close()
+
+	r := &Result{Time: time.Now(), Op: "CREATE", Path: "/hot/1_fr_11x14.jpg", Verdict: VerdictCorrect, Title: "CORRECT"}
+	if err := audit.Record(r); err != nil {
+		t.Fatal(err)
+	}
+
+	// The rotated log can't be moved over an existing file.
+	now := time.Now()
+	if err := os.WriteFile(filepath.Join(dir, "audit-"+now.Format(auditTimeFormat)+".jsonl"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := audit.rotate(now); err == nil {
+		t.Errorf("got unexpected error rotating onto an existing file, want=error, got=%v", err)
+	}
+
+	// The current log is still open and appended to.
+	if err := audit.Record(r); err != nil {
+		t.Fatalf("got unexpected error after a failed rotation, want=nil, got=%v", err)
+	}
+
+	data, err := os.ReadFile(cfg.File)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := strings.Count(string(data), "\n"); got != 2 {
+		t.Errorf("got unexpected audit entries after a failed rotation, want=2, got=%d", got)
+	}
+}
+
+func TestAuditEvents(t *testing.T) {
+	dir := t.TempDir()
+	cfg := Config{Audit: AuditConfig{Enabled: true, File: filepath.Join(dir, "audit.jsonl")}}
+	logger := logrus.New()
+
+	audit, err := OpenAudit(cfg.Audit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer audit.Close()
+
+	check := CheckSizeAndFrame(cfg, NewOverrides(logger, cfg.Watcher), Pipeline{}, nil, nil, []Recorder{audit})
+	removed := AuditRemovedFiles(audit)
+
+	events := []struct {
+		cb watcher.Callback
+		e  fsnotify.Event
+	}{
+		{cb: check, e: fsnotify.Event{Name: filepath.Join(dir, overrideFileName), Op: fsnotify.Create}},
+		{cb: removed, e: fsnotify.Event{Name: filepath.Join(dir, "a.jpg"), Op: fsnotify.Write}},
+		{cb: removed, e: fsnotify.Event{Name: filepath.Join(dir, "a.jpg"), Op: fsnotify.Rename}},
+		{cb: removed, e: fsnotify.Event{Name: filepath.Join(dir, "b.jpg"), Op: fsnotify.Remove}},
+	}
+
+	for _, ev := range events {
+		e := ev.e
+		if err := ev.cb(context.Background(), logger, watcher.Event{Event: &e}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	data, err := os.ReadFile(cfg.Audit.File)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		var entry AuditEntry
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("got audit entry not valid JSON: %v\n%s", err, line)
+		}
+
+		got = append(got, fmt.Sprintf("%s %s verdict=%q skipped=%v", entry.Op, filepath.Base(entry.Path), entry.Verdict, entry.Skipped))
+	}
+
+	want := []string{
+		`CREATE .watcher.yaml verdict="" skipped=true`,
+		`RENAME a.jpg verdict="" skipped=false`,
+		`REMOVE b.jpg verdict="" skipped=false`,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got unexpected audit entries,\nwant=%q\n got=%q", want, got)
+	}
+}
+
+// TestAuditSchema checks the documented schema describes every field of the
+// audit entries.
+func TestAuditSchema(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("..", "..", "docs", "audit-log.schema.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var schema struct {
+		Properties map[string]struct {
+			Const int      `json:"const"`
+			Enum  []string `json:"enum"`
+			Items struct {
+				Properties map[string]json.RawMessage `json:"properties"`
+			} `json:"items"`
+		} `json:"properties"`
+	}
+	if err := json.Unmarshal(data, &schema); err != nil {
+		t.Fatal(err)
+	}
+
+	fields := func(v interface{}) []string {
+		var names []string
+
+		typ := reflect.TypeOf(v)
+		for i := 0; i < typ.NumField(); i++ {
+			names = append(names, strings.Split(typ.Field(i).Tag.Get("json"), ",")[0])
+		}
+
+		sort.Strings(names)
+
+		return names
+	}
+
+	keys := func(m interface{}) []string {
+		var names []string
+		for _, k := range reflect.ValueOf(m).MapKeys() {
+			names = append(names, k.String())
+		}
+
+		sort.Strings(names)
+
+		return names
+	}
+
+	if got, want := keys(schema.Properties), fields(AuditEntry{}); !reflect.DeepEqual(got, want) {
+		t.Errorf("got schema properties not matching audit entry, want=%q, got=%q", want, got)
+	}
+
+	if got, want := keys(schema.Properties["actions"].Items.Properties), fields(ActionOutcome{}); !reflect.DeepEqual(got, want) {
+		t.Errorf("got schema action properties not matching action outcome, want=%q, got=%q", want, got)
+	}
+
+	if got := schema.Properties["version"].Const; got != auditVersion {
+		t.Errorf("got unexpected schema version, want=%d, got=%d", auditVersion, got)
+	}
+
+	var want []string
+	for _, v := range verdicts {
+		want = append(want, string(v))
+	}
+
+	if got := schema.Properties["verdict"].Enum; !reflect.DeepEqual(got, want) {
+		t.Errorf("got schema verdicts not matching, want=%q, got=%q", want, got)
+	}
+}
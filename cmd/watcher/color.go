@@ -0,0 +1,193 @@
+// Copyright (2023 -- present) Shahruk Hossain <shahruk10@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//		 http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// ==============================================================================
+
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/shahruk10/watcher/internal/imageinfo"
+	"github.com/shahruk10/watcher/internal/notify"
+)
+
+// ColorConfig declares the colour spaces images must be in, for the folders
+// of each printer.
+type ColorConfig struct {
+	Requirements []ColorRequirement `yaml:"requirements"`
+}
+
+func (cfg *ColorConfig) Validate() error {
+	for i := range cfg.Requirements {
+		if err := cfg.Requirements[i].Validate(); err != nil {
+			return fmt.Errorf("validate color: requirement[%d]: %w", i, err)
+		}
+	}
+
+	return nil
+}
+
+// ColorRequirement lists what is allowed for images in some folders. Empty
+// lists allow anything.
+type ColorRequirement struct {
+	// Name describes the requirement, e.g. the printer it is for.
+	Name string `yaml:"name"`
+	// Folders the requirement applies to, including the folders below them.
+	Folders []string `yaml:"folders"`
+
+	Formats     []string `yaml:"formats"`
+	ColorModels []string `yaml:"color_models"`
+	BitDepths   []int    `yaml:"bit_depths"`
+	// ICCProfiles are matched against the description of the embedded
+	// profile, ignoring case; any part of the description may match.
+	ICCProfiles []string `yaml:"icc_profiles"`
+}
+
+var (
+	knownFormats     = []string{imageinfo.FormatJPEG, imageinfo.FormatPNG, imageinfo.FormatTIFF}
+	knownColorModels = []string{imageinfo.ColorGray, imageinfo.ColorRGB, imageinfo.ColorCMYK, imageinfo.ColorIndexed, imageinfo.ColorLab}
+)
+
+func (r *ColorRequirement) Validate() error {
+	if len(r.Folders) == 0 {
+		return fmt.Errorf("folders must be specified")
+	}
+
+	for _, format := range r.Formats {
+		if !contains(knownFormats, format) {
+			return fmt.Errorf("unknown format %q, must be one of %s", format, strings.Join(knownFormats, ", "))
+		}
+	}
+
+	for _, model := range r.ColorModels {
+		if !contains(knownColorModels, model) {
+			return fmt.Errorf("unknown color model %q, must be one of %s", model, strings.Join(knownColorModels, ", "))
+		}
+	}
+
+	return nil
+}
+
+// appliesTo returns true if the requirement covers files in the folder.
+func (r *ColorRequirement) appliesTo(dir string) bool {
+	for _, folder := range r.Folders {
+		if isWithin(dir, absPath(folder)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// check returns a field for each way in which the image doesn't meet the
+// requirement.
+func (r *ColorRequirement) check(info *imageinfo.Info) []notify.Field {
+	problems := make([]notify.Field, 0)
+
+	mismatch := func(label, found string, expected []string) {
+		if found == "" {
+			found = "unknown"
+		}
+
+		problems = append(problems, notify.Field{
+			Label: "❌ " + label,
+			Value: fmt.Sprintf("%s, expected %s", found, strings.Join(expected, " or ")),
+		})
+	}
+
+	if len(r.Formats) > 0 && !contains(r.Formats, info.Format) {
+		mismatch("format", info.Format, r.Formats)
+	}
+
+	if len(r.ColorModels) > 0 && !contains(r.ColorModels, info.ColorModel) {
+		mismatch("color model", info.ColorModel, r.ColorModels)
+	}
+
+	if len(r.BitDepths) > 0 {
+		depths := make([]string, len(r.BitDepths))
+		for i, d := range r.BitDepths {
+			depths[i] = strconv.Itoa(d)
+		}
+
+		if !contains(depths, strconv.Itoa(info.BitDepth)) {
+			mismatch("bit depth", strconv.Itoa(info.BitDepth), depths)
+		}
+	}
+
+	if len(r.ICCProfiles) > 0 {
+		matched := false
+		for _, profile := range r.ICCProfiles {
+			matched = matched || (info.ICCProfile != "" && strings.Contains(strings.ToLower(info.ICCProfile), strings.ToLower(profile)))
+		}
+
+		if !matched {
+			quoted := make([]string, len(r.ICCProfiles))
+			for i, profile := range r.ICCProfiles {
+				quoted[i] = strconv.Quote(profile)
+			}
+
+			found := "none"
+			if info.ICCProfile != "" {
+				found = strconv.Quote(info.ICCProfile)
+			}
+
+			mismatch("ICC profile", found, quoted)
+		}
+	}
+
+	return problems
+}
+
+// checkColor returns true with the verdict set on the result if the image
+// doesn't meet the first colour requirement covering its folder.
+func checkColor(cfg ColorConfig, result *Result) bool {
+	dir := absPath(filepath.Dir(result.Path))
+
+	for i := range cfg.Requirements {
+		req := &cfg.Requirements[i]
+		if !req.appliesTo(dir) {
+			continue
+		}
+
+		problems := req.check(result.Image)
+		if len(problems) == 0 {
+			return false
+		}
+
+		result.Verdict = VerdictWrongColor
+		result.Title = "WRONG COLOR SPACE"
+		result.Fields = append([]notify.Field{{Label: "📁 file", Value: filepath.Base(result.Path)}}, problems...)
+
+		if req.Name != "" {
+			result.Fields = append(result.Fields, notify.Field{Label: "🖨 requirement", Value: req.Name})
+		}
+
+		return true
+	}
+
+	return false
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/shahruk10/watcher/internal/imageinfo"
+)
+
+func TestCheckColor(t *testing.T) {
+	root := t.TempDir()
+
+	cfg := ColorConfig{Requirements: []ColorRequirement{
+		{
+			Name:        "konica",
+			Folders:     []string{filepath.Join(root, "konica")},
+			Formats:     []string{imageinfo.FormatTIFF},
+			ColorModels: []string{imageinfo.ColorCMYK},
+			BitDepths:   []int{8},
+			ICCProfiles: []string{"fogra39"},
+		},
+		{
+			Name:        "mimaki",
+			Folders:     []string{filepath.Join(root, "mimaki")},
+			ColorModels: []string{imageinfo.ColorRGB},
+			ICCProfiles: []string{"sRGB"},
+		},
+	}}
+
+	if err := cfg.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	cmyk := &imageinfo.Info{Format: imageinfo.FormatTIFF, ColorModel: imageinfo.ColorCMYK, BitDepth: 8, ICCProfile: "Coated FOGRA39 (ISO 12647-2:2004)"}
+	rgb := &imageinfo.Info{Format: imageinfo.FormatJPEG, ColorModel: imageinfo.ColorRGB, BitDepth: 8}
+
+	tests := []struct {
+		name       string
+		dir        string
+		image      *imageinfo.Info
+		wantFields int
+	}{
+		{name: "cmyk for konica", dir: "konica/12x12", image: cmyk},
+		{name: "rgb for konica", dir: "konica/12x12", image: rgb, wantFields: 3},
+		{name: "rgb without profile for mimaki", dir: "mimaki/8x10", image: rgb, wantFields: 1},
+		{name: "no requirement", dir: "other/8x10", image: rgb},
+		{name: "similar folder name", dir: "konica2/8x10", image: rgb},
+	}
+
+	for _, tc := range tests {
+		result := &Result{Path: filepath.Join(root, tc.dir, "order_fr_8x10.jpg"), Image: tc.image}
+		found := checkColor(cfg, result)
+
+		if found != (tc.wantFields > 0) {
+			t.Errorf("%s: got unexpected result, want=%v, got=%v", tc.name, tc.wantFields > 0, found)
+			continue
+		}
+
+		if !found {
+			continue
+		}
+
+		// The file and requirement name are listed along with each problem.
+		if result.Verdict != VerdictWrongColor || len(result.Fields) != tc.wantFields+2 {
+			t.Errorf("%s: got unexpected verdict, got=%q: %q", tc.name, result.Verdict, result.Message())
+		}
+	}
+
+	result := &Result{Path: filepath.Join(root, "mimaki", "a.jpg"), Image: rgb}
+	checkColor(cfg, result)
+
+	want := `❌ ICC profile: none, expected "sRGB"`
+	if got := result.Fields[1].Label + ": " + result.Fields[1].Value; got != want {
+		t.Errorf("got unexpected description of problem, want=%q, got=%q", want, got)
+	}
+}
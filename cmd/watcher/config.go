@@ -19,21 +19,119 @@ import (
 	"fmt"
 	"regexp"
 	"strings"
+	"time"
 
+	"github.com/shahruk10/watcher/internal/notify"
 	"github.com/shahruk10/watcher/internal/watcher"
+	"github.com/shahruk10/watcher/internal/webhook"
+	"github.com/sirupsen/logrus"
+)
+
+// Run modes.
+const (
+	modeDesktop = "desktop"
+	modeDaemon  = "daemon"
 )
 
 type Config struct {
-	Watcher  watcher.Config `yaml:"watcher"`
-	Metadata Metadata       `yaml:"metadata"`
-	Debug    bool           `yaml:"debug"`
+	// Mode is either "desktop" (the default) or "daemon", which is the same as
+	// running with --headless.
+	Mode string `yaml:"mode"`
+
+	Watcher    watcher.Config   `yaml:"watcher"`
+	Metadata   Metadata         `yaml:"metadata"`
+	Move       MoveConfig       `yaml:"move"`
+	Quarantine QuarantineConfig `yaml:"quarantine"`
+	Image      ImageConfig      `yaml:"image"`
+	Color      ColorConfig      `yaml:"color"`
+	PDF        PDFConfig        `yaml:"pdf"`
+	Integrity  IntegrityConfig  `yaml:"integrity"`
+	Sets       SetsConfig       `yaml:"sets"`
+	Scaffold   ScaffoldConfig   `yaml:"scaffold"`
+	Exec       ExecConfig       `yaml:"exec"`
+	Webhook    WebhookConfig    `yaml:"webhook"`
+	Violations ViolationsConfig `yaml:"violations"`
+	Audit      AuditConfig      `yaml:"audit"`
+	History    HistoryConfig    `yaml:"history"`
+	Debug      bool             `yaml:"debug"`
+
+	// Actions lists the actions to carry out for each verdict.
+	Actions map[Verdict][]ActionConfig `yaml:"actions"`
+
+	// Notifiers alerts can be delivered to, and which of them are used for
+	// each verdict.
+	Notifiers []NotifierConfig    `yaml:"notifiers"`
+	Routing   map[string][]string `yaml:"routing"`
+	Alerts    AlertsConfig        `yaml:"alerts"`
+
+	// Examples are checked against the name patterns when loading the config.
+	Examples []Example `yaml:"examples"`
 }
 
 func (cfg *Config) Validate() error {
+	if cfg.Mode != "" && cfg.Mode != modeDesktop && cfg.Mode != modeDaemon {
+		return fmt.Errorf("validate config: unknown mode %q", cfg.Mode)
+	}
+
 	if err := cfg.Metadata.Validate(); err != nil {
 		return err
 	}
 
+	for i := range cfg.Examples {
+		if err := cfg.Examples[i].Check(cfg.Metadata); err != nil {
+			return fmt.Errorf("validate examples: example[%d]: %w", i, err)
+		}
+	}
+
+	if err := cfg.Quarantine.Validate(); err != nil {
+		return err
+	}
+
+	if err := cfg.Color.Validate(); err != nil {
+		return err
+	}
+
+	if err := cfg.Scaffold.Validate(); err != nil {
+		return err
+	}
+
+	if err := cfg.Audit.Validate(); err != nil {
+		return err
+	}
+
+	if err := cfg.History.Validate(); err != nil {
+		return err
+	}
+
+	// Files still being copied would otherwise be flagged as truncated.
+	if cfg.Integrity.Enabled && cfg.Watcher.Settle <= 0 {
+		return fmt.Errorf("validate integrity: watcher settle duration must be set")
+	}
+
+	if err := cfg.Webhook.Validate(); err != nil {
+		return err
+	}
+
+	if err := validateNotifiers(cfg.Notifiers, cfg.Routing); err != nil {
+		return err
+	}
+
+	for v, actions := range cfg.Actions {
+		if !isKnownVerdict(v) {
+			return fmt.Errorf("validate actions: unknown verdict %q", v)
+		}
+
+		for i := range actions {
+			if err := actions[i].Validate(); err != nil {
+				return fmt.Errorf("validate actions: %s action[%d]: %w", v, i, err)
+			}
+
+			if actions[i].Type == actionWebhook && actions[i].URL == "" && len(cfg.Webhook.URLs) == 0 {
+				return fmt.Errorf("validate actions: %s action[%d]: url must be specified if no webhook urls are configured", v, i)
+			}
+		}
+	}
+
 	return cfg.Watcher.Validate()
 }
 
@@ -41,6 +139,7 @@ type Metadata struct {
 	FrameType2Name     map[string][]string `yaml:"frame_type_mapping"`
 	FolderNamePatterns []string            `yaml:"folder_name_patterns"`
 	FileNamePatterns   []string            `yaml:"file_name_patterns"`
+	Embedded           EmbeddedConfig      `yaml:"embedded"`
 }
 
 func (cfg *Metadata) Validate() error {
@@ -66,5 +165,148 @@ func (cfg *Metadata) Validate() error {
 		return fmt.Errorf("validate metadata: frame_type_mapping must be specified")
 	}
 
+	return cfg.Embedded.Validate()
+}
+
+// MoveConfig controls moving misplaced files to their correct folder.
+type MoveConfig struct {
+	Enabled bool   `yaml:"enabled"`
+	DryRun  bool   `yaml:"dry_run"`
+	Journal string `yaml:"journal"`
+}
+
+func (cfg *MoveConfig) JournalPath() string {
+	if cfg.Journal == "" {
+		return defaultJournalPath
+	}
+
+	return cfg.Journal
+}
+
+// QuarantineConfig controls moving files with names that can't be parsed out
+// of the watched folders.
+type QuarantineConfig struct {
+	Enabled bool   `yaml:"enabled"`
+	Dir     string `yaml:"dir"`
+}
+
+func (cfg *QuarantineConfig) Validate() error {
+	if cfg.Enabled && cfg.Dir == "" {
+		return fmt.Errorf("validate quarantine: quarantine folder must be specified")
+	}
+
 	return nil
 }
+
+// ExecConfig holds settings shared by all exec actions.
+type ExecConfig struct {
+	// MaxConcurrent limits how many commands run at once.
+	MaxConcurrent int `yaml:"max_concurrent"`
+}
+
+func (cfg *ExecConfig) MaxConcurrentOrDefault() int {
+	if cfg.MaxConcurrent <= 0 {
+		return 4
+	}
+
+	return cfg.MaxConcurrent
+}
+
+// WebhookConfig configures delivery of verdicts to webhooks.
+type WebhookConfig struct {
+	webhook.Config `yaml:",inline"`
+
+	// Verdicts which are posted to the configured URLs.
+	Verdicts []Verdict `yaml:"verdicts"`
+}
+
+func (cfg *WebhookConfig) Validate() error {
+	for _, v := range cfg.Verdicts {
+		if !isKnownVerdict(v) {
+			return fmt.Errorf("validate webhook: unknown verdict %q", v)
+		}
+	}
+
+	if len(cfg.Verdicts) > 0 && len(cfg.URLs) == 0 {
+		return fmt.Errorf("validate webhook: urls must be specified")
+	}
+
+	return nil
+}
+
+// Posts returns true if the verdict should be posted to the configured URLs.
+func (cfg *WebhookConfig) Posts(v Verdict) bool {
+	for _, posted := range cfg.Verdicts {
+		if posted == v {
+			return true
+		}
+	}
+
+	return false
+}
+
+// webhooksEnabled returns true if any webhooks are used.
+func (cfg *Config) webhooksEnabled() bool {
+	if len(cfg.Webhook.URLs) > 0 {
+		return true
+	}
+
+	for _, actions := range cfg.Actions {
+		for _, ac := range actions {
+			if ac.Type == actionWebhook {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// AlertsConfig controls how alerts are shown on dialog and console notifiers.
+type AlertsConfig struct {
+	// AggregateWindow is how long to wait for more alerts for the same
+	// verdict and folder, after showing the first, before showing them in a
+	// single summary; each alert extends the wait. Alerts offering choices are
+	// still shown immediately. Zero turns aggregation off.
+	AggregateWindow time.Duration `yaml:"aggregate_window"`
+	// MaxListed limits how many files a summary lists.
+	MaxListed int `yaml:"max_listed"`
+
+	// Snooze is how long to wait before raising the same alert for a file
+	// again, unless the file changes. Zero turns snoozing off.
+	Snooze time.Duration `yaml:"snooze"`
+	// MuteFor is how long alerts are muted for when muting a file or folder
+	// from a dialog or the command line.
+	MuteFor time.Duration `yaml:"mute_for"`
+	// StateFile is where raised alerts and mutes are kept across restarts.
+	StateFile string `yaml:"state_file"`
+}
+
+func (cfg *AlertsConfig) StatePath() string {
+	if cfg.StateFile == "" {
+		return defaultAlertStatePath
+	}
+
+	return cfg.StateFile
+}
+
+func (cfg *AlertsConfig) MuteForOrDefault() time.Duration {
+	if cfg.MuteFor <= 0 {
+		return 8 * time.Hour
+	}
+
+	return cfg.MuteFor
+}
+
+func (cfg *AlertsConfig) aggregate(logger *logrus.Logger, n notify.Notifier) notify.Notifier {
+	if cfg.AggregateWindow <= 0 {
+		return n
+	}
+
+	maxListed := cfg.MaxListed
+	if maxListed <= 0 {
+		maxListed = 20
+	}
+
+	return notify.NewAggregator(logger, n, cfg.AggregateWindow, maxListed)
+}
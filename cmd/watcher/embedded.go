@@ -0,0 +1,174 @@
+// Copyright (2023 -- present) Shahruk Hossain <shahruk10@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//		 http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// ==============================================================================
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/shahruk10/watcher/internal/imageinfo"
+	"github.com/shahruk10/watcher/internal/notify"
+	"github.com/sirupsen/logrus"
+)
+
+// Where file attributes are taken from.
+const (
+	sourceName     = "name"
+	sourceMetadata = "metadata"
+	sourceAgree    = "agree"
+)
+
+// EmbeddedConfig controls reading file attributes from the EXIF and XMP
+// metadata embedded in images, as well as from their names.
+type EmbeddedConfig struct {
+	// Source is "name" (the default) to only parse the file name, "metadata"
+	// to prefer embedded attributes over those in the file name, or "agree"
+	// to require that both are present and the same.
+	Source string `yaml:"source"`
+
+	// Keys maps attribute names to the metadata properties holding them, e.g.
+	// "xmp:ws:FrameType" or "exif:ImageDescription".
+	Keys map[string]string `yaml:"keys"`
+}
+
+func (cfg *EmbeddedConfig) Validate() error {
+	switch cfg.Source {
+	case "", sourceName:
+		return nil
+	case sourceMetadata, sourceAgree:
+	default:
+		return fmt.Errorf("validate metadata: unknown embedded source %q", cfg.Source)
+	}
+
+	if len(cfg.Keys) == 0 {
+		return fmt.Errorf("validate metadata: embedded keys must be specified")
+	}
+
+	for attr, key := range cfg.Keys {
+		if attr != attrFrameSize && attr != attrFrameType {
+			return fmt.Errorf("validate metadata: unknown embedded attribute %q", attr)
+		}
+
+		if !strings.HasPrefix(key, "exif:") && !strings.HasPrefix(key, "xmp:") {
+			return fmt.Errorf("validate metadata: embedded key %q must start with \"exif:\" or \"xmp:\"", key)
+		}
+	}
+
+	return nil
+}
+
+func (cfg *EmbeddedConfig) Enabled() bool {
+	return cfg.Source == sourceMetadata || cfg.Source == sourceAgree
+}
+
+// metadataMismatchError is returned when the attributes embedded in a file
+// don't agree with those in its name; it holds the alert to show for it.
+type metadataMismatchError struct {
+	fields []notify.Field
+}
+
+func (e *metadataMismatchError) Error() string {
+	n := notify.Notification{Fields: e.fields}
+	return fmt.Sprintf("METADATA MISMATCH: %s", n.Message())
+}
+
+// getEmbeddedAttributes returns the configured attributes found in the
+// metadata embedded in the file.
+func getEmbeddedAttributes(logger *logrus.Logger, cfg EmbeddedConfig, filePath string) map[string]string {
+	attr := make(map[string]string)
+
+	md, err := imageinfo.ReadMetadataFile(filePath)
+	if errors.Is(err, imageinfo.ErrUnsupported) {
+		return attr
+	} else if err != nil {
+		logger.Debugf("not reading metadata of %q: %v", filePath, err)
+		return attr
+	}
+
+	for name, key := range cfg.Keys {
+		if value := md[key]; value != "" {
+			attr[name] = strings.ToLower(strings.TrimSpace(value))
+		}
+	}
+
+	logger.Debugf("embedded attributes for %q: %v", filePath, attr)
+
+	return attr
+}
+
+// resolveFileAttributes returns the attributes of the file from its name, its
+// embedded metadata or both, depending on the configured source.
+func resolveFileAttributes(logger *logrus.Logger, cfg EmbeddedConfig, filePath string, fileNamePatterns []string) (map[string]string, error) {
+	if !cfg.Enabled() {
+		return getFileAttributes(logger, filePath, fileNamePatterns)
+	}
+
+	embedded := getEmbeddedAttributes(logger, cfg, filePath)
+	attr, err := getFileAttributes(logger, filePath, fileNamePatterns)
+
+	if cfg.Source == sourceMetadata {
+		// Embedded attributes are trusted, so the file name only needs to
+		// provide those missing from the metadata.
+		if err != nil {
+			if embedded[attrFrameSize] == "" || embedded[attrFrameType] == "" {
+				return nil, err
+			}
+
+			return embedded, nil
+		}
+
+		for name, value := range embedded {
+			if attr[name] != value {
+				logger.Infof("using %s %q from metadata of %q instead of %q from its name", name, value, filePath, attr[name])
+			}
+
+			attr[name] = value
+		}
+
+		return attr, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(cfg.Keys))
+	for name := range cfg.Keys {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	var fields []notify.Field
+	for _, name := range names {
+		switch value, ok := embedded[name]; {
+		case !ok:
+			fields = append(fields, notify.Field{Label: "❌ " + name, Value: fmt.Sprintf("missing from %s", cfg.Keys[name])})
+		case value != attr[name]:
+			fields = append(fields, notify.Field{Label: "❌ " + name, Value: fmt.Sprintf("%s in name, %s in %s", attr[name], value, cfg.Keys[name])})
+		}
+	}
+
+	if len(fields) > 0 {
+		fields = append([]notify.Field{{Label: "📁 file", Value: filepath.Base(filePath)}}, fields...)
+		return nil, &metadataMismatchError{fields: fields}
+	}
+
+	return attr, nil
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/jpeg"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+// writeJPEGWithXMP writes a JPEG with an XMP packet declaring the frame type
+// and size.
+func writeJPEGWithXMP(t *testing.T, path, frameType, frameSize string) {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, image.NewGray(image.Rect(0, 0, 4, 4)), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	packet := []byte(`http://ns.adobe.com/xap/1.0/` + "\x00" +
+		`<x:xmpmeta xmlns:x="adobe:ns:meta/"><rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#">` +
+		`<rdf:Description xmlns:ws="http://example.com/ws/1.0/" ws:FrameType="` + frameType + `" ws:FrameSize="` + frameSize + `"/>` +
+		`</rdf:RDF></x:xmpmeta>`)
+
+	segment := []byte{0xff, 0xe1, 0, 0}
+	binary.BigEndian.PutUint16(segment[2:], uint16(len(packet)+2))
+
+	jpg := buf.Bytes()
+	data := append(append(append(append([]byte{}, jpg[:2]...), segment...), packet...), jpg[2:]...)
+
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestResolveFileAttributes(t *testing.T) {
+	dir := t.TempDir()
+	logger := logrus.New()
+	patterns := []string{`^([^_]+)_(?P<frame_type>[^_]+)_(?P<frame_size>\d+x\d+).*$`}
+	keys := map[string]string{attrFrameType: "xmp:ws:FrameType", attrFrameSize: "xmp:ws:FrameSize"}
+
+	matching, mismatched, unnamed := filepath.Join(dir, "a_fr_8x10.jpg"), filepath.Join(dir, "b_fr_8x10.jpg"), filepath.Join(dir, "c.jpg")
+	writeJPEGWithXMP(t, matching, "FR", "8x10")
+	writeJPEGWithXMP(t, mismatched, "wd", "8x10")
+	writeJPEGWithXMP(t, unnamed, "wd", "12x12")
+
+	tests := []struct {
+		name         string
+		source       string
+		path         string
+		wantType     string
+		wantInvalid  bool
+		wantMismatch bool
+	}{
+		{name: "name only", source: sourceName, path: mismatched, wantType: "fr"},
+		{name: "metadata preferred", source: sourceMetadata, path: mismatched, wantType: "wd"},
+		{name: "metadata without name", source: sourceMetadata, path: unnamed, wantType: "wd"},
+		{name: "agreeing", source: sourceAgree, path: matching, wantType: "fr"},
+		{name: "disagreeing", source: sourceAgree, path: mismatched, wantMismatch: true},
+		{name: "agree without name", source: sourceAgree, path: unnamed, wantInvalid: true},
+	}
+
+	for _, tc := range tests {
+		cfg := EmbeddedConfig{Source: tc.source, Keys: keys}
+		if err := cfg.Validate(); err != nil {
+			t.Fatal(err)
+		}
+
+		attr, err := resolveFileAttributes(logger, cfg, tc.path, patterns)
+
+		invalid, mismatch := (*invalidNameError)(nil), (*metadataMismatchError)(nil)
+		if errors.As(err, &invalid) != tc.wantInvalid || errors.As(err, &mismatch) != tc.wantMismatch {
+			t.Errorf("%s: got unexpected error, got=%v", tc.name, err)
+			continue
+		}
+
+		if err == nil && attr[attrFrameType] != tc.wantType {
+			t.Errorf("%s: got unexpected frame type, want=%q, got=%q", tc.name, tc.wantType, attr[attrFrameType])
+		}
+	}
+}
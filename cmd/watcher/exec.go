@@ -0,0 +1,165 @@
+// Copyright (2023 -- present) Shahruk Hossain <shahruk10@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//		 http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// ==============================================================================
+
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const defaultExecTimeout = time.Minute
+
+func newExecAction(ac ActionConfig, slots chan struct{}) Action {
+	command, _ := parseTemplate(ac.Command)
+
+	args := make([]*template.Template, 0, len(ac.Args))
+	for _, a := range ac.Args {
+		t, _ := parseTemplate(a)
+		args = append(args, t)
+	}
+
+	// Sort environment variable names so they are always set in the same order.
+	envNames := make([]string, 0, len(ac.Env))
+	for name := range ac.Env {
+		envNames = append(envNames, name)
+	}
+
+	sort.Strings(envNames)
+
+	env := make([]*template.Template, 0, len(envNames))
+	for _, name := range envNames {
+		t, _ := parseTemplate(ac.Env[name])
+		env = append(env, t)
+	}
+
+	timeout := ac.Timeout
+	if timeout <= 0 {
+		timeout = defaultExecTimeout
+	}
+
+	return func(ctx context.Context, logger *logrus.Logger, r *Result) error {
+		name, err := render(command, r)
+		if err != nil {
+			return err
+		}
+
+		argv := make([]string, 0, len(args))
+		for _, a := range args {
+			arg, err := render(a, r)
+			if err != nil {
+				return err
+			}
+
+			argv = append(argv, arg)
+		}
+
+		environ := append(os.Environ(),
+			"WATCHER_PATH="+r.Path,
+			"WATCHER_OP="+r.Op,
+			"WATCHER_VERDICT="+string(r.Verdict),
+			"WATCHER_CORRECT_FOLDER="+r.CorrectDirName(),
+		)
+
+		for i, name := range envNames {
+			value, err := render(env[i], r)
+			if err != nil {
+				return err
+			}
+
+			environ = append(environ, name+"="+value)
+		}
+
+		select {
+		case slots <- struct{}{}:
+			defer func() { <-slots }()
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+
+		// Output is captured in files rather than pipes, so that processes left
+		// behind by a killed command can't keep it from returning.
+		stdout, err := os.CreateTemp("", "watcher-exec-*.out")
+		if err != nil {
+			return fmt.Errorf("capture output: %w", err)
+		}
+		defer os.Remove(stdout.Name())
+		defer stdout.Close()
+
+		stderr, err := os.CreateTemp("", "watcher-exec-*.err")
+		if err != nil {
+			return fmt.Errorf("capture output: %w", err)
+		}
+		defer os.Remove(stderr.Name())
+		defer stderr.Close()
+
+		cmd := exec.CommandContext(ctx, name, argv...)
+		cmd.Env = environ
+		cmd.Stdout = stdout
+		cmd.Stderr = stderr
+
+		start := time.Now()
+		err = cmd.Run()
+
+		cmdLogger := logger.WithFields(logrus.Fields{"command": name, "file": r.Path})
+		logOutput(cmdLogger.WithField("stream", "stdout"), stdout)
+		logOutput(cmdLogger.WithField("stream", "stderr"), stderr)
+
+		if ctx.Err() == context.DeadlineExceeded {
+			err = fmt.Errorf("timed out after %s", timeout)
+		}
+
+		if err == nil {
+			cmdLogger.Debugf("command finished in %s", time.Since(start))
+			return nil
+		}
+
+		err = fmt.Errorf("run %q: %w", name, err)
+		if ac.FailOnError {
+			return err
+		}
+
+		cmdLogger.Warn(err)
+
+		return nil
+	}
+}
+
+func logOutput(logger *logrus.Entry, output *os.File) {
+	if _, err := output.Seek(0, io.SeekStart); err != nil {
+		logger.Errorf("read output: %v", err)
+		return
+	}
+
+	scanner := bufio.NewScanner(output)
+	for scanner.Scan() {
+		if line := strings.TrimRight(scanner.Text(), "\r"); line != "" {
+			logger.Info(line)
+		}
+	}
+}
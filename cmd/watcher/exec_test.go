@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestExecAction(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test uses a POSIX shell")
+	}
+
+	out := filepath.Join(t.TempDir(), "out.txt")
+	result := &Result{
+		Path:     "/hot/12x12/o1_cn_8x10.jpg",
+		Op:       "CREATE",
+		Verdict:  VerdictWrongFolder,
+		FileAttr: map[string]string{attrFrameSize: "8x10", attrFrameType: "cn"},
+	}
+
+	slots := make(chan struct{}, 1)
+	logger := logrus.New()
+
+	action := newExecAction(ActionConfig{
+		Type:    actionExec,
+		Command: "sh",
+		Args:    []string{"-c", `echo "$1 $SIZE $WATCHER_VERDICT" > ` + out, "--", "{{.Name}}"},
+		Env:     map[string]string{"SIZE": "{{.File.frame_size}}"},
+	}, slots)
+
+	if err := action(context.Background(), logger, result); err != nil {
+		t.Fatalf("got unexpected error running command, want=nil, got=%v", err)
+	}
+
+	want := "o1_cn_8x10.jpg 8x10 wrong_folder\n"
+	if got, _ := os.ReadFile(out); string(got) != want {
+		t.Errorf("got unexpected command output, want=%q, got=%q", want, got)
+	}
+
+	testCases := []struct {
+		Name        string
+		Args        []string
+		FailOnError bool
+		WantErr     bool
+	}{
+		{"ignored failure", []string{"-c", "exit 1"}, false, false},
+		{"reported failure", []string{"-c", "exit 1"}, true, true},
+		{"timeout", []string{"-c", "sleep 5"}, true, true},
+	}
+
+	for _, tc := range testCases {
+		action := newExecAction(ActionConfig{
+			Type:        actionExec,
+			Command:     "sh",
+			Args:        tc.Args,
+			Timeout:     100 * time.Millisecond,
+			FailOnError: tc.FailOnError,
+		}, slots)
+
+		err := action(context.Background(), logger, result)
+		if gotErr := err != nil; gotErr != tc.WantErr {
+			t.Errorf("got unexpected error for %q, want error=%v, got=%v", tc.Name, tc.WantErr, err)
+		}
+	}
+}
@@ -0,0 +1,210 @@
+// Copyright (2023 -- present) Shahruk Hossain <shahruk10@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//		 http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// ==============================================================================
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/peterbourgon/ff/v3/ffcli"
+	"github.com/sirupsen/logrus"
+)
+
+func newExplainCmd(logger *logrus.Logger, cfgPath *string, mode *runMode) *ffcli.Command {
+	explainFlagSet := flag.NewFlagSet("watcher explain", flag.ExitOnError)
+
+	return &ffcli.Command{
+		Name:       "explain",
+		ShortUsage: "watcher [flags] explain <path>...",
+		ShortHelp:  "Explain how the watcher validates a file.",
+		LongHelp: "Shows the file and folder name patterns tried for each path, which one matched and its capture " +
+			"groups, the attributes extracted, the frame type mapping and the verdict. The files need not exist.",
+		FlagSet: explainFlagSet,
+		Exec: func(ctx context.Context, args []string) error {
+			mode.headless = true
+
+			if len(args) == 0 {
+				return &configError{fmt.Errorf("explain: no paths given")}
+			}
+
+			cfg, err := loadConfig(logger, *cfgPath)
+			if err != nil {
+				return err
+			}
+
+			overrides := NewOverrides(logger, cfg.Watcher)
+
+			for i, path := range args {
+				if i > 0 {
+					fmt.Fprintln(os.Stdout)
+				}
+
+				if err := explain(os.Stdout, logger, cfg, overrides, path); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		},
+	}
+}
+
+// explain writes out each step of validating the file at path.
+func explain(w io.Writer, logger *logrus.Logger, cfg Config, overrides *Overrides, path string) error {
+	dir := filepath.Dir(path)
+	override := overrides.Resolve(dir)
+
+	fmt.Fprintf(w, "File:   %s\n", path)
+	fmt.Fprintf(w, "Folder: %s\n", dir)
+
+	if len(override.Disable) > 0 {
+		fmt.Fprintf(w, "Validators disabled by override files: %s\n", strings.Join(override.Disable, ", "))
+	}
+
+	fileNamePatterns := append(append([]string{}, override.FileNamePatterns...), cfg.Metadata.FileNamePatterns...)
+	fileName := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+
+	fmt.Fprintf(w, "\nFile name %q:\n", fileName)
+	explainPatterns(w, fileName, fileNamePatterns)
+
+	if cfg.Metadata.Embedded.Enabled() && !override.Disabled(validatorMetadata) {
+		fmt.Fprintf(w, "\nEmbedded metadata (source %q):\n", cfg.Metadata.Embedded.Source)
+		explainAttributes(w, getEmbeddedAttributes(logger, cfg.Metadata.Embedded, path))
+	}
+
+	fileAttr, err := getFileAttributes(logger, path, fileNamePatterns)
+	if err == nil {
+		fmt.Fprintln(w, "\nFile attributes:")
+		explainAttributes(w, fileAttr)
+	}
+
+	dirName := filepath.Base(dir)
+	if attr, ok := override.FolderAttributes(); ok {
+		fmt.Fprintf(w, "\nFolder attributes declared by override file:\n")
+		explainAttributes(w, attr)
+	} else {
+		fmt.Fprintf(w, "\nFolder name %q:\n", dirName)
+		explainPatterns(w, dirName, cfg.Metadata.FolderNamePatterns)
+
+		if attr, err := getFolderAttributes(logger, dir, cfg.Metadata.FolderNamePatterns); err == nil {
+			fmt.Fprintln(w, "\nFolder attributes:")
+			explainAttributes(w, attr)
+		}
+	}
+
+	if frameType := fileAttr[attrFrameType]; err == nil {
+		fmt.Fprintf(w, "\nFrame type mapping for %q:\n", frameType)
+		if names, ok := cfg.Metadata.FrameType2Name[frameType]; ok {
+			quoted := make([]string, 0, len(names))
+			for _, name := range names {
+				quoted = append(quoted, fmt.Sprintf("%q", name))
+			}
+
+			fmt.Fprintf(w, "  folder frame type must be one of %s\n", strings.Join(quoted, ", "))
+		} else {
+			fmt.Fprintln(w, "  not in frame_type_mapping")
+		}
+	}
+
+	result, err := checkFile(logger, cfg, overrides, path)
+	if err != nil {
+		return fmt.Errorf("explain %q: %w", path, err)
+	}
+
+	if result == nil {
+		fmt.Fprintln(w, "\nVerdict: not validated")
+		return nil
+	}
+
+	fmt.Fprintf(w, "\nVerdict: %s (%s)\n", result.Verdict, result.Title)
+	for _, field := range result.Fields {
+		fmt.Fprintf(w, "  %s: %s\n", field.Label, field.Value)
+	}
+
+	if len(result.CorrectDirNames) > 0 {
+		fmt.Fprintf(w, "Correct folder: %s\n", result.CorrectDirName())
+	}
+
+	return nil
+}
+
+// explainPatterns writes out whether each pattern matches the name, which of
+// them the watcher uses, and its capture groups.
+func explainPatterns(w io.Writer, name string, patterns []string) {
+	if len(patterns) == 0 {
+		fmt.Fprintln(w, "  no patterns configured")
+		return
+	}
+
+	matched := matchedPattern(name, patterns)
+
+	for i, pattern := range patterns {
+		re := regexp.MustCompile(pattern)
+		used := i+1 == matched
+
+		matches := re.FindStringSubmatch(name)
+
+		status := "no match"
+		switch {
+		case used:
+			status = "matched, used"
+		case matches != nil:
+			status = "matched, not used"
+		}
+
+		fmt.Fprintf(w, "  [%d] %s\n      %s\n", i+1, pattern, status)
+
+		if !used {
+			continue
+		}
+
+		for j, groupName := range re.SubexpNames() {
+			if j == 0 {
+				continue
+			}
+
+			if groupName == "" {
+				groupName = fmt.Sprintf("group %d", j)
+			}
+
+			fmt.Fprintf(w, "        %s: %q\n", groupName, matches[j])
+		}
+	}
+}
+
+func explainAttributes(w io.Writer, attr map[string]string) {
+	names := make([]string, 0, len(attr))
+	for name := range attr {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		fmt.Fprintf(w, "  %s: %q\n", name, attr[name])
+	}
+
+	if len(names) == 0 {
+		fmt.Fprintln(w, "  none")
+	}
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/shahruk10/watcher/internal/watcher"
+	"github.com/sirupsen/logrus"
+)
+
+func TestExplain(t *testing.T) {
+	root := t.TempDir()
+	logger := logrus.New()
+
+	cfg := Config{
+		Watcher: watcher.Config{IncludeFolders: []string{filepath.Join(root, "*")}},
+		Metadata: Metadata{
+			FrameType2Name:     map[string][]string{"fr": {"framed"}},
+			FolderNamePatterns: []string{`^(?P<frame_size>\d+x\d+) (?P<frame_type>framed)$`},
+			FileNamePatterns: []string{
+				`^(?P<frame_size>\d+x\d+)$`,
+				`^([^_]+)_(?P<frame_type>[^_]+)_(?P<frame_size>\d+x\d+).*$`,
+			},
+		},
+	}
+
+	// The file need not exist.
+	path := filepath.Join(root, "8x10 framed", "1_fr_11x14.jpg")
+
+	var out bytes.Buffer
+	if err := explain(&out, logger, cfg, NewOverrides(logger, cfg.Watcher), path); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{
+		"[1] ^(?P<frame_size>\\d+x\\d+)$\n      no match",
+		"matched, used\n        group 1: \"1\"\n        frame_type: \"fr\"\n        frame_size: \"11x14\"",
+		"folder frame type must be one of \"framed\"",
+		"Verdict: " + string(VerdictWrongFolder),
+		"Correct folder: 11x14 framed",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("explanation missing %q:\n%s", want, out.String())
+		}
+	}
+}
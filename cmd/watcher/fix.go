@@ -0,0 +1,275 @@
+// Copyright (2023 -- present) Shahruk Hossain <shahruk10@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//		 http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// ==============================================================================
+
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+	"time"
+
+	"github.com/peterbourgon/ff/v3/ffcli"
+	"github.com/shahruk10/watcher/internal/fileutil"
+	"github.com/sirupsen/logrus"
+)
+
+// fixMove is a move of a misplaced file to its correct folder, proposed by
+// the fix subcommand. Moves which can't be carried out safely have a reason
+// to skip them instead of a destination.
+type fixMove struct {
+	From string
+	To   string
+	Skip string
+}
+
+func newFixCmd(logger *logrus.Logger, cfgPath *string, mode *runMode) *ffcli.Command {
+	var (
+		fixFlagSet  = flag.NewFlagSet("watcher fix", flag.ExitOnError)
+		applyFlag   = fixFlagSet.Bool("apply", false, "Carry out the moves instead of only showing them.")
+		journalFlag = fixFlagSet.String("journal", "", "Journal to record the moves in; a new one is created by default.")
+	)
+
+	return &ffcli.Command{
+		Name:       "fix",
+		ShortUsage: "watcher [flags] fix [-apply] [-journal <file>] [<path>...]",
+		ShortHelp:  "Move every file in the wrong folder to its correct folder.",
+		LongHelp: "Finds the files in the wrong folder among the watched folders, or the given files and folders (and " +
+			"the folders below them), and shows where each would be moved. Files which could belong in more than " +
+			"one folder, or whose correct folder doesn't exist, are skipped. The moves are only carried out with " +
+			"-apply, and are recorded in a journal which can be passed to undo.",
+		FlagSet: fixFlagSet,
+		Exec: func(ctx context.Context, args []string) error {
+			mode.headless = true
+
+			cfg, err := loadConfig(logger, *cfgPath)
+			if err != nil {
+				return err
+			}
+
+			files, err := filesToScan(cfg, args)
+			if err != nil {
+				return err
+			}
+
+			results, failed := scan(ctx, logger, cfg, files)
+			moves := planFixes(results)
+
+			if err := writeFixPlan(os.Stdout, moves); err != nil {
+				return err
+			}
+
+			if failed > 0 {
+				return fmt.Errorf("failed to check %d files", failed)
+			}
+
+			if !*applyFlag {
+				logger.Infof("found %d misplaced files; run with -apply to move them", len(moves))
+				return nil
+			}
+
+			journalPath := *journalFlag
+			if journalPath == "" {
+				journalPath = fixJournalPath(cfg.Move, time.Now())
+			}
+
+			return applyFixes(logger, moves, journalPath)
+		},
+	}
+}
+
+func newUndoCmd(logger *logrus.Logger, mode *runMode) *ffcli.Command {
+	var (
+		undoFlagSet = flag.NewFlagSet("watcher undo", flag.ExitOnError)
+		dryRunFlag  = undoFlagSet.Bool("dry-run", false, "Only show the moves which would be undone.")
+	)
+
+	return &ffcli.Command{
+		Name:       "undo",
+		ShortUsage: "watcher [flags] undo [-dry-run] <journal>",
+		ShortHelp:  "Move the files recorded in a journal back to where they were.",
+		LongHelp: "Undoes the moves recorded in the journal, newest first. Files which have since been moved or " +
+			"deleted, or whose original location is taken, are left alone.",
+		FlagSet: undoFlagSet,
+		Exec: func(ctx context.Context, args []string) error {
+			mode.headless = true
+
+			if len(args) != 1 {
+				return &configError{fmt.Errorf("undo: expected exactly one journal, got %d", len(args))}
+			}
+
+			return undoJournal(logger, args[0], *dryRunFlag)
+		},
+	}
+}
+
+// planFixes proposes a move for each file in the wrong folder. A file is only
+// moved if it belongs in exactly one folder, that folder already exists and
+// no other file is in the way.
+func planFixes(results []*Result) []fixMove {
+	var moves []fixMove
+
+	planned := make(map[string]bool)
+
+	for _, r := range results {
+		if r.Verdict != VerdictWrongFolder {
+			continue
+		}
+
+		move := fixMove{From: r.Path}
+		dirs := correctFolderPaths(r.Path, r.CorrectDirNames)
+
+		switch {
+		case len(r.CorrectDirNames) != 1:
+			move.Skip = fmt.Sprintf("could belong in any of %s", r.CorrectDirName())
+		case len(dirs) != 1:
+			move.Skip = fmt.Sprintf("folder %q doesn't exist", r.CorrectDirNames[0])
+		default:
+			move.To = filepath.Join(dirs[0], filepath.Base(r.Path))
+		}
+
+		if move.To != "" {
+			if _, err := os.Lstat(move.To); err == nil {
+				move.Skip, move.To = "a file with the same name is already in the correct folder", ""
+			} else if planned[move.To] {
+				move.Skip, move.To = "another file with the same name is being moved there", ""
+			}
+		}
+
+		if move.To != "" {
+			planned[move.To] = true
+		}
+
+		moves = append(moves, move)
+	}
+
+	return moves
+}
+
+func writeFixPlan(w io.Writer, moves []fixMove) error {
+	out := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(out, "ACTION\tFILE\tDESTINATION")
+
+	for _, m := range moves {
+		if m.Skip != "" {
+			fmt.Fprintf(out, "skip\t%s\t(%s)\n", m.From, m.Skip)
+		} else {
+			fmt.Fprintf(out, "move\t%s\t%s\n", m.From, m.To)
+		}
+	}
+
+	return out.Flush()
+}
+
+// fixJournalPath returns the path of a new journal for a fix run, next to the
+// journal of the moves made while watching.
+func fixJournalPath(cfg MoveConfig, now time.Time) string {
+	return filepath.Join(filepath.Dir(cfg.JournalPath()), "watcher-fix-"+now.Format("20060102-150405")+".jsonl")
+}
+
+// applyFixes carries out the planned moves, recording each in the journal.
+func applyFixes(logger *logrus.Logger, moves []fixMove, journalPath string) error {
+	moved, failed := 0, 0
+
+	for _, m := range moves {
+		if m.Skip != "" {
+			continue
+		}
+
+		if err := fileutil.Move(m.From, m.To); err != nil {
+			logger.Errorf("failed to move misplaced file: %v", err)
+			failed++
+			continue
+		}
+
+		moved++
+
+		entry := JournalEntry{Time: time.Now(), Action: "fix", From: absPath(m.From), To: absPath(m.To)}
+		if err := appendJournal(journalPath, entry); err != nil {
+			return fmt.Errorf("moved %q to %q but failed to record move: %w", m.From, m.To, err)
+		}
+	}
+
+	if moved > 0 {
+		logger.Infof("moved %d files; run \"watcher undo %s\" to move them back", moved, journalPath)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("failed to move %d files", failed)
+	}
+
+	return nil
+}
+
+// undoJournal moves the files recorded in the journal back to where they were
+// moved from, newest first.
+func undoJournal(logger *logrus.Logger, journalPath string, dryRun bool) error {
+	entries, err := readJournal(journalPath)
+	if err != nil {
+		return err
+	}
+
+	restored, failed := 0, 0
+
+	for i := len(entries) - 1; i >= 0; i-- {
+		entry := entries[i]
+
+		if _, err := os.Lstat(entry.To); errors.Is(err, fs.ErrNotExist) {
+			logger.Warnf("skipping %q: no longer there", entry.To)
+			continue
+		}
+
+		if _, err := os.Lstat(entry.From); err == nil {
+			logger.Warnf("skipping %q: %q is taken", entry.To, entry.From)
+			continue
+		}
+
+		if dryRun {
+			logger.Infof("[dry run] would move %q back to %q", entry.To, entry.From)
+			continue
+		}
+
+		if err := os.MkdirAll(filepath.Dir(entry.From), 0o755); err != nil {
+			logger.Errorf("failed to restore %q: create folder: %v", entry.To, err)
+			failed++
+			continue
+		}
+
+		if err := fileutil.Move(entry.To, entry.From); err != nil {
+			logger.Errorf("failed to restore %q: %v", entry.To, err)
+			failed++
+			continue
+		}
+
+		logger.Infof("moved %q back to %q", entry.To, entry.From)
+		restored++
+	}
+
+	if !dryRun {
+		logger.Infof("restored %d of %d files", restored, len(entries))
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("failed to restore %d files", failed)
+	}
+
+	return nil
+}
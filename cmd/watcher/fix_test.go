@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/shahruk10/watcher/internal/watcher"
+	"github.com/sirupsen/logrus"
+)
+
+func TestFixAndUndo(t *testing.T) {
+	root := t.TempDir()
+	logger := logrus.New()
+
+	framed := filepath.Join(root, "8x10 framed")
+	for _, dir := range []string{framed, filepath.Join(root, "11x14 framed")} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, path := range []string{
+		filepath.Join(framed, "1_fr_8x10.jpg"),
+		filepath.Join(framed, "2_fr_11x14.jpg"),
+		filepath.Join(framed, "3_fr_16x20.jpg"),
+		filepath.Join(framed, "4_any_11x14.jpg"),
+	} {
+		if err := os.WriteFile(path, nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cfg := Config{
+		Watcher: watcher.Config{IncludeFolders: []string{filepath.Join(root, "*")}},
+		Metadata: Metadata{
+			FrameType2Name:     map[string][]string{"fr": {"framed"}, "any": {"black framed", "white framed"}},
+			FolderNamePatterns: []string{`^(?P<frame_size>\d+x\d+) (?P<frame_type>((black|white) )?framed)$`},
+			FileNamePatterns:   []string{`^([^_]+)_(?P<frame_type>[^_]+)_(?P<frame_size>\d+x\d+).*$`},
+		},
+	}
+
+	files, err := filesToScan(cfg, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results, _ := scan(context.Background(), logger, cfg, files)
+	moves := planFixes(results)
+
+	// Files without exactly one existing correct folder are skipped.
+	want := map[string]bool{"2_fr_11x14.jpg": false, "3_fr_16x20.jpg": true, "4_any_11x14.jpg": true}
+	if len(moves) != len(want) {
+		t.Fatalf("got unexpected moves, want=%d, got=%+v", len(want), moves)
+	}
+
+	for _, m := range moves {
+		if skip, ok := want[filepath.Base(m.From)]; !ok || skip != (m.Skip != "") {
+			t.Errorf("got unexpected move, got=%+v", m)
+		}
+	}
+
+	journal := filepath.Join(root, "fix.jsonl")
+	if err := applyFixes(logger, moves, journal); err != nil {
+		t.Fatal(err)
+	}
+
+	moved := filepath.Join(root, "11x14 framed", "2_fr_11x14.jpg")
+	if _, err := os.Stat(moved); err != nil {
+		t.Fatalf("got file not moved: %v", err)
+	}
+
+	if err := undoJournal(logger, journal, false); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Stat(filepath.Join(framed, "2_fr_11x14.jpg")); err != nil {
+		t.Errorf("got file not moved back: %v", err)
+	}
+
+	if _, err := os.Stat(moved); !os.IsNotExist(err) {
+		t.Errorf("got file left in correct folder after undo")
+	}
+
+	// Undoing again leaves the files alone.
+	if err := undoJournal(logger, journal, false); err != nil {
+		t.Fatal(err)
+	}
+}
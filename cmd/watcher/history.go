@@ -0,0 +1,738 @@
+// Copyright (2023 -- present) Shahruk Hossain <shahruk10@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//		 http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// ==============================================================================
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/peterbourgon/ff/v3/ffcli"
+	"github.com/shahruk10/watcher/internal/watcher"
+	"github.com/sirupsen/logrus"
+	bolt "go.etcd.io/bbolt"
+)
+
+const (
+	defaultHistoryPath = "watcher-history.db"
+
+	// historyPruneInterval is how often records past their retention are
+	// removed, at most.
+	historyPruneInterval = time.Hour
+
+	// historyLockTimeout is how long to wait for the history database while
+	// another process has it open.
+	historyLockTimeout = 10 * time.Second
+
+	// maxHistoryPending is the number of records kept queued while the
+	// history database can't be written.
+	maxHistoryPending = 10000
+)
+
+// Buckets of the history database. Records are keyed by their ID, which
+// increases with the time they are added; the index buckets map an order ID
+// or a path, followed by a zero byte and a record ID, to nothing.
+var (
+	bucketRecords = []byte("records")
+	bucketOrders  = []byte("orders")
+	bucketPaths   = []byte("paths")
+)
+
+// HistoryConfig controls keeping a history of the events and verdicts for the
+// history subcommand.
+type HistoryConfig struct {
+	Enabled bool   `yaml:"enabled"`
+	File    string `yaml:"file"`
+	// Retention is how long records are kept.
+	Retention time.Duration `yaml:"retention"`
+}
+
+func (cfg *HistoryConfig) Validate() error {
+	if cfg.Retention < 0 {
+		return fmt.Errorf("validate history: retention must not be negative")
+	}
+
+	return nil
+}
+
+func (cfg *HistoryConfig) Path() string {
+	if cfg.File == "" {
+		return defaultHistoryPath
+	}
+
+	return cfg.File
+}
+
+func (cfg *HistoryConfig) RetentionOrDefault() time.Duration {
+	if cfg.Retention <= 0 {
+		return 90 * 24 * time.Hour
+	}
+
+	return cfg.Retention
+}
+
+// HistoryRecord is an event in the life of a file: a verdict reached for it,
+// with the actions carried out, or its removal.
+type HistoryRecord struct {
+	ID            uint64          `json:"id"`
+	Time          time.Time       `json:"time"`
+	Op            string          `json:"op"`
+	Path          string          `json:"path"`
+	OrderID       string          `json:"order_id,omitempty"`
+	Owner         string          `json:"owner,omitempty"`
+	Verdict       Verdict         `json:"verdict,omitempty"`
+	Title         string          `json:"title,omitempty"`
+	CorrectFolder string          `json:"correct_folder,omitempty"`
+	Actions       []ActionOutcome `json:"actions,omitempty"`
+	MovedTo       string          `json:"moved_to,omitempty"`
+}
+
+// HistoryFilter selects history records. Empty fields match every record.
+type HistoryFilter struct {
+	OrderID string
+	// Path matches records with paths containing it.
+	Path string
+	// Folder matches records of files in, or moved to, the folder with the
+	// given name or path.
+	Folder  string
+	Verdict Verdict
+	Since   time.Time
+	Until   time.Time
+}
+
+func (f *HistoryFilter) Match(rec *HistoryRecord) bool {
+	inFolder := func(path string) bool {
+		dir := filepath.Dir(path)
+		return path != "" && (filepath.Base(dir) == f.Folder || dir == absPath(f.Folder))
+	}
+
+	switch {
+	case f.OrderID != "" && rec.OrderID != f.OrderID:
+		return false
+	case f.Path != "" && !strings.Contains(rec.Path, f.Path) && !strings.Contains(rec.MovedTo, f.Path):
+		return false
+	case f.Folder != "" && !inFolder(rec.Path) && !inFolder(rec.MovedTo):
+		return false
+	case f.Verdict != "" && rec.Verdict != f.Verdict:
+		return false
+	case !f.Since.IsZero() && rec.Time.Before(f.Since):
+		return false
+	case !f.Until.IsZero() && rec.Time.After(f.Until):
+		return false
+	}
+
+	return true
+}
+
+// History keeps records of events and verdicts in an embedded database.
+// Records are queued and written in batches by Run, so that callbacks never
+// wait on the database. The database is only open while it is used, so that
+// the history can be queried while the watcher is running.
+type History struct {
+	cfg HistoryConfig
+
+	mu      sync.Mutex
+	pending []*HistoryRecord
+	wake    chan struct{}
+
+	// flushMu serialises writing the queued records.
+	flushMu sync.Mutex
+	pruned  time.Time
+}
+
+func NewHistory(cfg HistoryConfig) *History {
+	return &History{cfg: cfg, wake: make(chan struct{}, 1)}
+}
+
+func (h *History) update(fn func(tx *bolt.Tx) error) error {
+	db, err := bolt.Open(h.cfg.Path(), 0o644, &bolt.Options{Timeout: historyLockTimeout})
+	if err != nil {
+		return fmt.Errorf("open history: %w", err)
+	}
+
+	if err := db.Update(fn); err != nil {
+		db.Close()
+		return fmt.Errorf("update history: %w", err)
+	}
+
+	return db.Close()
+}
+
+func (h *History) view(fn func(tx *bolt.Tx) error) error {
+	if _, err := os.Stat(h.cfg.Path()); errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+
+	db, err := bolt.Open(h.cfg.Path(), 0o644, &bolt.Options{Timeout: historyLockTimeout, ReadOnly: true})
+	if err != nil {
+		return fmt.Errorf("open history: %w", err)
+	}
+
+	if err := db.View(fn); err != nil {
+		db.Close()
+		return fmt.Errorf("read history: %w", err)
+	}
+
+	return db.Close()
+}
+
+// Record queues a record of the verdict for the result.
+func (h *History) Record(r *Result) error {
+	rec := &HistoryRecord{
+		Time:          r.Time,
+		Op:            r.Op,
+		Path:          absPath(r.CheckedPath()),
+		OrderID:       r.FileAttr[attrOrderID],
+		Owner:         r.Owner,
+		Verdict:       r.Verdict,
+		Title:         r.Title,
+		CorrectFolder: r.CorrectDirName(),
+		Actions:       r.Actions,
+	}
+
+	if r.MovedTo != "" {
+		rec.MovedTo = absPath(r.MovedTo)
+	}
+
+	h.queue(rec)
+
+	return nil
+}
+
+// Removed queues a record of the file at path being removed or renamed,
+// keeping the order ID of its earlier records.
+func (h *History) Removed(path, op string) error {
+	h.queue(&HistoryRecord{Time: time.Now(), Op: op, Path: absPath(path)})
+	return nil
+}
+
+func (h *History) queue(rec *HistoryRecord) {
+	h.mu.Lock()
+	h.pending = append(h.pending, rec)
+	h.mu.Unlock()
+
+	select {
+	case h.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run writes queued records to the database until the context is done.
+// Records queued after that are written by Flush.
+func (h *History) Run(ctx context.Context, logger *logrus.Logger) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-h.wake:
+		}
+
+		if err := h.Flush(); err != nil {
+			logger.Errorf("failed to write history: %v", err)
+		}
+	}
+}
+
+// Flush writes the queued records to the database in a single transaction.
+// If that fails, the records are kept queued, up to maxHistoryPending of
+// them, to be written with the next ones.
+func (h *History) Flush() error {
+	h.flushMu.Lock()
+	defer h.flushMu.Unlock()
+
+	h.mu.Lock()
+	batch := h.pending
+	h.pending = nil
+	h.mu.Unlock()
+
+	if len(batch) == 0 {
+		return nil
+	}
+
+	if err := h.add(batch); err != nil {
+		h.mu.Lock()
+		h.pending = append(batch, h.pending...)
+		if dropped := len(h.pending) - maxHistoryPending; dropped > 0 {
+			h.pending = h.pending[dropped:]
+			err = fmt.Errorf("%w; dropped %d oldest records", err, dropped)
+		}
+		h.mu.Unlock()
+
+		return err
+	}
+
+	return nil
+}
+
+func (h *History) add(batch []*HistoryRecord) error {
+	now := time.Now()
+	prune := now.Sub(h.pruned) > historyPruneInterval
+
+	err := h.update(func(tx *bolt.Tx) error {
+		records, err := tx.CreateBucketIfNotExists(bucketRecords)
+		if err != nil {
+			return err
+		}
+
+		orders, err := tx.CreateBucketIfNotExists(bucketOrders)
+		if err != nil {
+			return err
+		}
+
+		paths, err := tx.CreateBucketIfNotExists(bucketPaths)
+		if err != nil {
+			return err
+		}
+
+		if prune {
+			if err := pruneHistory(tx, now.Add(-h.cfg.RetentionOrDefault())); err != nil {
+				return err
+			}
+		}
+
+		for _, queued := range batch {
+			// The queued record is left untouched, in case the transaction
+			// fails and it's written again.
+			rec := *queued
+
+			if rec.OrderID == "" {
+				ids := indexed(paths, rec.Path)
+				if len(ids) > 0 {
+					if last, err := getRecord(records, ids[len(ids)-1]); err == nil {
+						rec.OrderID = last.OrderID
+					}
+				}
+			}
+
+			if rec.ID, err = records.NextSequence(); err != nil {
+				return err
+			}
+
+			data, err := json.Marshal(&rec)
+			if err != nil {
+				return err
+			}
+
+			if err := records.Put(recordKey(rec.ID), data); err != nil {
+				return err
+			}
+
+			if rec.OrderID != "" {
+				if err := orders.Put(indexKey(rec.OrderID, rec.ID), nil); err != nil {
+					return err
+				}
+			}
+
+			for _, path := range []string{rec.Path, rec.MovedTo} {
+				if path == "" {
+					continue
+				}
+
+				if err := paths.Put(indexKey(path, rec.ID), nil); err != nil {
+					return err
+				}
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if prune {
+		h.pruned = now
+	}
+
+	return nil
+}
+
+// pruneHistory removes the records added before the cutoff, along with their
+// index entries.
+func pruneHistory(tx *bolt.Tx, cutoff time.Time) error {
+	records, orders, paths := tx.Bucket(bucketRecords), tx.Bucket(bucketOrders), tx.Bucket(bucketPaths)
+
+	c := records.Cursor()
+	for k, v := c.First(); k != nil; k, v = c.First() {
+		var rec HistoryRecord
+		if err := json.Unmarshal(v, &rec); err != nil {
+			return err
+		}
+
+		if !rec.Time.Before(cutoff) {
+			return nil
+		}
+
+		if err := c.Delete(); err != nil {
+			return err
+		}
+
+		if err := orders.Delete(indexKey(rec.OrderID, rec.ID)); err != nil {
+			return err
+		}
+
+		for _, path := range []string{rec.Path, rec.MovedTo} {
+			if err := paths.Delete(indexKey(path, rec.ID)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func recordKey(id uint64) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, id)
+
+	return key
+}
+
+func indexKey(value string, id uint64) []byte {
+	return append(append([]byte(value), 0), recordKey(id)...)
+}
+
+// indexed returns the IDs of the records the index maps the value to, oldest
+// first.
+func indexed(index *bolt.Bucket, value string) []uint64 {
+	if index == nil {
+		return nil
+	}
+
+	prefix := append([]byte(value), 0)
+
+	var ids []uint64
+
+	c := index.Cursor()
+	for k, _ := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, _ = c.Next() {
+		if len(k) == len(prefix)+8 {
+			ids = append(ids, binary.BigEndian.Uint64(k[len(prefix):]))
+		}
+	}
+
+	return ids
+}
+
+func getRecord(records *bolt.Bucket, id uint64) (*HistoryRecord, error) {
+	data := records.Get(recordKey(id))
+	if data == nil {
+		return nil, fmt.Errorf("record %d not found", id)
+	}
+
+	rec := &HistoryRecord{}
+	if err := json.Unmarshal(data, rec); err != nil {
+		return nil, fmt.Errorf("decode record %d: %w", id, err)
+	}
+
+	return rec, nil
+}
+
+// Query returns the records matching the filter, oldest first.
+func (h *History) Query(f HistoryFilter) ([]HistoryRecord, error) {
+	var list []HistoryRecord
+
+	err := h.view(func(tx *bolt.Tx) error {
+		records := tx.Bucket(bucketRecords)
+		if records == nil {
+			return nil
+		}
+
+		match := func(rec *HistoryRecord) {
+			if f.Match(rec) {
+				list = append(list, *rec)
+			}
+		}
+
+		// Records of an order are found through the index, rather than
+		// going through all of them.
+		if f.OrderID != "" {
+			for _, id := range indexed(tx.Bucket(bucketOrders), f.OrderID) {
+				rec, err := getRecord(records, id)
+				if err != nil {
+					return err
+				}
+
+				match(rec)
+			}
+
+			return nil
+		}
+
+		return records.ForEach(func(k, v []byte) error {
+			rec := &HistoryRecord{}
+			if err := json.Unmarshal(v, rec); err != nil {
+				return err
+			}
+
+			match(rec)
+
+			return nil
+		})
+	})
+
+	return list, err
+}
+
+// Lifecycle returns the records of the file at path, following it through the
+// moves recorded before and after it was at path, oldest first.
+func (h *History) Lifecycle(path string) ([]HistoryRecord, error) {
+	var list []HistoryRecord
+
+	err := h.view(func(tx *bolt.Tx) error {
+		records, paths := tx.Bucket(bucketRecords), tx.Bucket(bucketPaths)
+		if records == nil {
+			return nil
+		}
+
+		seen := make(map[uint64]bool)
+		visited := make(map[string]bool)
+		queue := []string{absPath(path)}
+
+		for len(queue) > 0 {
+			path := queue[0]
+			queue = queue[1:]
+
+			if visited[path] {
+				continue
+			}
+
+			visited[path] = true
+
+			for _, id := range indexed(paths, path) {
+				if seen[id] {
+					continue
+				}
+
+				seen[id] = true
+
+				rec, err := getRecord(records, id)
+				if err != nil {
+					return err
+				}
+
+				list = append(list, *rec)
+				queue = append(queue, rec.Path, rec.MovedTo)
+			}
+		}
+
+		return nil
+	})
+
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+
+	return list, err
+}
+
+// TrackHistory records files being removed or renamed away in the history.
+func TrackHistory(history *History) watcher.Callback {
+	return func(ctx context.Context, logger *logrus.Logger, e watcher.Event) error {
+		if !e.HasOp(watcher.RemoveOp) && !e.HasOp(watcher.RenameOp) {
+			return nil
+		}
+
+		return history.Removed(e.Name, e.Op.String())
+	}
+}
+
+// parseHistoryTime parses a time given as a date, an RFC 3339 timestamp, or a
+// duration before now.
+func parseHistoryTime(s string, now time.Time) (time.Time, error) {
+	if d, err := time.ParseDuration(s); err == nil {
+		return now.Add(-d), nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+
+	if t, err := time.ParseInLocation("2006-01-02", s, time.Local); err == nil {
+		return t, nil
+	}
+
+	return time.Time{}, fmt.Errorf("time %q not a date, RFC 3339 timestamp or duration", s)
+}
+
+func newHistoryCmd(logger *logrus.Logger, cfgPath *string, mode *runMode) *ffcli.Command {
+	var (
+		historyFlagSet = flag.NewFlagSet("watcher history", flag.ExitOnError)
+		orderFlag      = historyFlagSet.String("order", "", "Only show records of the order ID.")
+		pathFlag       = historyFlagSet.String("path", "", "Only show records with paths containing this.")
+		folderFlag     = historyFlagSet.String("folder", "", "Only show records of files in or moved to this folder.")
+		verdictFlag    = historyFlagSet.String("verdict", "", "Only show records with this verdict.")
+		sinceFlag      = historyFlagSet.String("since", "", "Only show records from this date, time, or duration ago.")
+		untilFlag      = historyFlagSet.String("until", "", "Only show records up to this date, time, or duration ago.")
+		fileFlag       = historyFlagSet.String("file", "", "Show the lifecycle of the file, following it across moves.")
+		formatFlag     = historyFlagSet.String("format", formatTable, "Output format: table, json or csv.")
+		outputFlag     = historyFlagSet.String("output", "", "File to write to instead of stdout.")
+	)
+
+	return &ffcli.Command{
+		Name:       "history",
+		ShortUsage: "watcher [flags] history [-order <id>] [-path <text>] [-folder <folder>] [-verdict <verdict>] [-since <time>] [-until <time>] [-file <path>] [-format table|json|csv] [-output <file>]",
+		ShortHelp:  "Search the history of events and verdicts.",
+		LongHelp: "Lists the recorded verdicts, actions and removals of files matching all the given filters, oldest " +
+			"first. Times are dates (2006-01-02), RFC 3339 timestamps or durations before now (36h). With -file, " +
+			"the records of the file are followed across the moves made to and from the path.",
+		FlagSet: historyFlagSet,
+		Exec: func(ctx context.Context, args []string) error {
+			mode.headless = true
+
+			if *formatFlag != formatTable && *formatFlag != formatJSON && *formatFlag != formatCSV {
+				return &configError{fmt.Errorf("unknown format %q", *formatFlag)}
+			}
+
+			if *verdictFlag != "" && !isKnownVerdict(Verdict(*verdictFlag)) {
+				return &configError{fmt.Errorf("unknown verdict %q", *verdictFlag)}
+			}
+
+			cfg, err := loadConfig(logger, *cfgPath)
+			if err != nil {
+				return err
+			}
+
+			filter := HistoryFilter{OrderID: *orderFlag, Path: *pathFlag, Folder: *folderFlag, Verdict: Verdict(*verdictFlag)}
+
+			now := time.Now()
+			for _, t := range []struct {
+				flag string
+				dst  *time.Time
+			}{{*sinceFlag, &filter.Since}, {*untilFlag, &filter.Until}} {
+				if t.flag == "" {
+					continue
+				}
+
+				if *t.dst, err = parseHistoryTime(t.flag, now); err != nil {
+					return &configError{err}
+				}
+			}
+
+			history := NewHistory(cfg.History)
+
+			var list []HistoryRecord
+			if *fileFlag != "" {
+				all, err := history.Lifecycle(*fileFlag)
+				if err != nil {
+					return err
+				}
+
+				for i := range all {
+					if filter.Match(&all[i]) {
+						list = append(list, all[i])
+					}
+				}
+			} else if list, err = history.Query(filter); err != nil {
+				return err
+			}
+
+			out := io.Writer(os.Stdout)
+			if *outputFlag != "" {
+				f, err := os.Create(*outputFlag)
+				if err != nil {
+					return fmt.Errorf("create output file: %w", err)
+				}
+				defer f.Close()
+
+				out = f
+			}
+
+			return writeHistory(out, *formatFlag, list)
+		},
+	}
+}
+
+func writeHistory(w io.Writer, format string, list []HistoryRecord) error {
+	actions := func(rec *HistoryRecord) string {
+		names := make([]string, 0, len(rec.Actions))
+		for _, a := range rec.Actions {
+			if a.Error != "" {
+				names = append(names, fmt.Sprintf("%s (failed: %s)", a.Type, a.Error))
+			} else {
+				names = append(names, a.Type)
+			}
+		}
+
+		return strings.Join(names, ", ")
+	}
+
+	switch format {
+	case formatJSON:
+		if list == nil {
+			list = []HistoryRecord{}
+		}
+
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+
+		return enc.Encode(list)
+
+	case formatCSV:
+		out := csv.NewWriter(w)
+		out.Write([]string{"id", "time", "op", "path", "order_id", "owner", "verdict", "title", "correct_folder", "actions", "moved_to"})
+
+		for i := range list {
+			rec := &list[i]
+			out.Write([]string{
+				strconv.FormatUint(rec.ID, 10), rec.Time.Format(time.RFC3339), rec.Op, rec.Path, rec.OrderID, rec.Owner,
+				string(rec.Verdict), rec.Title, rec.CorrectFolder, actions(rec), rec.MovedTo,
+			})
+		}
+
+		out.Flush()
+
+		return out.Error()
+
+	case formatTable:
+		out := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(out, "TIME\tOP\tORDER\tVERDICT\tPATH\tDETAILS")
+
+		for i := range list {
+			rec := &list[i]
+
+			details := actions(rec)
+			if rec.MovedTo != "" {
+				details = strings.TrimPrefix(details+"; moved to "+rec.MovedTo, "; ")
+			}
+
+			verdict := string(rec.Verdict)
+			if verdict == "" {
+				verdict = "-"
+			}
+
+			fmt.Fprintf(out, "%s\t%s\t%s\t%s\t%s\t%s\n",
+				rec.Time.Format(time.RFC3339), rec.Op, rec.OrderID, verdict, rec.Path, details)
+		}
+
+		return out.Flush()
+
+	default:
+		return fmt.Errorf("unknown format %q", format)
+	}
+}
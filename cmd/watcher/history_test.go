@@ -0,0 +1,214 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	bolt "go.etcd.io/bbolt"
+)
+
+func TestHistory(t *testing.T) {
+	dir := t.TempDir()
+	history := NewHistory(HistoryConfig{Enabled: true, File: filepath.Join(dir, "history.db"), Retention: 24 * time.Hour})
+
+	now := time.Now()
+	wrong := filepath.Join(dir, "8x10 framed", "12345_fr_11x14.jpg")
+	right := filepath.Join(dir, "11x14 framed", "12345_fr_11x14.jpg")
+	other := filepath.Join(dir, "8x10 framed", "678_fr_8x10.jpg")
+
+	results := []*Result{
+		// Past its retention, so removed once the history is pruned.
+		{Time: now.Add(-48 * time.Hour), Op: "CREATE", Path: other, FileAttr: map[string]string{attrOrderID: "678"}, Verdict: VerdictCorrect},
+		{
+			Time: now.Add(-time.Hour), Op: "CREATE", Path: wrong, FileAttr: map[string]string{attrOrderID: "12345"},
+			Verdict: VerdictWrongFolder, CorrectDirNames: []string{"11x14 framed"},
+			Actions: []ActionOutcome{{Type: actionMove}}, MovedTo: right,
+		},
+		{Time: now, Op: "CREATE", Path: right, FileAttr: map[string]string{attrOrderID: "12345"}, Verdict: VerdictCorrect},
+	}
+
+	for _, r := range results {
+		if err := history.Record(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := history.Removed(wrong, "RENAME"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := history.Removed(right, "REMOVE"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := history.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Records are pruned at most once an hour, so the old record is still
+	// there until the next prune.
+	all, err := history.Query(HistoryFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(all) != 5 {
+		t.Fatalf("got unexpected records, want=5, got=%+v", all)
+	}
+
+	history.pruned = time.Time{}
+	if err := history.Removed(other, "REMOVE"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := history.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name   string
+		filter HistoryFilter
+		want   []string
+	}{
+		{"all", HistoryFilter{}, []string{"CREATE", "CREATE", "RENAME", "REMOVE", "REMOVE"}},
+		{"order", HistoryFilter{OrderID: "12345"}, []string{"CREATE", "CREATE", "RENAME", "REMOVE"}},
+		{"path", HistoryFilter{Path: "678_"}, []string{"REMOVE"}},
+		{"folder", HistoryFilter{Folder: "11x14 framed"}, []string{"CREATE", "CREATE", "REMOVE"}},
+		{"verdict", HistoryFilter{Verdict: VerdictWrongFolder}, []string{"CREATE"}},
+		{"since", HistoryFilter{Since: now.Add(-time.Minute)}, []string{"CREATE", "RENAME", "REMOVE", "REMOVE"}},
+		{"until", HistoryFilter{Until: now.Add(-time.Minute)}, []string{"CREATE"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			list, err := history.Query(tc.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got []string
+			for _, rec := range list {
+				got = append(got, rec.Op)
+			}
+
+			if strings.Join(got, ",") != strings.Join(tc.want, ",") {
+				t.Errorf("got unexpected records, want=%q, got=%+v", tc.want, list)
+			}
+		})
+	}
+
+	// The lifecycle of the file is followed across its move.
+	lifecycle, err := history.Lifecycle(right)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, rec := range lifecycle {
+		got = append(got, rec.Op+" "+filepath.Base(filepath.Dir(rec.Path)))
+	}
+
+	want := []string{"CREATE 8x10 framed", "CREATE 11x14 framed", "RENAME 8x10 framed", "REMOVE 11x14 framed"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("got unexpected lifecycle, want=%q, got=%q", want, got)
+	}
+
+	var out bytes.Buffer
+	if err := writeHistory(&out, formatTable, lifecycle[:1]); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(out.String(), "move; moved to "+right) {
+		t.Errorf("got move missing from history table:\n%s", out.String())
+	}
+}
+
+func TestHistoryQueue(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "history.db")
+	history := NewHistory(HistoryConfig{Enabled: true, File: path})
+
+	// Records are queued without waiting for the database, which another
+	// process may have open.
+	db, err := bolt.Open(path, 0o644, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := &Result{Time: time.Now(), Op: "CREATE", Path: filepath.Join(dir, "1_fr_8x10.jpg"), Verdict: VerdictCorrect}
+
+	start := time.Now()
+	if err := history.Record(r); err != nil {
+		t.Fatal(err)
+	}
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("got record blocked on the open database for %v", elapsed)
+	}
+
+	if err := db.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Records which couldn't be written stay queued for the next attempt.
+	history.cfg.File = filepath.Join(dir, "missing", "history.db")
+	if err := history.Flush(); err == nil {
+		t.Errorf("got unexpected error writing to a missing folder, want=error, got=%v", err)
+	}
+
+	history.cfg.File = path
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		history.Run(ctx, logrus.New())
+		close(done)
+	}()
+
+	if err := history.Removed(r.Path, "REMOVE"); err != nil {
+		t.Fatal(err)
+	}
+
+	var list []HistoryRecord
+	for deadline := time.Now().Add(5 * time.Second); len(list) < 2 && time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if list, err = history.Query(HistoryFilter{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cancel()
+	<-done
+
+	if len(list) != 2 || list[0].Op != "CREATE" || list[1].Op != "REMOVE" {
+		t.Errorf("got unexpected records written in the background, want=CREATE,REMOVE, got=%+v", list)
+	}
+}
+
+func TestParseHistoryTime(t *testing.T) {
+	now := time.Date(2023, 5, 6, 12, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		in   string
+		want time.Time
+	}{
+		{"36h", now.Add(-36 * time.Hour)},
+		{"2023-05-01T08:30:00Z", time.Date(2023, 5, 1, 8, 30, 0, 0, time.UTC)},
+		{"2023-05-01", time.Date(2023, 5, 1, 0, 0, 0, 0, time.Local)},
+	}
+
+	for _, tc := range testCases {
+		got, err := parseHistoryTime(tc.in, now)
+		if err != nil || !got.Equal(tc.want) {
+			t.Errorf("got unexpected time for %q, want=%v, got=%v (%v)", tc.in, tc.want, got, err)
+		}
+	}
+
+	if _, err := parseHistoryTime("last week", now); err == nil {
+		t.Errorf("got no error for unknown time")
+	}
+}
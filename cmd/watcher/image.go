@@ -0,0 +1,158 @@
+// Copyright (2023 -- present) Shahruk Hossain <shahruk10@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//		 http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// ==============================================================================
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/shahruk10/watcher/internal/imageinfo"
+	"github.com/shahruk10/watcher/internal/notify"
+	"github.com/sirupsen/logrus"
+)
+
+// ImageConfig controls inspecting images to check that they fit the frame
+// size given in their file name.
+type ImageConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// AspectTolerance is how much the aspect ratio of an image may differ from
+	// that of its frame, as a fraction of the frame's aspect ratio.
+	AspectTolerance float64 `yaml:"aspect_tolerance"`
+	// MinDPI is the lowest resolution an image may have when printed at the
+	// frame size. Zero doesn't check the resolution.
+	MinDPI float64 `yaml:"min_dpi"`
+}
+
+func (cfg *ImageConfig) AspectToleranceOrDefault() float64 {
+	if cfg.AspectTolerance <= 0 {
+		return 0.02
+	}
+
+	return cfg.AspectTolerance
+}
+
+// parseFrameSize parses frame sizes such as "11x14" into width and height in
+// inches.
+func parseFrameSize(frameSize string) (float64, float64, bool) {
+	w, h, ok := strings.Cut(strings.ToLower(frameSize), "x")
+	if !ok {
+		return 0, 0, false
+	}
+
+	width, err := strconv.ParseFloat(w, 64)
+	if err != nil || width <= 0 {
+		return 0, 0, false
+	}
+
+	height, err := strconv.ParseFloat(h, 64)
+	if err != nil || height <= 0 {
+		return 0, 0, false
+	}
+
+	return width, height, true
+}
+
+// readImage reads the image header of the file, returning nil for files which
+// aren't images, or whose header can't be read yet.
+func readImage(logger *logrus.Logger, filePath string) *imageinfo.Info {
+	info, err := imageinfo.ReadFile(filePath)
+	if errors.Is(err, imageinfo.ErrUnsupported) {
+		return nil
+	} else if err != nil {
+		logger.Debugf("not inspecting image %q: %v", filePath, err)
+		return nil
+	}
+
+	return info
+}
+
+// checkImage returns true with the verdict set on the result if the image
+// doesn't fit the frame size in its name.
+func checkImage(logger *logrus.Logger, cfg ImageConfig, result *Result) bool {
+	info := result.Image
+	frameSize := result.FileAttr[attrFrameSize]
+
+	frameW, frameH, ok := parseFrameSize(frameSize)
+	if !ok {
+		logger.Debugf("not inspecting image %q: can't parse frame size %q", result.Path, frameSize)
+		return false
+	}
+
+	imageW, imageH := info.DisplaySize()
+
+	imageAspect := float64(imageW) / float64(imageH)
+	frameAspect := frameW / frameH
+
+	// Orientation is checked separately, so aspect ratios are compared as
+	// long over short side.
+	longAspect := func(aspect float64) float64 { return math.Max(aspect, 1/aspect) }
+
+	// Resolution is limited by whichever side of the image has the fewest
+	// pixels per inch when printed.
+	long, short := math.Max(float64(imageW), float64(imageH)), math.Min(float64(imageW), float64(imageH))
+	dpi := math.Min(long/math.Max(frameW, frameH), short/math.Min(frameW, frameH))
+
+	fields := []notify.Field{
+		{Label: "📁 file", Value: filepath.Base(result.Path)},
+		{Label: "🖼 image", Value: fmt.Sprintf("%dx%d px", imageW, imageH)},
+		{Label: "📐 frame", Value: frameSize},
+	}
+
+	switch {
+	case math.Abs(longAspect(imageAspect)-longAspect(frameAspect))/longAspect(frameAspect) > cfg.AspectToleranceOrDefault():
+		result.Verdict = VerdictWrongAspectRatio
+		result.Title = "WRONG ASPECT RATIO"
+		fields = append(fields, notify.Field{
+			Label: "❌ aspect ratio",
+			Value: fmt.Sprintf("%.2f, frame is %.2f", imageAspect, frameAspect),
+		})
+
+	case frameW != frameH && imageW != imageH && (imageW > imageH) != (frameW > frameH):
+		result.Verdict = VerdictWrongOrientation
+		result.Title = "WRONG ORIENTATION"
+		fields = append(fields, notify.Field{
+			Label: "❌ orientation",
+			Value: fmt.Sprintf("%s, frame is %s", orientation(imageW > imageH), orientation(frameW > frameH)),
+		})
+
+	case cfg.MinDPI > 0 && dpi < cfg.MinDPI:
+		result.Verdict = VerdictLowResolution
+		result.Title = "LOW RESOLUTION"
+		fields = append(fields, notify.Field{
+			Label: "❌ resolution",
+			Value: fmt.Sprintf("%.0f DPI, minimum is %.0f", dpi, cfg.MinDPI),
+		})
+
+	default:
+		return false
+	}
+
+	result.Fields = fields
+
+	return true
+}
+
+func orientation(landscape bool) string {
+	if landscape {
+		return "landscape"
+	}
+
+	return "portrait"
+}
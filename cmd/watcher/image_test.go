@@ -0,0 +1,61 @@
+package main
+
+import (
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestCheckImage(t *testing.T) {
+	cfg := ImageConfig{Enabled: true, MinDPI: 100}
+
+	tests := []struct {
+		name      string
+		frameSize string
+		width     int
+		height    int
+		want      Verdict
+	}{
+		{name: "fits", frameSize: "11x14", width: 1100, height: 1400},
+		{name: "within tolerance", frameSize: "11x14", width: 1110, height: 1400},
+		{name: "square image", frameSize: "11x14", width: 1400, height: 1400, want: VerdictWrongAspectRatio},
+		{name: "landscape image", frameSize: "11x14", width: 1400, height: 1100, want: VerdictWrongOrientation},
+		{name: "square frame", frameSize: "12x12", width: 1200, height: 1200},
+		{name: "low resolution", frameSize: "11x14", width: 550, height: 700, want: VerdictLowResolution},
+	}
+
+	for _, tc := range tests {
+		path := filepath.Join(t.TempDir(), "order_fr_"+tc.frameSize+".png")
+
+		f, err := os.Create(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := png.Encode(f, image.NewGray(image.Rect(0, 0, tc.width, tc.height))); err != nil {
+			t.Fatal(err)
+		}
+		f.Close()
+
+		result := &Result{Path: path, FileAttr: map[string]string{attrFrameSize: tc.frameSize}, Image: readImage(logrus.New(), path)}
+		found := checkImage(logrus.New(), cfg, result)
+
+		if found != (tc.want != "") || result.Verdict != tc.want {
+			t.Errorf("%s: got unexpected verdict, want=%q, got=%q (%v)", tc.name, tc.want, result.Verdict, found)
+		}
+
+	}
+
+	notImage := filepath.Join(t.TempDir(), "order_fr_11x14.pdf")
+	if err := os.WriteFile(notImage, []byte("%PDF-1.7\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if info := readImage(logrus.New(), notImage); info != nil {
+		t.Errorf("got image info for file which isn't an image, got=%+v", info)
+	}
+}
@@ -0,0 +1,563 @@
+// Copyright (2023 -- present) Shahruk Hossain <shahruk10@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//		 http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// ==============================================================================
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/peterbourgon/ff/v3/ffcli"
+	"github.com/shahruk10/watcher/internal/fileutil"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	sizePattern = `\d+x\d+`
+
+	// examplesPerCode is the number of examples written for each frame type
+	// code found in file names.
+	examplesPerCode = 2
+
+	// maxUnmatched is the number of folders and files that couldn't be parsed
+	// listed in the inferred config.
+	maxUnmatched = 10
+)
+
+var (
+	sizeRegex       = regexp.MustCompile(`^` + sizePattern + `$`)
+	sizePrefixRegex = regexp.MustCompile(`^` + sizePattern)
+)
+
+// Example is a file path along with the attributes the configured name
+// patterns are expected to give it and its folder. Examples are checked when
+// the config is loaded, so mistakes made while editing the patterns are caught
+// early.
+type Example struct {
+	Path   string            `yaml:"path"`
+	File   map[string]string `yaml:"file"`
+	Folder map[string]string `yaml:"folder"`
+}
+
+// Check returns an error if the name patterns don't give the example the
+// expected attributes.
+func (e *Example) Check(cfg Metadata) error {
+	logger := logrus.StandardLogger()
+
+	check := func(kind string, attr, want map[string]string) error {
+		for name, value := range want {
+			if attr[name] != value {
+				return fmt.Errorf("%q: got %s %s %q, want %q", e.Path, kind, name, attr[name], value)
+			}
+		}
+
+		return nil
+	}
+
+	if len(e.File) > 0 {
+		attr, err := getFileAttributes(logger, e.Path, cfg.FileNamePatterns)
+		if err != nil {
+			return fmt.Errorf("%q: file name doesn't match any pattern", e.Path)
+		}
+
+		if err := check("file", attr, e.File); err != nil {
+			return err
+		}
+	}
+
+	if len(e.Folder) > 0 {
+		attr, err := getFolderAttributes(logger, filepath.Dir(e.Path), cfg.FolderNamePatterns)
+		if err != nil {
+			return fmt.Errorf("%q: folder name doesn't match any pattern", e.Path)
+		}
+
+		if err := check("folder", attr, e.Folder); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Folder name shapes, by where the frame size is in the name.
+const (
+	shapeSize     = "size"
+	shapeSizeType = "size type"
+	shapeTypeSize = "type size"
+)
+
+// inferredConfig is the config proposed by the init subcommand for an existing
+// folder tree.
+type inferredConfig struct {
+	Roots          []string
+	IncludeFolders []string
+	Metadata       Metadata
+
+	// Counts holds, for each frame type code, the number of files in folders
+	// of each frame type.
+	Counts map[string]map[string]int
+
+	Examples         []Example
+	UnmatchedFolders []string
+	UnmatchedFiles   []string
+}
+
+type treeFile struct {
+	path string
+	code string
+}
+
+func newInitCmd(logger *logrus.Logger, cfgPath *string) *ffcli.Command {
+	var (
+		initFlagSet = flag.NewFlagSet("watcher init", flag.ExitOnError)
+		forceFlag   = initFlagSet.Bool("force", false, "Overwrite the config file if it already exists.")
+	)
+
+	return &ffcli.Command{
+		Name:       "init",
+		ShortUsage: "watcher [flags] init [-force] <folder>...",
+		ShortHelp:  "Write a config inferred from an existing folder tree.",
+		LongHelp: "Scans the folders, and the folders below them, and proposes folder and file name patterns and a " +
+			"frame type mapping, pairing the frame type codes in file names with the folders they are usually in. " +
+			"The config is written to the path given by -config, with examples to review.",
+		FlagSet: initFlagSet,
+		Exec: func(ctx context.Context, args []string) error {
+			if len(args) == 0 {
+				return &configError{fmt.Errorf("init: no folders given")}
+			}
+
+			if _, err := os.Stat(*cfgPath); err == nil && !*forceFlag {
+				return &configError{fmt.Errorf("init: %q already exists; use -force to overwrite it", *cfgPath)}
+			}
+
+			inferred, err := inferConfig(args)
+			if err != nil {
+				return err
+			}
+
+			var out strings.Builder
+			writeInferredConfig(&out, inferred)
+
+			if err := fileutil.WriteFile(*cfgPath, []byte(out.String()), 0o644); err != nil {
+				return fmt.Errorf("write config: %w", err)
+			}
+
+			logger.Infof(
+				"wrote %q with %d folder and %d file name patterns and %d frame types; review it before use",
+				*cfgPath, len(inferred.Metadata.FolderNamePatterns), len(inferred.Metadata.FileNamePatterns),
+				len(inferred.Metadata.FrameType2Name),
+			)
+
+			return nil
+		},
+	}
+}
+
+// inferConfig scans the folder trees under roots and proposes name patterns
+// and a frame type mapping which fit them.
+func inferConfig(roots []string) (*inferredConfig, error) {
+	folderTypes := make(map[string]string)
+	shapes := make(map[string]map[string]bool)
+	parents := make(map[string]bool)
+	files := make(map[string][]treeFile)
+	codeParts := make(map[int]bool)
+
+	inferred := &inferredConfig{Roots: roots, Counts: make(map[string]map[string]int)}
+
+	for _, root := range roots {
+		err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+
+			if entry.IsDir() {
+				if path == root {
+					return nil
+				}
+
+				shape, frameType, ok := folderShape(entry.Name())
+				if !ok {
+					inferred.UnmatchedFolders = append(inferred.UnmatchedFolders, path)
+					return nil
+				}
+
+				if shapes[shape] == nil {
+					shapes[shape] = make(map[string]bool)
+				}
+
+				shapes[shape][frameType] = true
+				folderTypes[path] = strings.ToLower(frameType)
+				parents[filepath.Dir(path)] = true
+
+				return nil
+			}
+
+			if !entry.Type().IsRegular() {
+				return nil
+			}
+
+			dir := filepath.Dir(path)
+			if _, ok := folderTypes[dir]; !ok {
+				return nil
+			}
+
+			code, parts, ok := fileCode(entry.Name())
+			if !ok {
+				inferred.UnmatchedFiles = append(inferred.UnmatchedFiles, path)
+				return nil
+			}
+
+			codeParts[parts] = true
+			files[code] = append(files[code], treeFile{path: path, code: code})
+
+			if inferred.Counts[code] == nil {
+				inferred.Counts[code] = make(map[string]int)
+			}
+
+			inferred.Counts[code][folderTypes[dir]]++
+
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("scan %q: %w", root, err)
+		}
+	}
+
+	if len(shapes) == 0 {
+		return nil, fmt.Errorf("init: no folder names with a frame size found")
+	}
+
+	for dir := range parents {
+		inferred.IncludeFolders = append(inferred.IncludeFolders, filepath.Join(dir, "*"))
+	}
+
+	sort.Strings(inferred.IncludeFolders)
+
+	inferred.Metadata.FolderNamePatterns = folderNamePatterns(shapes)
+	inferred.Metadata.FileNamePatterns = fileNamePatterns(codeParts)
+	inferred.Metadata.FrameType2Name = frameTypeMapping(inferred.Counts)
+
+	// Examples are taken from files in the folders their frame type code maps
+	// to, and are given the attributes the proposed patterns extract.
+	codes := make([]string, 0, len(files))
+	for code := range files {
+		codes = append(codes, code)
+	}
+
+	sort.Strings(codes)
+
+	for _, code := range codes {
+		sort.Slice(files[code], func(i, j int) bool { return files[code][i].path < files[code][j].path })
+
+		added := 0
+		for _, f := range files[code] {
+			if added == examplesPerCode {
+				break
+			}
+
+			if !contains(inferred.Metadata.FrameType2Name[code], folderTypes[filepath.Dir(f.path)]) {
+				continue
+			}
+
+			example, ok := inferExample(inferred.Metadata, f.path)
+			if ok {
+				inferred.Examples = append(inferred.Examples, example)
+				added++
+			}
+		}
+	}
+
+	return inferred, nil
+}
+
+// folderShape returns the shape of the folder name and its frame type, if it
+// has a frame size at its start or end.
+func folderShape(name string) (string, string, bool) {
+	words := strings.Split(name, " ")
+
+	switch {
+	case len(words) == 1 && sizeRegex.MatchString(name):
+		return shapeSize, "", true
+	case len(words) > 1 && sizeRegex.MatchString(words[0]):
+		return shapeSizeType, strings.Join(words[1:], " "), true
+	case len(words) > 1 && sizeRegex.MatchString(words[len(words)-1]):
+		return shapeTypeSize, strings.Join(words[:len(words)-1], " "), true
+	}
+
+	return "", "", false
+}
+
+// fileCode returns the frame type code of a file named like
+// "<order>_<code>_<size>...", and the number of parts the code has.
+func fileCode(fileName string) (string, int, bool) {
+	parts := strings.Split(strings.TrimSuffix(fileName, filepath.Ext(fileName)), "_")
+
+	for i := 2; i < len(parts); i++ {
+		if sizePrefixRegex.MatchString(parts[i]) {
+			return strings.ToLower(strings.Join(parts[1:i], "_")), i - 1, true
+		}
+	}
+
+	return "", 0, false
+}
+
+func folderNamePatterns(shapes map[string]map[string]bool) []string {
+	types := func(shape string) string {
+		names := make([]string, 0, len(shapes[shape]))
+		for name := range shapes[shape] {
+			names = append(names, regexp.QuoteMeta(name))
+		}
+
+		sort.Strings(names)
+
+		return strings.Join(names, "|")
+	}
+
+	var patterns []string
+
+	if shapes[shapeSize] != nil {
+		patterns = append(patterns, `^(?P<frame_size>`+sizePattern+`)$`)
+	}
+
+	if shapes[shapeSizeType] != nil {
+		patterns = append(patterns, `^(?P<frame_size>`+sizePattern+`) (?P<frame_type>`+types(shapeSizeType)+`)$`)
+	}
+
+	if shapes[shapeTypeSize] != nil {
+		patterns = append(patterns, `^(?P<frame_type>`+types(shapeTypeSize)+`) (?P<frame_size>`+sizePattern+`)$`)
+	}
+
+	return patterns
+}
+
+func fileNamePatterns(codeParts map[int]bool) []string {
+	counts := make([]int, 0, len(codeParts))
+	for n := range codeParts {
+		counts = append(counts, n)
+	}
+
+	sort.Ints(counts)
+
+	patterns := make([]string, 0, len(counts))
+	for _, n := range counts {
+		code := strings.Repeat(`[^_]+_`, n-1) + `[^_]+`
+		patterns = append(patterns, `^([^_]+)_(?P<frame_type>`+code+`)_(?P<frame_size>`+sizePattern+`).*$`)
+	}
+
+	return patterns
+}
+
+// frameTypeMapping maps each frame type code to the folder frame types holding
+// more than a quarter of the files with that code, most common first. Folders
+// holding fewer are assumed to only have misplaced files. Codes whose files are
+// spread out so that no folder type holds that many are mapped to the most
+// common one, so that the code still matches somewhere.
+func frameTypeMapping(counts map[string]map[string]int) map[string][]string {
+	mapping := make(map[string][]string, len(counts))
+
+	for code, byType := range counts {
+		total := 0
+		names := make([]string, 0, len(byType))
+		for name, n := range byType {
+			total += n
+			names = append(names, name)
+		}
+
+		sort.Slice(names, func(i, j int) bool {
+			if byType[names[i]] != byType[names[j]] {
+				return byType[names[i]] > byType[names[j]]
+			}
+
+			return names[i] < names[j]
+		})
+
+		common := 1
+		for common < len(names) && 4*byType[names[common]] > total {
+			common++
+		}
+
+		mapping[code] = names[:common]
+	}
+
+	return mapping
+}
+
+// spreadOut returns true if no folder type holds more than a quarter of the
+// files with the code.
+func spreadOut(byType map[string]int) bool {
+	total, most := 0, 0
+	for _, n := range byType {
+		total += n
+		if n > most {
+			most = n
+		}
+	}
+
+	return 4*most <= total
+}
+
+func inferExample(cfg Metadata, path string) (Example, bool) {
+	logger := logrus.StandardLogger()
+
+	fileAttr, err := getFileAttributes(logger, path, cfg.FileNamePatterns)
+	if err != nil {
+		return Example{}, false
+	}
+
+	folderAttr, err := getFolderAttributes(logger, filepath.Dir(path), cfg.FolderNamePatterns)
+	if err != nil {
+		return Example{}, false
+	}
+
+	return Example{
+		Path:   path,
+		File:   map[string]string{attrFrameType: fileAttr[attrFrameType], attrFrameSize: fileAttr[attrFrameSize]},
+		Folder: map[string]string{attrFrameType: folderAttr[attrFrameType], attrFrameSize: folderAttr[attrFrameSize]},
+	}, true
+}
+
+// yamlString quotes s for YAML, leaving backslashes in patterns as they are.
+func yamlString(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// writeInferredConfig writes the inferred config as a commented watcher.yaml.
+func writeInferredConfig(w io.Writer, inferred *inferredConfig) {
+	fmt.Fprintln(w, "# Watcher config inferred by \"watcher init\" from:")
+	for _, root := range inferred.Roots {
+		fmt.Fprintf(w, "#   %s\n", root)
+	}
+
+	fmt.Fprintln(w, "#")
+	fmt.Fprintln(w, "# Review the name patterns and the frame type mapping before using it. The")
+	fmt.Fprintln(w, "# examples at the end are checked whenever the config is loaded; see")
+	fmt.Fprintln(w, "# watcher.yaml in the watcher's repository for all the other settings.")
+	fmt.Fprintln(w)
+
+	fmt.Fprintln(w, "metadata:")
+	fmt.Fprintln(w, "  # Frame types are the folder names seen with the frame size removed.")
+	fmt.Fprintln(w, "  folder_name_patterns:")
+	for _, p := range inferred.Metadata.FolderNamePatterns {
+		fmt.Fprintf(w, "    - %s\n", yamlString(p))
+	}
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "  # The first unnamed group is the order ID.")
+	fmt.Fprintln(w, "  file_name_patterns:")
+	for _, p := range inferred.Metadata.FileNamePatterns {
+		fmt.Fprintf(w, "    - %s\n", yamlString(p))
+	}
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "  # Each code is mapped to the folder types holding more than a quarter of the")
+	fmt.Fprintln(w, "  # files with it, or to the most common one if none does; the number of files")
+	fmt.Fprintln(w, "  # found in each folder type is noted.")
+	fmt.Fprintln(w, "  frame_type_mapping:")
+
+	codes := make([]string, 0, len(inferred.Metadata.FrameType2Name))
+	for code := range inferred.Metadata.FrameType2Name {
+		codes = append(codes, code)
+	}
+
+	sort.Strings(codes)
+
+	for _, code := range codes {
+		names := inferred.Metadata.FrameType2Name[code]
+
+		quoted := make([]string, 0, len(names))
+		for _, name := range names {
+			quoted = append(quoted, yamlString(name))
+		}
+
+		byType := inferred.Counts[code]
+		seen := make([]string, 0, len(byType))
+		for name := range byType {
+			seen = append(seen, name)
+		}
+
+		sort.Slice(seen, func(i, j int) bool {
+			if byType[seen[i]] != byType[seen[j]] {
+				return byType[seen[i]] > byType[seen[j]]
+			}
+
+			return seen[i] < seen[j]
+		})
+
+		notes := make([]string, 0, len(seen))
+		for _, name := range seen {
+			label := name
+			if label == "" {
+				label = "(size only)"
+			}
+
+			notes = append(notes, fmt.Sprintf("%s: %d", label, byType[name]))
+		}
+
+		note := strings.Join(notes, ", ")
+		if spreadOut(byType) {
+			note += "; spread out, review"
+		}
+
+		fmt.Fprintf(w, "    %s: [%s] # %s\n", yamlString(code), strings.Join(quoted, ", "), note)
+	}
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "watcher:")
+	fmt.Fprintln(w, "  include_folders:")
+	for _, dir := range inferred.IncludeFolders {
+		fmt.Fprintf(w, "    - %s\n", yamlString(dir))
+	}
+
+	writeUnmatched := func(kind string, paths []string) {
+		if len(paths) == 0 {
+			return
+		}
+
+		fmt.Fprintln(w)
+		fmt.Fprintf(w, "# %s that didn't fit any pattern (%d):\n", kind, len(paths))
+		for i, path := range paths {
+			if i == maxUnmatched {
+				break
+			}
+
+			fmt.Fprintf(w, "#   %s\n", path)
+		}
+	}
+
+	writeUnmatched("Folders", inferred.UnmatchedFolders)
+	writeUnmatched("Files", inferred.UnmatchedFiles)
+
+	if len(inferred.Examples) == 0 {
+		return
+	}
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "# Files found for each frame type code, with the attributes the patterns above")
+	fmt.Fprintln(w, "# give them and their folder.")
+	fmt.Fprintln(w, "examples:")
+	for _, e := range inferred.Examples {
+		fmt.Fprintf(w, "  - path: %s\n", yamlString(e.Path))
+		fmt.Fprintf(w, "    file: {frame_type: %s, frame_size: %s}\n", yamlString(e.File[attrFrameType]), yamlString(e.File[attrFrameSize]))
+		fmt.Fprintf(w, "    folder: {frame_type: %s, frame_size: %s}\n", yamlString(e.Folder[attrFrameType]), yamlString(e.Folder[attrFrameSize]))
+	}
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestInferConfig(t *testing.T) {
+	root := t.TempDir()
+
+	for _, path := range []string{
+		"8x10 framed/1_fr_8x10.jpg",
+		"8x10 framed/2_fr_8x10.jpg",
+		"8x10 framed/3_fr_8x10.jpg",
+		"8x10 framed/4_cn_8x10.jpg", // Misplaced.
+		"11x14 black framed/5_fr_11x14.jpg",
+		"11x14 black framed/6_fr_11x14.jpg",
+		"framed 2pc 12x12/7_fr_2pc_12x12_1.jpg",
+		"12x12/8_cn_12x12.jpg",
+		"12x12/9_cn_12x12.jpg",
+		"12x12/10_cn_12x12.jpg",
+		"12x12/notes.txt",
+		// Spread out so that no folder type holds more than a quarter.
+		"8x10 framed/11_wd_8x10.jpg",
+		"11x14 black framed/12_wd_11x14.jpg",
+		"framed 2pc 12x12/13_wd_12x12.jpg",
+		"16x20 wood/14_wd_16x20.jpg",
+		"8x10 white/15_wd_8x10.jpg",
+		"misc/readme.txt",
+	} {
+		path = filepath.Join(root, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(path, nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	inferred, err := inferConfig([]string{root})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantFolderPatterns := []string{
+		`^(?P<frame_size>\d+x\d+)$`,
+		`^(?P<frame_size>\d+x\d+) (?P<frame_type>black framed|framed|white|wood)$`,
+		`^(?P<frame_type>framed 2pc) (?P<frame_size>\d+x\d+)$`,
+	}
+	if !reflect.DeepEqual(inferred.Metadata.FolderNamePatterns, wantFolderPatterns) {
+		t.Errorf("got unexpected folder name patterns, want=%q, got=%q", wantFolderPatterns, inferred.Metadata.FolderNamePatterns)
+	}
+
+	if len(inferred.Metadata.FileNamePatterns) != 2 {
+		t.Errorf("got unexpected file name patterns, want=2, got=%q", inferred.Metadata.FileNamePatterns)
+	}
+
+	wantMapping := map[string][]string{
+		"fr":     {"framed", "black framed"},
+		"fr_2pc": {"framed 2pc"},
+		"cn":     {""},
+		"wd":     {"black framed"},
+	}
+	if !reflect.DeepEqual(inferred.Metadata.FrameType2Name, wantMapping) {
+		t.Errorf("got unexpected frame type mapping, want=%q, got=%q", wantMapping, inferred.Metadata.FrameType2Name)
+	}
+
+	if want := []string{filepath.Join(root, "misc")}; !reflect.DeepEqual(inferred.UnmatchedFolders, want) {
+		t.Errorf("got unexpected unmatched folders, want=%q, got=%q", want, inferred.UnmatchedFolders)
+	}
+
+	// The written config loads, and its examples pass.
+	var out strings.Builder
+	writeInferredConfig(&out, inferred)
+
+	var cfg Config
+	if err := yaml.Unmarshal([]byte(out.String()), &cfg); err != nil {
+		t.Fatalf("failed to load inferred config: %v\n%s", err, out.String())
+	}
+
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("got inferred config not valid: %v\n%s", err, out.String())
+	}
+
+	if len(cfg.Examples) != 6 {
+		t.Errorf("got unexpected examples, want=6, got=%+v", cfg.Examples)
+	}
+
+	if !strings.Contains(out.String(), "    'wd': ['black framed'] # black framed: 1, framed: 1, framed 2pc: 1, white: 1, wood: 1; spread out, review\n") {
+		t.Errorf("got spread out code not noted in the inferred config:\n%s", out.String())
+	}
+
+	// Examples catch patterns which no longer fit.
+	cfg.Metadata.FolderNamePatterns = cfg.Metadata.FolderNamePatterns[:1]
+	if err := cfg.Validate(); err == nil {
+		t.Errorf("got no error for examples not fitting the patterns")
+	}
+}
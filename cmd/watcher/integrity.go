@@ -0,0 +1,91 @@
+// Copyright (2023 -- present) Shahruk Hossain <shahruk10@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//		 http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// ==============================================================================
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/shahruk10/watcher/internal/imageinfo"
+	"github.com/shahruk10/watcher/internal/notify"
+	"github.com/sirupsen/logrus"
+)
+
+// IntegrityConfig controls checking that image files are intact and that
+// their contents match their extension.
+type IntegrityConfig struct {
+	Enabled bool `yaml:"enabled"`
+}
+
+// checkIntegrity returns true with the verdict set on the result if the file
+// is a damaged image, or its contents don't match its extension.
+func checkIntegrity(logger *logrus.Logger, result *Result) bool {
+	filePath := result.Path
+
+	info, err := os.Stat(filePath)
+	if err != nil || !info.Mode().IsRegular() {
+		return false
+	}
+
+	expected := imageinfo.FormatForExtension(filePath)
+
+	format, err := imageinfo.VerifyFile(filePath)
+	switch {
+	case errors.Is(err, imageinfo.ErrUnsupported) && expected == "":
+		return false
+
+	case format != "" && format != expected:
+		ext := strings.TrimPrefix(strings.ToLower(filepath.Ext(filePath)), ".")
+		if ext == "" {
+			ext = "none"
+		}
+
+		result.Verdict = VerdictMislabelledFile
+		result.Title = "MISLABELLED FILE"
+		result.Fields = []notify.Field{
+			{Label: "📁 file", Value: filepath.Base(filePath)},
+			{Label: "❌ contents", Value: fmt.Sprintf("%s image, extension is %s", strings.ToUpper(format), ext)},
+		}
+
+	case errors.Is(err, imageinfo.ErrUnsupported):
+		result.Verdict = VerdictCorruptFile
+		result.Title = "CORRUPT FILE"
+		result.Fields = []notify.Field{
+			{Label: "📁 file", Value: filepath.Base(filePath)},
+			{Label: "❌ contents", Value: fmt.Sprintf("not a %s image", strings.ToUpper(expected))},
+		}
+
+	case errors.Is(err, imageinfo.ErrCorrupt):
+		result.Verdict = VerdictCorruptFile
+		result.Title = "CORRUPT FILE"
+		result.Fields = []notify.Field{
+			{Label: "📁 file", Value: filepath.Base(filePath)},
+			{Label: "❌ damaged", Value: strings.TrimPrefix(err.Error(), imageinfo.ErrCorrupt.Error()+": ")},
+		}
+
+	case err != nil:
+		logger.Debugf("not verifying image %q: %v", filePath, err)
+		return false
+
+	default:
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestCheckIntegrity(t *testing.T) {
+	dir := t.TempDir()
+	logger := logrus.New()
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewGray(image.Rect(0, 0, 4, 4))); err != nil {
+		t.Fatal(err)
+	}
+
+	pngData := buf.Bytes()
+
+	tests := []struct {
+		name string
+		data []byte
+		want Verdict
+	}{
+		{name: "order_fr_8x10.png", data: pngData},
+		{name: "order_fr_8x10.pdf", data: []byte("%PDF-1.4")},
+		{name: "truncated_fr_8x10.png", data: pngData[:len(pngData)-12], want: VerdictCorruptFile},
+		{name: "empty_fr_8x10.jpg", data: nil, want: VerdictCorruptFile},
+		{name: "renamed_fr_8x10.jpg", data: pngData, want: VerdictMislabelledFile},
+		{name: "renamed_fr_8x10.pdf", data: pngData, want: VerdictMislabelledFile},
+	}
+
+	for _, tc := range tests {
+		path := filepath.Join(dir, tc.name)
+		if err := os.WriteFile(path, tc.data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		result := &Result{Path: path}
+		found := checkIntegrity(logger, result)
+
+		if found != (tc.want != "") || result.Verdict != tc.want {
+			t.Errorf("%s: got unexpected verdict, want=%q, got=%q", tc.name, tc.want, result.Verdict)
+		}
+	}
+}
@@ -25,30 +25,58 @@ import (
 	"path/filepath"
 	"regexp"
 	"strings"
-	"sync"
 	"syscall"
+	"time"
 
+	"github.com/peterbourgon/ff/v3"
 	"github.com/peterbourgon/ff/v3/ffcli"
+	"github.com/shahruk10/watcher/internal/fileutil"
+	"github.com/shahruk10/watcher/internal/notify"
+	"github.com/shahruk10/watcher/internal/sdnotify"
 	"github.com/shahruk10/watcher/internal/watcher"
+	"github.com/shahruk10/watcher/internal/webhook"
 	"github.com/sirupsen/logrus"
-	"github.com/sqweek/dialog"
 	"gopkg.in/yaml.v3"
 )
 
+// Exit codes of the watcher.
+const (
+	exitRuntimeError = 1
+	exitConfigError  = 2
+	exitViolations   = 3
+)
+
 func main() {
 	var (
-		rootFlagSet = flag.NewFlagSet("watcher", flag.ExitOnError)
-		cfgPath     = rootFlagSet.String("config", "watcher.yaml", "Path to watcher config file.")
-		helpFlag    = rootFlagSet.Bool("help", false, "Display usage information.")
-		verboseFlag = rootFlagSet.Bool("verbose", false, "Display debugging information.")
+		rootFlagSet  = flag.NewFlagSet("watcher", flag.ExitOnError)
+		cfgPath      = rootFlagSet.String("config", "watcher.yaml", "Path to watcher config file.")
+		helpFlag     = rootFlagSet.Bool("help", false, "Display usage information.")
+		verboseFlag  = rootFlagSet.Bool("verbose", false, "Display debugging information.")
+		headlessFlag = rootFlagSet.Bool("headless", false, "Run without GUI dialogs, logging JSON to stdout.")
 	)
 
 	logger := logrus.New()
 	logger.SetFormatter(&logrus.TextFormatter{FullTimestamp: true})
 
+	mode := &runMode{}
+
 	root := &ffcli.Command{
-		ShortUsage: "watcher [flags]",
+		ShortUsage: "watcher [flags] [<subcommand>]",
 		FlagSet:    rootFlagSet,
+		Options:    []ff.Option{ff.WithEnvVarPrefix("WATCHER")},
+		Subcommands: []*ffcli.Command{
+			newReleaseCmd(logger, cfgPath, mode),
+			newMuteCmd(logger, cfgPath),
+			newUnmuteCmd(logger, cfgPath),
+			newViolationsCmd(logger, cfgPath),
+			newScanCmd(logger, cfgPath, mode),
+			newExplainCmd(logger, cfgPath, mode),
+			newFixCmd(logger, cfgPath, mode),
+			newUndoCmd(logger, mode),
+			newInitCmd(logger, cfgPath),
+			newScaffoldCmd(logger, cfgPath, mode),
+			newHistoryCmd(logger, cfgPath, mode),
+		},
 		Exec: func(ctx context.Context, args []string) error {
 			if *helpFlag {
 				rootFlagSet.Usage()
@@ -60,28 +88,37 @@ func main() {
 			}
 
 			if _, err := os.Stat(*cfgPath); os.IsNotExist(err) {
-				return fmt.Errorf("failed to find watcher config file at %q", *cfgPath)
+				return &configError{fmt.Errorf("failed to find watcher config file at %q", *cfgPath)}
 			}
 
-			return watch(ctx, logger, *cfgPath)
+			return watch(ctx, logger, *cfgPath, mode)
 		},
 	}
 
 	if err := root.Parse(os.Args[1:]); err != nil {
-		title := "ERROR"
-		msg := err.Error()
-		showAlert(logger, title, msg)
-		return
+		reportError(logger, mode, err)
+		os.Exit(exitConfigError)
+	}
+
+	if *headlessFlag {
+		mode.setHeadless(logger)
 	}
 
 	waitCh := make(chan struct{})
 	ctx, cancel := context.WithCancel(context.Background())
+	exitCode := 0
 
 	go func() {
-		if err := root.Run(ctx); err != nil && !errors.Is(err, flag.ErrHelp) && !errors.Is(err, ctx.Err()) {
-			title := "ERROR"
-			msg := err.Error()
-			showAlert(logger, title, msg)
+		err := root.Run(ctx)
+		if found := (*violationsFoundError)(nil); errors.As(err, &found) {
+			exitCode = exitViolations
+		} else if err != nil && !errors.Is(err, flag.ErrHelp) && !errors.Is(err, ctx.Err()) {
+			reportError(logger, mode, err)
+
+			exitCode = exitRuntimeError
+			if cfgErr := (*configError)(nil); errors.As(err, &cfgErr) {
+				exitCode = exitConfigError
+			}
 		}
 
 		cancel()
@@ -100,27 +137,80 @@ func main() {
 
 	// Wait for the go routine above to return to gracefully stop.
 	<-waitCh
+
+	os.Exit(exitCode)
+}
+
+// runMode holds how the watcher interacts with the user.
+type runMode struct {
+	// headless is set when running without a display; GUI dialogs are never
+	// shown and logs are written as JSON to stdout.
+	headless bool
 }
 
-func watch(ctx context.Context, logger *logrus.Logger, cfgPath string) error {
+func (m *runMode) setHeadless(logger *logrus.Logger) {
+	m.headless = true
+	logger.SetOutput(os.Stdout)
+	logger.SetFormatter(&logrus.JSONFormatter{})
+}
+
+// configError is returned when the watcher can't start because of an invalid
+// config.
+type configError struct {
+	err error
+}
+
+func (e *configError) Error() string {
+	return e.err.Error()
+}
+
+func (e *configError) Unwrap() error {
+	return e.err
+}
+
+func readConfig(cfgPath string) (Config, error) {
 	cfgData, err := os.ReadFile(cfgPath)
 	if err != nil {
-		return fmt.Errorf("read config file: %w", err)
+		return Config{}, &configError{fmt.Errorf("read config file: %w", err)}
 	}
 
 	var cfg Config
 	if err := yaml.Unmarshal(cfgData, &cfg); err != nil {
-		return fmt.Errorf("load config file: %w", err)
+		return Config{}, &configError{fmt.Errorf("load config file: %w", err)}
+	}
+
+	return cfg, nil
+}
+
+func loadConfig(logger *logrus.Logger, cfgPath string) (Config, error) {
+	cfg, err := readConfig(cfgPath)
+	if err != nil {
+		return Config{}, err
 	}
 
 	if err := cfg.Validate(); err != nil {
-		return fmt.Errorf("validate config: %w", err)
+		return Config{}, &configError{fmt.Errorf("validate config: %w", err)}
 	}
 
 	if cfg.Debug {
 		logger.SetLevel(logrus.DebugLevel)
 	}
 
+	return cfg, nil
+}
+
+func watch(ctx context.Context, logger *logrus.Logger, cfgPath string, mode *runMode) error {
+	// The run mode is checked before validating the config, so that config
+	// errors are never shown in a dialog when running as a daemon.
+	if cfg, err := readConfig(cfgPath); err == nil && cfg.Mode == modeDaemon && !mode.headless {
+		mode.setHeadless(logger)
+	}
+
+	cfg, err := loadConfig(logger, cfgPath)
+	if err != nil {
+		return err
+	}
+
 	w, err := watcher.New(logger, cfg.Watcher)
 	if err != nil {
 		return err
@@ -135,8 +225,98 @@ func watch(ctx context.Context, logger *logrus.Logger, cfgPath string) error {
 		return fmt.Errorf("failed to add folders to watch list: %w", err)
 	}
 
+	overrides := NewOverrides(logger, cfg.Watcher)
+
+	var webhooks *webhook.Sender
+	if cfg.webhooksEnabled() {
+		webhooks, err = webhook.New(logger, cfg.Webhook.Config)
+		if err != nil {
+			return &configError{err}
+		}
+
+		go webhooks.Run(ctx)
+	}
+
+	alertState, err := OpenAlertState(cfg.Alerts)
+	if err != nil {
+		return &configError{err}
+	}
+
+	router := NewRouter(logger, cfg, mode.headless, alertState)
+
+	pipeline, err := NewPipeline(cfg, alertState.filter(logger, router), webhooks)
+	if err != nil {
+		return &configError{fmt.Errorf("failed to set up actions: %w", err)}
+	}
+
+	var violations *Violations
+	if cfg.Violations.Enabled {
+		if violations, err = OpenViolations(cfg.Violations); err != nil {
+			return err
+		}
+
+		escalation, ok := router.Routes[routeEscalation]
+		if !ok {
+			escalation = router.Default
+		}
+
+		go violations.Run(ctx, logger, escalation, alertState, time.Minute)
+	}
+
+	var (
+		recorders []Recorder
+		audit     *AuditLog
+	)
+
+	if cfg.Audit.Enabled {
+		audit, err = OpenAudit(cfg.Audit)
+		if err != nil {
+			return err
+		}
+
+		defer audit.Close()
+
+		recorders = append(recorders, audit)
+	}
+
+	var history *History
+	if cfg.History.Enabled {
+		history = NewHistory(cfg.History)
+		recorders = append(recorders, history)
+
+		go history.Run(ctx, logger)
+
+		defer func() {
+			if err := history.Flush(); err != nil {
+				logger.Errorf("failed to write history: %v", err)
+			}
+		}()
+	}
+
+	var sets *Sets
+	if cfg.Sets.Enabled {
+		sets = NewSets(cfg, pipeline, recorders)
+	}
+
 	callbacks := []watcher.Callback{
-		CheckSizeAndFrame(cfg),
+		ReloadOverrides(overrides),
+		CheckSizeAndFrame(cfg, overrides, pipeline, violations, sets, recorders),
+	}
+
+	if violations != nil {
+		callbacks = append(callbacks, TrackRemovedFiles(violations))
+	}
+
+	if sets != nil {
+		callbacks = append(callbacks, TrackSetPieces(sets))
+	}
+
+	if history != nil {
+		callbacks = append(callbacks, TrackHistory(history))
+	}
+
+	if audit != nil {
+		callbacks = append(callbacks, AuditRemovedFiles(audit))
 	}
 
 	if err := w.AddCallbacks(callbacks...); err != nil {
@@ -150,7 +330,21 @@ func watch(ctx context.Context, logger *logrus.Logger, cfgPath string) error {
 		logger.Printf("[%d] %s", i+1, folder)
 	}
 
-	logger.Info("Press CTRL + C to close")
+	if !mode.headless {
+		logger.Info("Press CTRL + C to close")
+	}
+
+	if _, err := sdnotify.Notify(sdnotify.Ready); err != nil {
+		logger.Errorf("failed to notify service manager: %v", err)
+	}
+
+	go func() {
+		if err := sdnotify.RunWatchdog(ctx); err != nil && !errors.Is(err, ctx.Err()) {
+			logger.Errorf("failed to ping service manager watchdog: %v", err)
+		}
+	}()
+
+	defer sdnotify.Notify(sdnotify.Stopping)
 
 	return w.Watch(ctx)
 }
@@ -158,76 +352,212 @@ func watch(ctx context.Context, logger *logrus.Logger, cfgPath string) error {
 const (
 	attrFrameSize = "frame_size"
 	attrFrameType = "frame_type"
+	attrOrderID   = "order_id"
+	attrPiece     = "piece"
 )
 
-func CheckSizeAndFrame(cfg Config) watcher.Callback {
+func CheckSizeAndFrame(cfg Config, overrides *Overrides, pipeline Pipeline, violations *Violations, sets *Sets, recorders []Recorder) watcher.Callback {
 	return func(ctx context.Context, logger *logrus.Logger, e watcher.Event) error {
 		if !e.HasOp(watcher.CreateOp) && !e.HasOp(watcher.WriteOp) {
 			return nil
 		}
 
-		fileAttr, err := getFileAttributes(logger, e.Name, cfg.Metadata.FileNamePatterns)
+		result, err := checkFile(logger, cfg, overrides, e.Name)
 		if err != nil {
 			return err
 		}
 
-		dirAttr, err := getFolderAttributes(logger, filepath.Dir(e.Name), cfg.Metadata.FolderNamePatterns)
-		if err != nil {
-			return err
-		}
+		if result == nil {
+			for _, recorder := range recorders {
+				if skips, ok := recorder.(SkipRecorder); ok {
+					if err := skips.Skipped(e.Name, e.Op.String()); err != nil {
+						logger.Errorf("failed to record skipped file: %v", err)
+					}
+				}
+			}
 
-		// File / folder attributes could not be parsed from file name;
-		// getFileAttributes / getFolderAttributes will have shown an alert already,
-		// so we just return here.
-		if fileAttr == nil || dirAttr == nil {
 			return nil
 		}
 
-		frameTypeNames, ok := cfg.Metadata.FrameType2Name[fileAttr[attrFrameType]]
-		if !ok {
-			title := "UNKNOWN FRAME TYPE"
-			msg := fmt.Sprintf(
-				"%s: %s\n%s: %s",
-				"📁 file", e.Name, "❌ unknown frame type", fileAttr[attrFrameType],
-			)
+		result.Op = e.Op.String()
 
-			return showAlert(logger, title, msg)
+		// The owner is looked up before actions move the file away.
+		if len(recorders) > 0 {
+			if result.Owner, err = fileutil.Owner(e.Name); err != nil {
+				logger.Debugf("failed to look up owner: %v", err)
+			}
 		}
 
-		wrongFrameType := true
-		for _, name := range frameTypeNames {
-			wrongFrameType = wrongFrameType && dirAttr[attrFrameType] != name
+		if sets != nil && result.FileAttr != nil && !overrides.Resolve(filepath.Dir(e.Name)).Disabled(validatorSet) {
+			sets.Add(ctx, logger, result)
 		}
 
-		wrongFrameSize := dirAttr[attrFrameSize] != fileAttr[attrFrameSize]
-		currentDirName := filepath.Base(filepath.Dir(e.Name))
-
-		if wrongFrameSize || wrongFrameType {
-			var correctDirName string
-			if !wrongFrameType {
-				correctDirName = strings.TrimSpace(fmt.Sprintf("%s %s", fileAttr[attrFrameSize], dirAttr[attrFrameType]))
-			} else {
-				possibleNames := make([]string, 0, len(frameTypeNames))
-				for _, name := range frameTypeNames {
-					possibleNames = append(possibleNames, strings.TrimSpace(fmt.Sprintf("%s %s", fileAttr[attrFrameSize], name)))
-				}
+		// The violation is recorded before carrying out actions, so that
+		// actions moving the file away resolve it.
+		if violations != nil {
+			if err := violations.Record(logger, result); err != nil {
+				logger.Errorf("failed to record violation: %v", err)
+			}
+		}
 
-				correctDirName = strings.Join(possibleNames, " OR ")
+		err = pipeline.Run(ctx, logger, result)
+
+		for _, recorder := range recorders {
+			if err := recorder.Record(result); err != nil {
+				logger.Errorf("failed to record result: %v", err)
 			}
+		}
+
+		return err
+	}
+}
+
+// checkFile validates the file at the given path, returning nil if the file
+// should not be validated at all.
+func checkFile(logger *logrus.Logger, cfg Config, overrides *Overrides, filePath string) (*Result, error) {
+	if isOverrideFile(filePath) {
+		return nil, nil
+	}
+
+	result := &Result{Time: time.Now(), Path: filePath}
+
+	override := overrides.Resolve(filepath.Dir(filePath))
+
+	// Damaged files are caught before anything else, so they're quarantined
+	// even if their names are valid.
+	if cfg.Integrity.Enabled && !override.Disabled(validatorIntegrity) && checkIntegrity(logger, result) {
+		return result, nil
+	}
+
+	if override.Disabled(validatorFileName) {
+		logger.Debugf("file name validation disabled for %q", filePath)
+		return nil, nil
+	}
+
+	fileNamePatterns := append(append([]string{}, override.FileNamePatterns...), cfg.Metadata.FileNamePatterns...)
+
+	embedded := cfg.Metadata.Embedded
+	if override.Disabled(validatorMetadata) {
+		embedded.Source = sourceName
+	}
+
+	fileAttr, err := resolveFileAttributes(logger, embedded, filePath, fileNamePatterns)
+	if invalid := (*invalidNameError)(nil); errors.As(err, &invalid) {
+		result.Verdict, result.Title, result.Fields = VerdictInvalidFileName, invalid.title, invalid.fields
+		return result, nil
+	} else if mismatch := (*metadataMismatchError)(nil); errors.As(err, &mismatch) {
+		result.Verdict, result.Title, result.Fields = VerdictMetadataMismatch, "METADATA MISMATCH", mismatch.fields
+		return result, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	result.FileAttr = fileAttr
+	result.FilePattern = matchedPattern(strings.TrimSuffix(filepath.Base(filePath), filepath.Ext(filePath)), fileNamePatterns)
+
+	dirAttr, ok := override.FolderAttributes()
+	if !ok {
+		if override.Disabled(validatorFolderName) {
+			logger.Debugf("folder name validation disabled for %q", filePath)
+			return nil, nil
+		}
+
+		dirAttr, err = getFolderAttributes(logger, filepath.Dir(filePath), cfg.Metadata.FolderNamePatterns)
+		if invalid := (*invalidNameError)(nil); errors.As(err, &invalid) {
+			result.Verdict, result.Title, result.Fields = VerdictInvalidFolderName, invalid.title, invalid.fields
+			return result, nil
+		} else if err != nil {
+			return nil, err
+		}
+
+		result.FolderPattern = matchedPattern(filepath.Base(filepath.Dir(filePath)), cfg.Metadata.FolderNamePatterns)
+	}
+
+	result.DirAttr = dirAttr
+
+	checkFrameType := !override.Disabled(validatorFrameType)
+	checkFrameSize := !override.Disabled(validatorFrameSize)
+
+	frameTypeNames, ok := cfg.Metadata.FrameType2Name[fileAttr[attrFrameType]]
+	if !ok && checkFrameType {
+		result.Verdict = VerdictUnknownType
+		result.Title = "UNKNOWN FRAME TYPE"
+		result.Fields = []notify.Field{
+			{Label: "📁 file", Value: filePath},
+			{Label: "❌ unknown frame type", Value: fileAttr[attrFrameType]},
+		}
+
+		return result, nil
+	}
+
+	wrongFrameType := checkFrameType
+	for _, name := range frameTypeNames {
+		wrongFrameType = wrongFrameType && dirAttr[attrFrameType] != name
+	}
 
-			title := "WRONG FOLDER"
-			msg := fmt.Sprintf(
-				"%s: %s\n%s: %s\n%s: %s",
-				"📁 file", filepath.Base(e.Name), "❌ wrong", currentDirName, "✅ correct", correctDirName,
-			)
+	wrongFrameSize := checkFrameSize && dirAttr[attrFrameSize] != fileAttr[attrFrameSize]
+	currentDirName := filepath.Base(filepath.Dir(filePath))
 
-			return showAlert(logger, title, msg)
+	if wrongFrameSize || wrongFrameType {
+		if !wrongFrameType {
+			result.CorrectDirNames = []string{strings.TrimSpace(fmt.Sprintf("%s %s", fileAttr[attrFrameSize], dirAttr[attrFrameType]))}
+		} else {
+			result.CorrectDirNames = make([]string, 0, len(frameTypeNames))
+			for _, name := range frameTypeNames {
+				result.CorrectDirNames = append(result.CorrectDirNames, strings.TrimSpace(fmt.Sprintf("%s %s", fileAttr[attrFrameSize], name)))
+			}
 		}
 
-		logger.Debugf("CORRECT FOLDER %q: %q", currentDirName, e.Name)
+		result.Verdict = VerdictWrongFolder
+		result.Title = "WRONG FOLDER"
+		result.Fields = []notify.Field{
+			{Label: "📁 file", Value: filepath.Base(filePath)},
+			{Label: "❌ wrong", Value: currentDirName},
+			{Label: "✅ correct", Value: result.CorrectDirName()},
+		}
 
-		return nil
+		return result, nil
 	}
+
+	if cfg.PDF.Enabled && isPDF(filePath) && !override.Disabled(validatorPDF) && checkPDF(logger, cfg, result) {
+		return result, nil
+	}
+
+	checkDimensions := cfg.Image.Enabled && !override.Disabled(validatorImage)
+	checkColorSpace := len(cfg.Color.Requirements) > 0 && !override.Disabled(validatorColor)
+
+	if checkDimensions || checkColorSpace {
+		result.Image = readImage(logger, filePath)
+	}
+
+	if result.Image != nil && checkDimensions && checkImage(logger, cfg.Image, result) {
+		return result, nil
+	}
+
+	if result.Image != nil && checkColorSpace && checkColor(cfg.Color, result) {
+		return result, nil
+	}
+
+	result.Verdict = VerdictCorrect
+	result.Title = "CORRECT FOLDER"
+	result.Fields = []notify.Field{
+		{Label: "📁 file", Value: filepath.Base(filePath)},
+		{Label: "✅ folder", Value: currentDirName},
+	}
+
+	return result, nil
+}
+
+// invalidNameError is returned when attributes can not be parsed from a file or
+// folder name; it holds the alert to show for it.
+type invalidNameError struct {
+	title  string
+	fields []notify.Field
+}
+
+func (e *invalidNameError) Error() string {
+	n := notify.Notification{Fields: e.fields}
+	return fmt.Sprintf("%s: %s", e.title, n.Message())
 }
 
 func getFileAttributes(logger *logrus.Logger, filePath string, fileNamePatterns []string) (map[string]string, error) {
@@ -258,22 +588,32 @@ func getFileAttributes(logger *logrus.Logger, filePath string, fileNamePatterns
 
 	if !foundAttrFrameType {
 		title := "INVALID FILE NAME"
-		msg := fmt.Sprintf(
-			"%s: %s\n%s: %s",
-			"📁 file", fileName, "❌ error", "does not specify frame type in the configured format",
-		)
+		fields := []notify.Field{
+			{Label: "📁 file", Value: fileName},
+			{Label: "❌ error", Value: "does not specify frame type in the configured format"},
+		}
 
-		return nil, showAlert(logger, title, msg)
+		return nil, &invalidNameError{title: title, fields: fields}
 	}
 
 	if !foundAttrFrameSize {
 		title := "INVALID FILE NAME"
-		msg := fmt.Sprintf(
-			"%s: %s\n%s: %s",
-			"📁 file", fileName, "❌ error", "does not specify frame size in the configured format",
-		)
+		fields := []notify.Field{
+			{Label: "📁 file", Value: fileName},
+			{Label: "❌ error", Value: "does not specify frame size in the configured format"},
+		}
+
+		return nil, &invalidNameError{title: title, fields: fields}
+	}
+
+	// Pieces of multi-piece sets are grouped by order and piece index.
+	order, piece := orderAndPiece(fileName, fileNamePatterns)
+	if order != "" {
+		attr[attrOrderID] = order
+	}
 
-		return nil, showAlert(logger, title, msg)
+	if piece != "" {
+		attr[attrPiece] = piece
 	}
 
 	logger.Debugf("file attributes for %q: %s", fileName, attr)
@@ -281,6 +621,33 @@ func getFileAttributes(logger *logrus.Logger, filePath string, fileNamePatterns
 	return attr, nil
 }
 
+// matchedPattern returns the 1-based index of the pattern used to parse the
+// name, or 0 if none of the patterns match it. The patterns are tried together,
+// so the first one that matches is used.
+func matchedPattern(name string, patterns []string) int {
+	if len(patterns) == 0 {
+		return 0
+	}
+
+	combined := regexp.MustCompile("(" + strings.Join(patterns, ")|(") + ")")
+
+	loc := combined.FindStringSubmatchIndex(name)
+	if loc == nil {
+		return 0
+	}
+
+	group := 1
+	for i, pattern := range patterns {
+		if loc[2*group] >= 0 {
+			return i + 1
+		}
+
+		group += 1 + regexp.MustCompile(pattern).NumSubexp()
+	}
+
+	return 0
+}
+
 func getFolderAttributes(logger *logrus.Logger, folderPath string, folderNamePatterns []string) (map[string]string, error) {
 	patterns := "(" + strings.Join(folderNamePatterns, ")|(") + ")"
 	dirNameRegex := regexp.MustCompile(patterns)
@@ -314,12 +681,12 @@ func getFolderAttributes(logger *logrus.Logger, folderPath string, folderNamePat
 
 	if !foundAttrFrameSize {
 		title := "INVALID FOLDER NAME"
-		msg := fmt.Sprintf(
-			"%s: %s\n%s: %s",
-			"📁 folder", dirName, "❌ error", "does not specify frame size in the configured format",
-		)
+		fields := []notify.Field{
+			{Label: "📁 folder", Value: dirName},
+			{Label: "❌ error", Value: "does not specify frame size in the configured format"},
+		}
 
-		return nil, showAlert(logger, title, msg)
+		return nil, &invalidNameError{title: title, fields: fields}
 	}
 
 	logger.Debugf("folder attributes for %q: %s", dirName, attr)
@@ -366,14 +733,19 @@ func getFoldersToWatch(cfg watcher.Config) ([]string, error) {
 	return watchList, nil
 }
 
-var windowMu sync.Mutex
-
-var showAlert = func(logger *logrus.Logger, title, msg string) error {
-	logger.Infof("<< %s >> %q", title, msg)
+// reportError notifies the user of errors which stop the watcher.
+func reportError(logger *logrus.Logger, mode *runMode, err error) {
+	n := notify.Notification{
+		Severity: notify.SeverityError,
+		Title:    "ERROR",
+		Fields:   []notify.Field{{Value: err.Error()}},
+		Time:     time.Now(),
+	}
 
-	windowMu.Lock()
-	dialog.Message(msg).Title(title).Error()
-	windowMu.Unlock()
+	notifier := notify.Fanout{notify.Log{Logger: logger}}
+	if !mode.headless {
+		notifier = append(notifier, notify.Dialog{})
+	}
 
-	return nil
+	notifier.Notify(context.Background(), n)
 }
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/shahruk10/watcher/internal/watcher"
+	"github.com/sirupsen/logrus"
+)
+
+func TestCheckFile(t *testing.T) {
+	root := t.TempDir()
+	logger := logrus.New()
+
+	for _, dir := range []string{"8x10 framed", "misc", "loose"} {
+		if err := os.Mkdir(filepath.Join(root, dir), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := os.WriteFile(filepath.Join(root, "loose", overrideFileName), []byte("disable: [file_name]\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := Config{
+		Watcher: watcher.Config{IncludeFolders: []string{filepath.Join(root, "*")}},
+		Metadata: Metadata{
+			FrameType2Name:     map[string][]string{"fr": {"framed"}, "any": {"black framed", "white framed"}},
+			FolderNamePatterns: []string{`^(?P<frame_size>\d+x\d+) (?P<frame_type>((black|white) )?framed)$`},
+			FileNamePatterns:   []string{`^rush_(?P<frame_type>[^_]+)_(?P<frame_size>\d+x\d+)$`, `^([^_]+)_(?P<frame_type>[^_]+)_(?P<frame_size>\d+x\d+).*$`},
+		},
+	}
+
+	overrides := NewOverrides(logger, cfg.Watcher)
+
+	tests := []struct {
+		path        string
+		wantVerdict Verdict
+		wantDirs    []string
+		wantPattern int
+	}{
+		{path: "8x10 framed/1_fr_8x10.jpg", wantVerdict: VerdictCorrect, wantPattern: 2},
+		{path: "8x10 framed/rush_fr_8x10.jpg", wantVerdict: VerdictCorrect, wantPattern: 1},
+		{path: "8x10 framed/2_fr_11x14.jpg", wantVerdict: VerdictWrongFolder, wantDirs: []string{"11x14 framed"}, wantPattern: 2},
+		{path: "8x10 framed/3_any_8x10.jpg", wantVerdict: VerdictWrongFolder, wantDirs: []string{"8x10 black framed", "8x10 white framed"}, wantPattern: 2},
+		{path: "8x10 framed/4_zz_8x10.jpg", wantVerdict: VerdictUnknownType, wantPattern: 2},
+		{path: "8x10 framed/bad.jpg", wantVerdict: VerdictInvalidFileName},
+		{path: "misc/5_fr_8x10.jpg", wantVerdict: VerdictInvalidFolderName, wantPattern: 2},
+		// Override files, and files in folders with file name validation
+		// disabled, get no verdict at all.
+		{path: "8x10 framed/" + overrideFileName},
+		{path: "loose/6_fr_8x10.jpg"},
+	}
+
+	for _, tc := range tests {
+		filePath := filepath.Join(root, filepath.FromSlash(tc.path))
+
+		result, err := checkFile(logger, cfg, overrides, filePath)
+		if err != nil {
+			t.Errorf("%s: got unexpected error, want=nil, got=%v", tc.path, err)
+			continue
+		}
+
+		if tc.wantVerdict == "" {
+			if result != nil {
+				t.Errorf("%s: got unexpected result, want=nil, got=%+v", tc.path, result)
+			}
+
+			continue
+		}
+
+		if result == nil {
+			t.Errorf("%s: got no result, want=%s", tc.path, tc.wantVerdict)
+			continue
+		}
+
+		if result.Verdict != tc.wantVerdict || result.Path != filePath || result.Title == "" {
+			t.Errorf("%s: got unexpected verdict, want=%s, got=%s (%q)", tc.path, tc.wantVerdict, result.Verdict, result.Title)
+		}
+
+		if !reflect.DeepEqual(result.CorrectDirNames, tc.wantDirs) {
+			t.Errorf("%s: got unexpected correct folders, want=%q, got=%q", tc.path, tc.wantDirs, result.CorrectDirNames)
+		}
+
+		if result.FilePattern != tc.wantPattern {
+			t.Errorf("%s: got unexpected file pattern, want=%d, got=%d", tc.path, tc.wantPattern, result.FilePattern)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+// Copyright (2023 -- present) Shahruk Hossain <shahruk10@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//		 http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// ==============================================================================
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/shahruk10/watcher/internal/fileutil"
+	"github.com/sirupsen/logrus"
+)
+
+// correctFolderPath returns the path of the folder a misplaced file should be
+// moved to. The folder must be unambiguous and already exist next to the
+// folder the file currently is in.
+func correctFolderPath(filePath string, correctDirNames []string) (string, bool) {
+	if len(correctDirNames) != 1 {
+		return "", false
+	}
+
+	dirs := correctFolderPaths(filePath, correctDirNames)
+	if len(dirs) != 1 {
+		return "", false
+	}
+
+	return dirs[0], true
+}
+
+// correctFolderPaths returns the paths of the folders a misplaced file could
+// be moved to, out of those which already exist next to the folder the file
+// currently is in.
+func correctFolderPaths(filePath string, correctDirNames []string) []string {
+	root := filepath.Dir(filepath.Dir(filePath))
+	dirs := make([]string, 0, len(correctDirNames))
+
+	for _, name := range correctDirNames {
+		dir := filepath.Join(root, name)
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			dirs = append(dirs, dir)
+		}
+	}
+
+	return dirs
+}
+
+func absPath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+
+	return path
+}
+
+// isWithin returns true if path is dir, or is inside dir.
+func isWithin(path, dir string) bool {
+	return path == dir || strings.HasPrefix(path, strings.TrimSuffix(dir, string(filepath.Separator))+string(filepath.Separator))
+}
+
+// moveFile moves the file into the given folder, recording the move in the
+// journal. In dry run mode the move is only logged and false is returned.
+func moveFile(logger *logrus.Logger, cfg MoveConfig, filePath, dir string) (bool, error) {
+	dst := filepath.Join(dir, filepath.Base(filePath))
+
+	if cfg.DryRun {
+		logger.Infof("[dry run] would move %q to %q", filePath, dst)
+		return false, nil
+	}
+
+	if err := fileutil.Move(filePath, dst); err != nil {
+		return false, err
+	}
+
+	logger.Infof("moved %q to %q", filePath, dst)
+
+	entry := JournalEntry{Time: time.Now(), Action: "move", From: absPath(filePath), To: absPath(dst)}
+	if err := appendJournal(cfg.JournalPath(), entry); err != nil {
+		logger.Errorf("moved %q to %q but failed to record move: %v", filePath, dst, err)
+	}
+
+	return true, nil
+}
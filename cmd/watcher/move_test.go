@@ -0,0 +1,132 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestCorrectFolderPath(t *testing.T) {
+	root := t.TempDir()
+	for _, dir := range []string{"8x10 framed", "11x14 framed", "11x14 black framed", "11x14 white framed"} {
+		if err := os.Mkdir(filepath.Join(root, dir), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	filePath := filepath.Join(root, "8x10 framed", "1_fr_11x14.jpg")
+
+	tests := []struct {
+		name      string
+		dirNames  []string
+		wantPaths []string
+		wantPath  string
+	}{
+		{name: "existing", dirNames: []string{"11x14 framed"}, wantPaths: []string{"11x14 framed"}, wantPath: "11x14 framed"},
+		{name: "missing", dirNames: []string{"16x20 framed"}},
+		{name: "ambiguous", dirNames: []string{"11x14 black framed", "11x14 white framed"}, wantPaths: []string{"11x14 black framed", "11x14 white framed"}},
+		{name: "one of several existing", dirNames: []string{"11x14 black framed", "11x14 gold framed"}, wantPaths: []string{"11x14 black framed"}},
+		{name: "not a folder", dirNames: []string{"8x10 framed/1_fr_11x14.jpg"}},
+	}
+
+	if err := os.WriteFile(filePath, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range tests {
+		want := make([]string, 0, len(tc.wantPaths))
+		for _, name := range tc.wantPaths {
+			want = append(want, filepath.Join(root, name))
+		}
+
+		if got := correctFolderPaths(filePath, tc.dirNames); !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: got unexpected folders, want=%q, got=%q", tc.name, want, got)
+		}
+
+		// Only a single candidate folder, which exists, is moved to without
+		// asking.
+		dir, ok := correctFolderPath(filePath, tc.dirNames)
+		if wantOK := tc.wantPath != ""; ok != wantOK || (ok && dir != filepath.Join(root, tc.wantPath)) {
+			t.Errorf("%s: got unexpected folder, want=%q (%v), got=%q (%v)", tc.name, tc.wantPath, wantOK, dir, ok)
+		}
+	}
+}
+
+func TestMoveFile(t *testing.T) {
+	root := t.TempDir()
+	logger := logrus.New()
+
+	from, to := filepath.Join(root, "8x10 framed"), filepath.Join(root, "11x14 framed")
+	for _, dir := range []string{from, to} {
+		if err := os.Mkdir(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	writeFile := func(path string) {
+		if err := os.WriteFile(path, []byte(filepath.Base(path)), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cfg := MoveConfig{Enabled: true, Journal: filepath.Join(root, "move.journal")}
+
+	// Dry runs leave the file in place and the journal alone.
+	filePath := filepath.Join(from, "1_fr_11x14.jpg")
+	writeFile(filePath)
+
+	dryRun := cfg
+	dryRun.DryRun = true
+	if moved, err := moveFile(logger, dryRun, filePath, to); moved || err != nil {
+		t.Errorf("got unexpected dry run move, want=false, got=%v (%v)", moved, err)
+	}
+
+	if _, err := os.Stat(cfg.Journal); !os.IsNotExist(err) {
+		t.Errorf("got journal written by dry run: %v", err)
+	}
+
+	// Moves are recorded in the journal, in order.
+	second := filepath.Join(from, "2_fr_11x14.jpg")
+	writeFile(second)
+
+	for _, path := range []string{filePath, second} {
+		if moved, err := moveFile(logger, cfg, path, to); !moved || err != nil {
+			t.Fatalf("got unexpected move of %q, want=true, got=%v (%v)", path, moved, err)
+		}
+	}
+
+	entries, err := readJournal(cfg.Journal)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(entries) != 2 {
+		t.Fatalf("got unexpected journal entries, want=2, got=%+v", entries)
+	}
+
+	for i, path := range []string{filePath, second} {
+		want := JournalEntry{Action: "move", From: path, To: filepath.Join(to, filepath.Base(path))}
+		got := entries[i]
+		if got.Action != want.Action || got.From != want.From || got.To != want.To || got.Time.IsZero() {
+			t.Errorf("got unexpected journal entry %d, want=%+v, got=%+v", i, want, got)
+		}
+	}
+
+	// Files already in the destination folder are never overwritten, and
+	// failed moves aren't recorded.
+	writeFile(filePath)
+	if moved, err := moveFile(logger, cfg, filePath, to); moved || err == nil {
+		t.Errorf("got unexpected move onto an existing file, want=false and error, got=%v (%v)", moved, err)
+	}
+
+	if data, _ := os.ReadFile(filepath.Join(to, "1_fr_11x14.jpg")); string(data) != "1_fr_11x14.jpg" {
+		t.Errorf("got existing file overwritten, got=%q", data)
+	}
+
+	if entries, _ := readJournal(cfg.Journal); len(entries) != 2 {
+		t.Errorf("got failed move recorded in the journal, got=%+v", entries)
+	}
+}
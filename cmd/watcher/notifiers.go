@@ -0,0 +1,187 @@
+// Copyright (2023 -- present) Shahruk Hossain <shahruk10@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//		 http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// ==============================================================================
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/shahruk10/watcher/internal/notify"
+	"github.com/sirupsen/logrus"
+)
+
+// Types of notifiers.
+const (
+	notifierDialog  = "dialog"
+	notifierConsole = "console"
+	notifierLog     = "log"
+	notifierFile    = "file"
+)
+
+// Routing keys other than verdicts.
+const (
+	// routeDefault is for verdicts without a route of their own.
+	routeDefault = "default"
+	// routeEscalation is for violations which are escalated; the default
+	// route is used if it isn't routed.
+	routeEscalation = "escalation"
+)
+
+// NotifierConfig configures a named notifier that alerts can be routed to.
+type NotifierConfig struct {
+	Name string `yaml:"name"`
+	Type string `yaml:"type"`
+	// Path of the file that file notifiers append to.
+	Path string `yaml:"path"`
+	// Prompt makes console notifiers ask on the terminal how to respond to
+	// alerts which offer choices, such as moving a misplaced file.
+	Prompt bool `yaml:"prompt"`
+}
+
+func (cfg *NotifierConfig) Validate() error {
+	if cfg.Name == "" {
+		return fmt.Errorf("name must be specified")
+	}
+
+	switch cfg.Type {
+	case notifierDialog, notifierConsole, notifierLog:
+	case notifierFile:
+		if cfg.Path == "" {
+			return fmt.Errorf("%s notifier %q: path must be specified", cfg.Type, cfg.Name)
+		}
+	default:
+		return fmt.Errorf("notifier %q: unknown type %q", cfg.Name, cfg.Type)
+	}
+
+	return nil
+}
+
+// defaultNotifiers are used if no notifiers are configured; they log and show
+// a dialog for every alert.
+var defaultNotifiers = []NotifierConfig{
+	{Name: "log", Type: notifierLog},
+	{Name: "desktop", Type: notifierDialog},
+}
+
+func validateNotifiers(notifiers []NotifierConfig, routing map[string][]string) error {
+	names := make(map[string]bool)
+	for i := range notifiers {
+		if err := notifiers[i].Validate(); err != nil {
+			return fmt.Errorf("validate notifiers: %w", err)
+		}
+
+		if names[notifiers[i].Name] {
+			return fmt.Errorf("validate notifiers: duplicate name %q", notifiers[i].Name)
+		}
+
+		names[notifiers[i].Name] = true
+	}
+
+	for route, targets := range routing {
+		if route != routeDefault && route != routeEscalation && !isKnownVerdict(Verdict(route)) {
+			return fmt.Errorf("validate routing: unknown verdict %q", route)
+		}
+
+		for _, name := range targets {
+			if !names[name] {
+				return fmt.Errorf("validate routing: %s: unknown notifier %q", route, name)
+			}
+		}
+	}
+
+	return nil
+}
+
+func newNotifier(logger *logrus.Logger, cfg NotifierConfig, alerts AlertsConfig, state *AlertState, headless bool) notify.Notifier {
+	switch cfg.Type {
+	case notifierDialog:
+		d := notify.Dialog{}
+		if state != nil {
+			d.MuteFor = alerts.MuteForOrDefault()
+			d.Mute = func(path string, muteFor time.Duration) error {
+				logger.Infof("muting alerts about %q for %s", path, muteFor)
+				return state.Mute(path, time.Now().Add(muteFor))
+			}
+		}
+
+		return alerts.aggregate(logger, d)
+	case notifierConsole:
+		if cfg.Prompt && !headless {
+			return alerts.aggregate(logger, notify.NewPromptingConsole(os.Stdin, nil))
+		}
+
+		return alerts.aggregate(logger, notify.NewConsole(nil))
+	case notifierFile:
+		return notify.NewFile(cfg.Path)
+	default:
+		return notify.Log{Logger: logger}
+	}
+}
+
+// NewRouter builds the configured notifiers and routes alerts for each verdict
+// to them. Verdicts without a route go to the "default" route, or to every
+// notifier if there is no default route either. When headless, dialog
+// notifiers are left out, console notifiers don't prompt, and alerts with
+// nowhere else to go are logged. If state is given, dialogs offer to mute the
+// file or folder they are about.
+func NewRouter(logger *logrus.Logger, cfg Config, headless bool, state *AlertState) *notify.Router {
+	notifierCfgs := cfg.Notifiers
+	if len(notifierCfgs) == 0 {
+		notifierCfgs = defaultNotifiers
+	}
+
+	notifiers := make(map[string]notify.Notifier)
+	all := make(notify.Fanout, 0, len(notifierCfgs))
+
+	for _, nc := range notifierCfgs {
+		if headless && nc.Type == notifierDialog {
+			logger.Debugf("headless, so not using %s notifier %q", nc.Type, nc.Name)
+			continue
+		}
+
+		n := newNotifier(logger, nc, cfg.Alerts, state, headless)
+		notifiers[nc.Name] = n
+		all = append(all, n)
+	}
+
+	if len(all) == 0 {
+		all = append(all, notify.Log{Logger: logger})
+	}
+
+	router := &notify.Router{Routes: make(map[string]notify.Fanout), Default: all}
+
+	for route, names := range cfg.Routing {
+		targets := make(notify.Fanout, 0, len(names))
+		for _, name := range names {
+			if n, ok := notifiers[name]; ok {
+				targets = append(targets, n)
+			}
+		}
+
+		if len(targets) == 0 {
+			targets = append(targets, notify.Log{Logger: logger})
+		}
+
+		if route == routeDefault {
+			router.Default = targets
+		} else {
+			router.Routes[route] = targets
+		}
+	}
+
+	return router
+}
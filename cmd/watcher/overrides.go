@@ -0,0 +1,316 @@
+// Copyright (2023 -- present) Shahruk Hossain <shahruk10@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//		 http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// ==============================================================================
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shahruk10/watcher/internal/watcher"
+	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+)
+
+// overrideFileName is the name of the optional config file that can be placed
+// inside any watched folder to override how files in it are validated.
+const overrideFileName = ".watcher.yaml"
+
+// Names of validators that can be turned off in a folder override file.
+const (
+	validatorFolderName = "folder_name"
+	validatorFileName   = "file_name"
+	validatorFrameType  = "frame_type"
+	validatorFrameSize  = "frame_size"
+	validatorImage      = "image"
+	validatorColor      = "color"
+	validatorIntegrity  = "integrity"
+	validatorMetadata   = "metadata"
+	validatorSet        = "set"
+	validatorPDF        = "pdf"
+)
+
+var knownValidators = []string{
+	validatorFolderName,
+	validatorFileName,
+	validatorFrameType,
+	validatorFrameSize,
+	validatorImage,
+	validatorColor,
+	validatorIntegrity,
+	validatorMetadata,
+	validatorSet,
+	validatorPDF,
+}
+
+// FolderOverride holds the settings read from a folder's override file. The
+// settings apply to the folder and every folder below it, with overrides
+// further down the tree taking precedence.
+type FolderOverride struct {
+	// Attributes declares the folder's attributes explicitly instead of parsing
+	// them from the folder name.
+	Attributes map[string]string `yaml:"attributes"`
+	// Disable lists validators which should not be applied.
+	Disable []string `yaml:"disable"`
+	// FileNamePatterns are tried before the patterns in the main config.
+	FileNamePatterns []string `yaml:"file_name_patterns"`
+}
+
+func (o *FolderOverride) Validate() error {
+	for name := range o.Attributes {
+		if name != attrFrameSize && name != attrFrameType {
+			return fmt.Errorf("unknown attribute %q", name)
+		}
+	}
+
+	if len(o.Attributes) > 0 && o.Attributes[attrFrameSize] == "" {
+		return fmt.Errorf("attributes must specify %q", attrFrameSize)
+	}
+
+	for _, name := range o.Disable {
+		if !isKnownValidator(name) {
+			return fmt.Errorf("unknown validator %q", name)
+		}
+	}
+
+	for _, p := range o.FileNamePatterns {
+		if _, err := regexp.Compile(p); err != nil {
+			return fmt.Errorf("file name pattern %q not valid regular expression, %w", p, err)
+		}
+	}
+
+	return nil
+}
+
+// Disabled returns true if the named validator has been turned off.
+func (o *FolderOverride) Disabled(name string) bool {
+	for _, d := range o.Disable {
+		if d == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// FolderAttributes returns the explicitly declared folder attributes, if any.
+func (o *FolderOverride) FolderAttributes() (map[string]string, bool) {
+	if o.Attributes[attrFrameSize] == "" {
+		return nil, false
+	}
+
+	attr := map[string]string{attrFrameType: ""}
+	for name, value := range o.Attributes {
+		attr[name] = strings.ToLower(strings.TrimSpace(value))
+	}
+
+	return attr, true
+}
+
+// inherit returns a new override with the settings of o applied on top of the
+// settings of the parent.
+func (o *FolderOverride) inherit(parent *FolderOverride) *FolderOverride {
+	merged := &FolderOverride{
+		Attributes:       parent.Attributes,
+		Disable:          append(append([]string{}, parent.Disable...), o.Disable...),
+		FileNamePatterns: append(append([]string{}, o.FileNamePatterns...), parent.FileNamePatterns...),
+	}
+
+	if len(o.Attributes) > 0 {
+		merged.Attributes = o.Attributes
+	}
+
+	return merged
+}
+
+func isKnownValidator(name string) bool {
+	for _, v := range knownValidators {
+		if v == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Overrides loads and caches folder override files. Folder overrides are
+// inherited from parent folders up to (and including) the top level folders
+// that the watch list was built from.
+type Overrides struct {
+	logger *logrus.Logger
+	roots  map[string]bool
+
+	mu    sync.Mutex
+	cache map[string]*FolderOverride
+	// stamps holds the modification time and size of the override files of
+	// the top level folders when they were loaded.
+	stamps map[string]overrideStamp
+}
+
+type overrideStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+func NewOverrides(logger *logrus.Logger, cfg watcher.Config) *Overrides {
+	roots := make(map[string]bool)
+	for _, folder := range cfg.IncludeFolders {
+		if abs, err := filepath.Abs(globRoot(folder)); err == nil {
+			roots[abs] = true
+		}
+	}
+
+	return &Overrides{logger: logger, roots: roots, cache: make(map[string]*FolderOverride), stamps: make(map[string]overrideStamp)}
+}
+
+// globRoot returns the folder an include pattern is rooted at: the path up to
+// the first element with a wildcard, or the folder itself if it has none.
+func globRoot(folder string) string {
+	root := filepath.Clean(folder)
+	for strings.ContainsAny(root, "*?[") {
+		parent := filepath.Dir(root)
+		if parent == root {
+			break
+		}
+
+		root = parent
+	}
+
+	return root
+}
+
+// Resolve returns the effective override settings for files in the given
+// folder. It never returns nil; folders without any overrides get empty
+// settings. Override files are looked for up to the watched root the folder
+// is in; for folders outside the watched roots, only in the folder itself.
+func (o *Overrides) Resolve(folderPath string) *FolderOverride {
+	dir, err := filepath.Abs(folderPath)
+	if err != nil {
+		return &FolderOverride{}
+	}
+
+	// Collect folders from the given one up to its root, then merge settings
+	// top down so that deeper folders take precedence.
+	chain := []string{dir}
+	for !o.roots[dir] {
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			chain = chain[:1]
+			break
+		}
+
+		dir = parent
+		chain = append(chain, dir)
+	}
+
+	resolved := &FolderOverride{}
+	for i := len(chain) - 1; i >= 0; i-- {
+		resolved = o.load(chain[i]).inherit(resolved)
+	}
+
+	return resolved
+}
+
+// Invalidate drops cached settings for the given folder so that they are read
+// again on next use.
+func (o *Overrides) Invalidate(folderPath string) {
+	dir, err := filepath.Abs(folderPath)
+	if err != nil {
+		return
+	}
+
+	o.mu.Lock()
+	delete(o.cache, dir)
+	o.mu.Unlock()
+}
+
+func (o *Overrides) load(dir string) *FolderOverride {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	// The top level folders are usually not watched themselves, so changes to
+	// their override files are noticed by their modification time instead.
+	if o.roots[dir] {
+		var stamp overrideStamp
+		if info, err := os.Stat(filepath.Join(dir, overrideFileName)); err == nil {
+			stamp = overrideStamp{modTime: info.ModTime(), size: info.Size()}
+		}
+
+		if prev, ok := o.stamps[dir]; !ok || !prev.modTime.Equal(stamp.modTime) || prev.size != stamp.size {
+			delete(o.cache, dir)
+			o.stamps[dir] = stamp
+		}
+	}
+
+	if override, ok := o.cache[dir]; ok {
+		return override
+	}
+
+	override, err := readFolderOverride(filepath.Join(dir, overrideFileName))
+	if err != nil {
+		o.logger.Errorf("ignoring folder overrides in %q: %v", dir, err)
+		override = &FolderOverride{}
+	}
+
+	o.cache[dir] = override
+
+	return override
+}
+
+func readFolderOverride(path string) (*FolderOverride, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &FolderOverride{}, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("read %s: %w", overrideFileName, err)
+	}
+
+	var override FolderOverride
+	if err := yaml.Unmarshal(data, &override); err != nil {
+		return nil, fmt.Errorf("load %s: %w", overrideFileName, err)
+	}
+
+	if err := override.Validate(); err != nil {
+		return nil, fmt.Errorf("validate %s: %w", overrideFileName, err)
+	}
+
+	return &override, nil
+}
+
+func isOverrideFile(path string) bool {
+	return filepath.Base(path) == overrideFileName
+}
+
+// ReloadOverrides returns a callback which drops cached folder overrides
+// whenever an override file in a watched folder changes.
+func ReloadOverrides(overrides *Overrides) watcher.Callback {
+	return func(ctx context.Context, logger *logrus.Logger, e watcher.Event) error {
+		if !isOverrideFile(e.Name) {
+			return nil
+		}
+
+		logger.Infof("reloading folder overrides from %q", e.Name)
+		overrides.Invalidate(filepath.Dir(e.Name))
+
+		return nil
+	}
+}
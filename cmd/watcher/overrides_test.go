@@ -0,0 +1,157 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/shahruk10/watcher/internal/watcher"
+	"github.com/sirupsen/logrus"
+)
+
+func TestOverridesResolve(t *testing.T) {
+	root := t.TempDir()
+	rush := filepath.Join(root, "rush")
+	rushSmall := filepath.Join(rush, "small")
+
+	if err := os.MkdirAll(rushSmall, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	writeFile := func(path, data string) {
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	writeFile(filepath.Join(root, overrideFileName), "disable: [frame_type]\n")
+	writeFile(filepath.Join(rush, overrideFileName), "attributes:\n  frame_size: 12X12\nfile_name_patterns: ['^rush$']\n")
+	writeFile(filepath.Join(rushSmall, overrideFileName), "attributes:\n  frame_size: 8x10\n")
+
+	overrides := NewOverrides(logrus.New(), watcher.Config{IncludeFolders: []string{filepath.Join(root, "*")}})
+
+	got := overrides.Resolve(rushSmall)
+	if !got.Disabled(validatorFrameType) || got.Disabled(validatorFrameSize) {
+		t.Errorf("got unexpected disabled validators for %q, got=%v", rushSmall, got.Disable)
+	}
+
+	if attr, ok := got.FolderAttributes(); !ok || attr[attrFrameSize] != "8x10" || attr[attrFrameType] != "" {
+		t.Errorf("got unexpected folder attributes for %q, want=8x10, got=%v", rushSmall, attr)
+	}
+
+	if len(got.FileNamePatterns) != 1 || got.FileNamePatterns[0] != "^rush$" {
+		t.Errorf("got unexpected file name patterns for %q, got=%v", rushSmall, got.FileNamePatterns)
+	}
+
+	if attr, ok := overrides.Resolve(rush).FolderAttributes(); !ok || attr[attrFrameSize] != "12x12" {
+		t.Errorf("got unexpected folder attributes for %q, want=12x12, got=%v", rush, attr)
+	}
+
+	// Changes should only be visible after the cached settings are invalidated.
+	writeFile(filepath.Join(rushSmall, overrideFileName), "disable: [frame_size]\n")
+
+	if overrides.Resolve(rushSmall).Disabled(validatorFrameSize) {
+		t.Errorf("got stale settings applied before invalidation for %q", rushSmall)
+	}
+
+	overrides.Invalidate(rushSmall)
+
+	got = overrides.Resolve(rushSmall)
+	if !got.Disabled(validatorFrameSize) {
+		t.Errorf("got reloaded settings not applied for %q, got=%v", rushSmall, got.Disable)
+	}
+
+	if attr, _ := got.FolderAttributes(); attr[attrFrameSize] != "12x12" {
+		t.Errorf("got unexpected inherited folder attributes for %q, want=12x12, got=%v", rushSmall, attr)
+	}
+}
+
+func TestOverridesReloadRoot(t *testing.T) {
+	root := t.TempDir()
+	folder := filepath.Join(root, "8x10 framed")
+
+	if err := os.Mkdir(folder, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	overrides := NewOverrides(logrus.New(), watcher.Config{IncludeFolders: []string{filepath.Join(root, "*")}})
+
+	if overrides.Resolve(folder).Disabled(validatorFrameType) {
+		t.Fatalf("got validator disabled without an override file")
+	}
+
+	// The top level folder isn't watched, so edits to its override file are
+	// picked up without being invalidated.
+	path := filepath.Join(root, overrideFileName)
+	for i, tc := range []struct {
+		data string
+		want string
+	}{
+		{data: "disable: [frame_type]\n", want: validatorFrameType},
+		{data: "disable: [frame_size]\n", want: validatorFrameSize},
+	} {
+		if err := os.WriteFile(path, []byte(tc.data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		// Keep edits apart on file systems with coarse modification times.
+		modTime := time.Now().Add(time.Duration(i) * time.Minute)
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+
+		if got := overrides.Resolve(folder); !got.Disabled(tc.want) || len(got.Disable) != 1 {
+			t.Errorf("got unexpected disabled validators after editing %q, want=%v, got=%v", path, tc.want, got.Disable)
+		}
+	}
+
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := overrides.Resolve(folder); len(got.Disable) != 0 {
+		t.Errorf("got disabled validators after removing %q, got=%v", path, got.Disable)
+	}
+}
+
+func TestOverridesNestedGlob(t *testing.T) {
+	parent := t.TempDir()
+	root := filepath.Join(parent, "hot")
+	folder := filepath.Join(root, "rush", "8x10 framed")
+	other := filepath.Join(parent, "cold", "8x10 framed")
+
+	for _, dir := range []string{folder, other} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Override files above the watched root aren't part of the watched tree.
+	for path, data := range map[string]string{
+		filepath.Join(parent, overrideFileName): "disable: [frame_type]\n",
+		filepath.Join(root, overrideFileName):   "disable: [frame_size]\n",
+		filepath.Join(other, overrideFileName):  "disable: [color]\n",
+	} {
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	overrides := NewOverrides(logrus.New(), watcher.Config{IncludeFolders: []string{filepath.Join(root, "*", "*")}})
+
+	got := overrides.Resolve(folder)
+	if !got.Disabled(validatorFrameSize) || got.Disabled(validatorFrameType) {
+		t.Errorf("got unexpected disabled validators for %q, want=[frame_size], got=%v", folder, got.Disable)
+	}
+
+	// Folders outside the watched roots only get their own overrides.
+	got = overrides.Resolve(other)
+	if !got.Disabled(validatorColor) || got.Disabled(validatorFrameType) {
+		t.Errorf("got unexpected disabled validators for %q, want=[color], got=%v", other, got.Disable)
+	}
+
+	if got := globRoot(filepath.Join(root, "8x10")); got != filepath.Join(root, "8x10") {
+		t.Errorf("got unexpected root for folder without wildcards, want=%q, got=%q", filepath.Join(root, "8x10"), got)
+	}
+}
@@ -1,18 +1,11 @@
 package main
 
 import (
-	"fmt"
 	"testing"
 
 	"github.com/sirupsen/logrus"
 )
 
-func init() {
-	showAlert = func(logger *logrus.Logger, title, msg string) error {
-		return fmt.Errorf("%s: %s", title, msg)
-	}
-}
-
 func TestFolderAttributes(t *testing.T) {
 	testCases := []struct {
 		FolderName    string
@@ -0,0 +1,136 @@
+// Copyright (2023 -- present) Shahruk Hossain <shahruk10@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//		 http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// ==============================================================================
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"path/filepath"
+	"strings"
+
+	"github.com/shahruk10/watcher/internal/notify"
+	"github.com/shahruk10/watcher/internal/pdfinfo"
+	"github.com/sirupsen/logrus"
+)
+
+// PDFConfig controls inspecting PDF documents to check that their pages fit
+// the frame size given in their file and folder names.
+type PDFConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// AllPages checks every page of a document rather than only the first.
+	AllPages bool `yaml:"all_pages"`
+}
+
+func isPDF(filePath string) bool {
+	return strings.EqualFold(filepath.Ext(filePath), ".pdf")
+}
+
+// checkPDF returns true with the verdict set on the result if the document
+// can't be read, or its pages don't fit the frame size. Pages are measured by
+// their trim box, or media box if they have none, and compared with the same
+// tolerance and orientation rules as images.
+func checkPDF(logger *logrus.Logger, cfg Config, result *Result) bool {
+	filePath := result.Path
+
+	info, err := pdfinfo.ReadFile(filePath, cfg.PDF.AllPages)
+	switch {
+	case errors.Is(err, pdfinfo.ErrEncrypted):
+		result.Verdict = VerdictEncryptedPDF
+		result.Title = "ENCRYPTED PDF"
+		result.Fields = []notify.Field{
+			{Label: "📁 file", Value: filepath.Base(filePath)},
+			{Label: "❌ error", Value: "document is encrypted, its pages can't be checked"},
+		}
+
+		return true
+
+	case errors.Is(err, pdfinfo.ErrMalformed), errors.Is(err, pdfinfo.ErrUnsupported):
+		result.Verdict = VerdictMalformedPDF
+		result.Title = "MALFORMED PDF"
+		result.Fields = []notify.Field{
+			{Label: "📁 file", Value: filepath.Base(filePath)},
+			{Label: "❌ error", Value: err.Error()},
+		}
+
+		return true
+
+	case err != nil:
+		logger.Debugf("not inspecting pdf %q: %v", filePath, err)
+		return false
+	}
+
+	result.PDF = info
+
+	// The frame size in the folder name is checked as well, in case the file
+	// name is wrong and frame size validation is turned off.
+	frameSizes := []string{result.FileAttr[attrFrameSize]}
+	if dirSize := result.DirAttr[attrFrameSize]; dirSize != "" && dirSize != frameSizes[0] {
+		frameSizes = append(frameSizes, dirSize)
+	}
+
+	tolerance := cfg.Image.AspectToleranceOrDefault()
+
+	for _, frameSize := range frameSizes {
+		frameW, frameH, ok := parseFrameSize(frameSize)
+		if !ok {
+			logger.Debugf("not inspecting pdf %q: can't parse frame size %q", filePath, frameSize)
+			continue
+		}
+
+		for i, page := range info.Pages {
+			pageW, pageH := page.Size()
+
+			fields := []notify.Field{
+				{Label: "📁 file", Value: filepath.Base(filePath)},
+				{Label: "📄 page", Value: fmt.Sprintf("%d of %d, %.2fx%.2f in", i+1, info.PageCount, pageW, pageH)},
+				{Label: "📐 frame", Value: frameSize},
+			}
+
+			// Orientation is checked separately, so sizes are compared as long
+			// and short sides.
+			longOff := math.Abs(math.Max(pageW, pageH)-math.Max(frameW, frameH)) / math.Max(frameW, frameH)
+			shortOff := math.Abs(math.Min(pageW, pageH)-math.Min(frameW, frameH)) / math.Min(frameW, frameH)
+
+			switch {
+			case longOff > tolerance || shortOff > tolerance:
+				result.Verdict = VerdictWrongPageSize
+				result.Title = "WRONG PAGE SIZE"
+				fields = append(fields, notify.Field{
+					Label: "❌ page size",
+					Value: fmt.Sprintf("%.2fx%.2f in, frame is %s", pageW, pageH, frameSize),
+				})
+
+			case frameW != frameH && pageW != pageH && (pageW > pageH) != (frameW > frameH):
+				result.Verdict = VerdictWrongOrientation
+				result.Title = "WRONG ORIENTATION"
+				fields = append(fields, notify.Field{
+					Label: "❌ orientation",
+					Value: fmt.Sprintf("%s, frame is %s", orientation(pageW > pageH), orientation(frameW > frameH)),
+				})
+
+			default:
+				continue
+			}
+
+			result.Fields = fields
+
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+// writePDF writes a single page document with the given media box and
+// trailer entries.
+func writePDF(t *testing.T, path, mediaBox, trailer string) {
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [" + mediaBox + "] >>",
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n")
+
+	offsets := make([]int, len(objects))
+	for i, o := range objects {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, o)
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f\r\n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n\r\n", offset)
+	}
+
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R %s >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, trailer, xref)
+
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCheckPDF(t *testing.T) {
+	dir := t.TempDir()
+	logger := logrus.New()
+	cfg := Config{PDF: PDFConfig{Enabled: true}}
+
+	tests := []struct {
+		name      string
+		mediaBox  string
+		trailer   string
+		frameSize string
+		dirSize   string
+		want      Verdict
+	}{
+		{name: "fits", mediaBox: "0 0 576 720", frameSize: "8x10", dirSize: "8x10"},
+		{name: "within tolerance", mediaBox: "0 0 577 721", frameSize: "8x10"},
+		{name: "wrong size", mediaBox: "0 0 576 720", frameSize: "11x14", dirSize: "11x14", want: VerdictWrongPageSize},
+		{name: "wrong folder size", mediaBox: "0 0 576 720", frameSize: "8x10", dirSize: "11x14", want: VerdictWrongPageSize},
+		{name: "landscape", mediaBox: "0 0 720 576", frameSize: "8x10", want: VerdictWrongOrientation},
+		{name: "encrypted", mediaBox: "0 0 576 720", trailer: "/Encrypt << /Filter /Standard >>", frameSize: "8x10", want: VerdictEncryptedPDF},
+	}
+
+	for i, tc := range tests {
+		path := filepath.Join(dir, fmt.Sprintf("%d.pdf", i))
+		writePDF(t, path, tc.mediaBox, tc.trailer)
+
+		result := &Result{
+			Path:     path,
+			FileAttr: map[string]string{attrFrameSize: tc.frameSize},
+			DirAttr:  map[string]string{attrFrameSize: tc.dirSize},
+		}
+
+		if found := checkPDF(logger, cfg, result); found != (tc.want != "") || result.Verdict != tc.want {
+			t.Errorf("%s: got unexpected verdict, want=%q, got=%q %v", tc.name, tc.want, result.Verdict, result.Fields)
+		}
+	}
+
+	truncated := filepath.Join(dir, "truncated.pdf")
+	if err := os.WriteFile(truncated, []byte("%PDF-1.4\n1 0 obj\n<< /Type /Catalog"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if result := (&Result{Path: truncated}); !checkPDF(logger, cfg, result) || result.Verdict != VerdictMalformedPDF {
+		t.Errorf("got unexpected verdict for truncated pdf, want=%q, got=%q", VerdictMalformedPDF, result.Verdict)
+	}
+}
@@ -0,0 +1,204 @@
+// Copyright (2023 -- present) Shahruk Hossain <shahruk10@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//		 http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// ==============================================================================
+
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/peterbourgon/ff/v3/ffcli"
+	"github.com/shahruk10/watcher/internal/fileutil"
+	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+)
+
+// sidecarSuffix is appended to the name of a quarantined file to get the name
+// of the file explaining why it was quarantined.
+const sidecarSuffix = ".quarantine.yaml"
+
+// Sidecar is written next to every quarantined file.
+type Sidecar struct {
+	OriginalPath  string    `yaml:"original_path"`
+	Reason        string    `yaml:"reason"`
+	Details       string    `yaml:"details"`
+	QuarantinedAt time.Time `yaml:"quarantined_at"`
+}
+
+// quarantinePath returns the path under the quarantine folder which mirrors
+// the given file path.
+func quarantinePath(cfg QuarantineConfig, filePath string) string {
+	abs := absPath(filePath)
+	rel := strings.TrimPrefix(abs[len(filepath.VolumeName(abs)):], string(filepath.Separator))
+
+	return filepath.Join(cfg.Dir, rel)
+}
+
+// quarantineFile moves the file into the quarantine folder and writes a
+// sidecar explaining why, returning the new path of the file.
+func quarantineFile(logger *logrus.Logger, cfg QuarantineConfig, filePath, reason, details string) (string, error) {
+	dst := quarantinePath(cfg, filePath)
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return "", fmt.Errorf("create quarantine folder: %w", err)
+	}
+
+	if err := fileutil.Move(filePath, dst); err != nil {
+		return "", fmt.Errorf("quarantine file: %w", err)
+	}
+
+	sidecar := Sidecar{
+		OriginalPath:  absPath(filePath),
+		Reason:        reason,
+		Details:       details,
+		QuarantinedAt: time.Now(),
+	}
+
+	if err := writeSidecar(dst+sidecarSuffix, sidecar); err != nil {
+		logger.Errorf("quarantined %q but failed to write sidecar: %v", filePath, err)
+	}
+
+	logger.Infof("quarantined %q to %q", filePath, dst)
+
+	return dst, nil
+}
+
+func writeSidecar(path string, sidecar Sidecar) error {
+	data, err := yaml.Marshal(sidecar)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, data, 0o644)
+}
+
+// findSidecar returns the sidecar of a quarantined file. If the file has been
+// renamed inside the quarantine folder, the sidecar whose file no longer exists
+// is used, as long as there is only one such sidecar in the folder.
+func findSidecar(filePath string) (string, Sidecar, error) {
+	path := filePath + sidecarSuffix
+
+	if _, err := os.Stat(path); errors.Is(err, fs.ErrNotExist) {
+		candidates, err := filepath.Glob(filepath.Join(filepath.Dir(filePath), "*"+sidecarSuffix))
+		if err != nil {
+			return "", Sidecar{}, err
+		}
+
+		orphans := make([]string, 0, 1)
+		for _, c := range candidates {
+			if _, err := os.Stat(strings.TrimSuffix(c, sidecarSuffix)); errors.Is(err, fs.ErrNotExist) {
+				orphans = append(orphans, c)
+			}
+		}
+
+		if len(orphans) != 1 {
+			return "", Sidecar{}, fmt.Errorf("no quarantine sidecar found for %q", filePath)
+		}
+
+		path = orphans[0]
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", Sidecar{}, fmt.Errorf("read quarantine sidecar: %w", err)
+	}
+
+	var sidecar Sidecar
+	if err := yaml.Unmarshal(data, &sidecar); err != nil {
+		return "", Sidecar{}, fmt.Errorf("load quarantine sidecar: %w", err)
+	}
+
+	return path, sidecar, nil
+}
+
+func newReleaseCmd(logger *logrus.Logger, cfgPath *string, mode *runMode) *ffcli.Command {
+	var (
+		releaseFlagSet = flag.NewFlagSet("watcher release", flag.ExitOnError)
+		renameFlag     = releaseFlagSet.String("rename", "", "New name to give the file when releasing it.")
+	)
+
+	return &ffcli.Command{
+		Name:       "release",
+		ShortUsage: "watcher [flags] release [-rename <name>] <file>",
+		ShortHelp:  "Re-validate a quarantined file and move it back to where it came from.",
+		FlagSet:    releaseFlagSet,
+		Exec: func(ctx context.Context, args []string) error {
+			mode.headless = true
+
+			if len(args) != 1 {
+				return fmt.Errorf("release: expected exactly one file, got %d", len(args))
+			}
+
+			cfg, err := loadConfig(logger, *cfgPath)
+			if err != nil {
+				return err
+			}
+
+			return releaseFile(logger, cfg, args[0], *renameFlag)
+		},
+	}
+}
+
+// releaseFile moves a quarantined file back to the folder it was quarantined
+// from, provided its (possibly new) name is now valid.
+func releaseFile(logger *logrus.Logger, cfg Config, filePath, newName string) error {
+	sidecarPath, sidecar, err := findSidecar(filePath)
+	if err != nil {
+		return err
+	}
+
+	name := filepath.Base(filePath)
+	if newName != "" {
+		name = newName
+	}
+
+	originalDir := filepath.Dir(sidecar.OriginalPath)
+	dst := filepath.Join(originalDir, name)
+
+	overrides := NewOverrides(logger, cfg.Watcher)
+	override := overrides.Resolve(originalDir)
+	fileNamePatterns := append(append([]string{}, override.FileNamePatterns...), cfg.Metadata.FileNamePatterns...)
+
+	if !override.Disabled(validatorFileName) {
+		fileAttr, err := getFileAttributes(logger, dst, fileNamePatterns)
+		if err != nil {
+			return fmt.Errorf("release %q: %w", filePath, err)
+		}
+
+		frameType := fileAttr[attrFrameType]
+		if _, ok := cfg.Metadata.FrameType2Name[frameType]; !ok && !override.Disabled(validatorFrameType) {
+			return fmt.Errorf("release %q: unknown frame type %q", filePath, frameType)
+		}
+	}
+
+	if err := fileutil.Move(filePath, dst); err != nil {
+		return fmt.Errorf("release %q: %w", filePath, err)
+	}
+
+	if err := os.Remove(sidecarPath); err != nil {
+		logger.Errorf("released %q but failed to remove sidecar: %v", filePath, err)
+	}
+
+	logger.Infof("released %q to %q", filePath, dst)
+
+	return nil
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/shahruk10/watcher/internal/watcher"
+	"github.com/sirupsen/logrus"
+)
+
+func TestQuarantineAndRelease(t *testing.T) {
+	root := t.TempDir()
+	logger := logrus.New()
+
+	folder := filepath.Join(root, "hot", "8x10 framed")
+	if err := os.MkdirAll(folder, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := Config{
+		Watcher: watcher.Config{IncludeFolders: []string{filepath.Join(root, "hot", "*")}},
+		Metadata: Metadata{
+			FrameType2Name:     map[string][]string{"fr": {"framed"}},
+			FolderNamePatterns: []string{`^(?P<frame_size>\d+x\d+) (?P<frame_type>framed)$`},
+			FileNamePatterns:   []string{`^([^_]+)_(?P<frame_type>[^_]+)_(?P<frame_size>\d+x\d+).*$`},
+		},
+		Quarantine: QuarantineConfig{Enabled: true, Dir: filepath.Join(root, "quarantine")},
+	}
+
+	quarantine := func(name string) string {
+		path := filepath.Join(folder, name)
+		if err := os.WriteFile(path, []byte(name), 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		dst, err := quarantineFile(logger, cfg.Quarantine, path, "invalid file name", "no pattern matched")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return dst
+	}
+
+	// Quarantined files mirror their original path and get a sidecar.
+	dst := quarantine("scan.jpg")
+	if want := quarantinePath(cfg.Quarantine, filepath.Join(folder, "scan.jpg")); dst != want {
+		t.Errorf("got unexpected quarantine path, want=%q, got=%q", want, dst)
+	}
+
+	if _, err := os.Stat(filepath.Join(folder, "scan.jpg")); !os.IsNotExist(err) {
+		t.Errorf("got quarantined file left in place: %v", err)
+	}
+
+	sidecarPath, sidecar, err := findSidecar(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if sidecarPath != dst+sidecarSuffix || sidecar.OriginalPath != absPath(filepath.Join(folder, "scan.jpg")) || sidecar.Reason != "invalid file name" {
+		t.Errorf("got unexpected sidecar %q, got=%+v", sidecarPath, sidecar)
+	}
+
+	// Releasing it under a name which is still invalid leaves it quarantined.
+	if err := releaseFile(logger, cfg, dst, ""); err == nil {
+		t.Errorf("got unexpected error releasing an invalid file, want=error, got=%v", err)
+	}
+
+	if err := releaseFile(logger, cfg, dst, "1_xx_8x10.jpg"); err == nil {
+		t.Errorf("got unexpected error releasing a file of unknown frame type, want=error, got=%v", err)
+	}
+
+	if _, err := os.Stat(dst); err != nil {
+		t.Errorf("got file not left in quarantine: %v", err)
+	}
+
+	// Releasing it with a valid new name moves it back and removes the
+	// sidecar.
+	if err := releaseFile(logger, cfg, dst, "1_fr_8x10.jpg"); err != nil {
+		t.Fatal(err)
+	}
+
+	if data, err := os.ReadFile(filepath.Join(folder, "1_fr_8x10.jpg")); err != nil || string(data) != "scan.jpg" {
+		t.Errorf("got unexpected released file, want=%q, got=%q (%v)", "scan.jpg", data, err)
+	}
+
+	if _, err := os.Stat(dst + sidecarSuffix); !os.IsNotExist(err) {
+		t.Errorf("got sidecar not removed: %v", err)
+	}
+
+	// A file renamed in the quarantine folder is matched with the sidecar
+	// left without a file.
+	dst = quarantine("photo.jpg")
+	renamed := filepath.Join(filepath.Dir(dst), "2_fr_8x10.jpg")
+	if err := os.Rename(dst, renamed); err != nil {
+		t.Fatal(err)
+	}
+
+	if path, _, err := findSidecar(renamed); err != nil || path != dst+sidecarSuffix {
+		t.Errorf("got unexpected sidecar of renamed file, want=%q, got=%q (%v)", dst+sidecarSuffix, path, err)
+	}
+
+	if err := releaseFile(logger, cfg, renamed, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Stat(filepath.Join(folder, "2_fr_8x10.jpg")); err != nil {
+		t.Errorf("got renamed file not released: %v", err)
+	}
+
+	// Files without a sidecar, or with more than one orphaned sidecar to
+	// choose from, can't be released.
+	for _, name := range []string{"a.jpg", "b.jpg"} {
+		dst := quarantine(name)
+		if err := os.Remove(dst); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	stray := filepath.Join(filepath.Dir(dst), "3_fr_8x10.jpg")
+	if err := os.WriteFile(stray, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err := findSidecar(stray); err == nil {
+		t.Errorf("got unexpected error finding one of two orphaned sidecars, want=error, got=%v", err)
+	}
+
+	if err := os.Remove(quarantinePath(cfg.Quarantine, filepath.Join(folder, "a.jpg")) + sidecarSuffix); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := releaseFile(logger, cfg, stray, ""); err != nil {
+		t.Errorf("got unexpected error releasing with the only orphaned sidecar, want=nil, got=%v", err)
+	}
+
+	if err := os.Remove(quarantinePath(cfg.Quarantine, filepath.Join(folder, "b.jpg")) + sidecarSuffix); !os.IsNotExist(err) {
+		t.Errorf("got orphaned sidecar not removed on release: %v", err)
+	}
+
+	missing := filepath.Join(filepath.Dir(dst), "4_fr_8x10.jpg")
+	if err := os.WriteFile(missing, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := releaseFile(logger, cfg, missing, ""); err == nil {
+		t.Errorf("got unexpected error releasing a file without a sidecar, want=error, got=%v", err)
+	}
+}
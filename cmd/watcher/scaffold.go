@@ -0,0 +1,320 @@
+// Copyright (2023 -- present) Shahruk Hossain <shahruk10@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//		 http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// ==============================================================================
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+
+	"github.com/peterbourgon/ff/v3/ffcli"
+	"github.com/sirupsen/logrus"
+)
+
+const defaultScaffoldTemplate = "{{.FrameSize}} {{.FrameType}}"
+
+// ScaffoldConfig controls the folders created by the scaffold subcommand.
+type ScaffoldConfig struct {
+	// Sizes is the catalogue of frame sizes a folder is created for, for each
+	// frame type in the frame type mapping.
+	Sizes []string `yaml:"sizes"`
+	// Templates name the folders, given .FrameSize and .FrameType. They are
+	// tried in order, and the first name the folder name patterns parse back
+	// is used. Spaces around the name are trimmed, for empty frame types.
+	Templates []string `yaml:"templates"`
+}
+
+func (cfg *ScaffoldConfig) Validate() error {
+	for _, size := range cfg.Sizes {
+		if !sizeRegex.MatchString(size) {
+			return fmt.Errorf("validate scaffold: size %q not in the form <width>x<height>", size)
+		}
+	}
+
+	for _, text := range cfg.TemplatesOrDefault() {
+		if _, err := parseTemplate(text); err != nil {
+			return fmt.Errorf("validate scaffold: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (cfg *ScaffoldConfig) TemplatesOrDefault() []string {
+	if len(cfg.Templates) == 0 {
+		return []string{defaultScaffoldTemplate}
+	}
+
+	return cfg.Templates
+}
+
+// scaffoldFolder is a folder expected under the scaffold root. Folders whose
+// names wouldn't be parsed back into their frame size and type have a reason
+// not to create them.
+type scaffoldFolder struct {
+	Name      string
+	FrameSize string
+	FrameType string
+	Exists    bool
+	Invalid   string
+}
+
+func newScaffoldCmd(logger *logrus.Logger, cfgPath *string, mode *runMode) *ffcli.Command {
+	var (
+		scaffoldFlagSet = flag.NewFlagSet("watcher scaffold", flag.ExitOnError)
+		applyFlag       = scaffoldFlagSet.Bool("apply", false, "Create the folders instead of only showing them.")
+		sizesFlag       = scaffoldFlagSet.String("sizes", "", "Comma separated frame sizes, instead of scaffold.sizes.")
+		extraFlag       = scaffoldFlagSet.Bool("extra", false, "Also report folders which aren't expected.")
+	)
+
+	return &ffcli.Command{
+		Name:       "scaffold",
+		ShortUsage: "watcher [flags] scaffold [-apply] [-sizes <size,...>] [-extra] <root>",
+		ShortHelp:  "Create the folder for each frame size and type under a root.",
+		LongHelp: "Names a folder for each of the configured frame sizes and each frame type in the frame type " +
+			"mapping, using scaffold.templates, and shows those missing under the root. Names which the folder " +
+			"name patterns wouldn't parse back are skipped. The folders are only created with -apply.",
+		FlagSet: scaffoldFlagSet,
+		Exec: func(ctx context.Context, args []string) error {
+			mode.headless = true
+
+			if len(args) != 1 {
+				return &configError{fmt.Errorf("scaffold: expected exactly one root folder, got %d", len(args))}
+			}
+
+			cfg, err := loadConfig(logger, *cfgPath)
+			if err != nil {
+				return err
+			}
+
+			if *sizesFlag != "" {
+				cfg.Scaffold.Sizes = splitSizes(*sizesFlag)
+				if err := cfg.Scaffold.Validate(); err != nil {
+					return &configError{err}
+				}
+			}
+
+			if len(cfg.Scaffold.Sizes) == 0 {
+				return &configError{fmt.Errorf("scaffold: no frame sizes given in scaffold.sizes or -sizes")}
+			}
+
+			root := args[0]
+
+			folders, err := scaffoldFolders(logger, cfg, root)
+			if err != nil {
+				return err
+			}
+
+			var extra []string
+			if *extraFlag {
+				if extra, err = extraFolders(root, folders); err != nil {
+					return err
+				}
+			}
+
+			if err := writeScaffoldPlan(os.Stdout, root, folders, extra); err != nil {
+				return err
+			}
+
+			if !*applyFlag {
+				return nil
+			}
+
+			created := 0
+			for _, f := range folders {
+				if f.Exists || f.Invalid != "" {
+					continue
+				}
+
+				if err := os.MkdirAll(filepath.Join(root, f.Name), 0o755); err != nil {
+					return fmt.Errorf("create folder: %w", err)
+				}
+
+				created++
+			}
+
+			logger.Infof("created %d folders under %q", created, root)
+
+			return nil
+		},
+	}
+}
+
+// scaffoldFolders returns the folders expected under root, one for each
+// configured frame size and each frame type in the frame type mapping.
+func scaffoldFolders(logger *logrus.Logger, cfg Config, root string) ([]scaffoldFolder, error) {
+	var templates []*template.Template
+	for _, text := range cfg.Scaffold.TemplatesOrDefault() {
+		tmpl, err := parseTemplate(text)
+		if err != nil {
+			return nil, &configError{err}
+		}
+
+		templates = append(templates, tmpl)
+	}
+
+	types := make(map[string]bool)
+	for _, names := range cfg.Metadata.FrameType2Name {
+		for _, name := range names {
+			types[name] = true
+		}
+	}
+
+	frameTypes := make([]string, 0, len(types))
+	for name := range types {
+		frameTypes = append(frameTypes, name)
+	}
+
+	sort.Strings(frameTypes)
+
+	var folders []scaffoldFolder
+
+	seen := make(map[string]bool)
+
+	for _, size := range cfg.Scaffold.Sizes {
+		for _, frameType := range frameTypes {
+			var f scaffoldFolder
+
+			for _, tmpl := range templates {
+				name, err := scaffoldName(tmpl, size, frameType)
+				if err != nil {
+					return nil, err
+				}
+
+				f = scaffoldFolder{Name: name, FrameSize: size, FrameType: frameType}
+
+				attr, err := getFolderAttributes(logger, filepath.Join(root, name), cfg.Metadata.FolderNamePatterns)
+				switch {
+				case err != nil:
+					f.Invalid = "doesn't match any folder name pattern"
+				case attr[attrFrameSize] != size:
+					f.Invalid = fmt.Sprintf("parsed as frame size %q", attr[attrFrameSize])
+				case attr[attrFrameType] != strings.ToLower(frameType):
+					f.Invalid = fmt.Sprintf("parsed as frame type %q", attr[attrFrameType])
+				}
+
+				if f.Invalid == "" {
+					break
+				}
+			}
+
+			if seen[f.Name] {
+				continue
+			}
+
+			seen[f.Name] = true
+
+			if info, err := os.Stat(filepath.Join(root, f.Name)); err == nil && info.IsDir() {
+				f.Exists = true
+			}
+
+			folders = append(folders, f)
+		}
+	}
+
+	return folders, nil
+}
+
+// splitSizes splits a comma separated list of frame sizes, ignoring spaces
+// around the sizes and empty entries.
+func splitSizes(list string) []string {
+	var sizes []string
+	for _, size := range strings.Split(list, ",") {
+		if size = strings.TrimSpace(size); size != "" {
+			sizes = append(sizes, size)
+		}
+	}
+
+	return sizes
+}
+
+// scaffoldName executes the template for the frame size and type. The name
+// must be a single folder directly under the root, so names which are empty or
+// contain a path separator or ".." are rejected.
+func scaffoldName(tmpl *template.Template, frameSize, frameType string) (string, error) {
+	var sb strings.Builder
+
+	data := struct{ FrameSize, FrameType string }{frameSize, frameType}
+	if err := tmpl.Execute(&sb, data); err != nil {
+		return "", &configError{fmt.Errorf("execute scaffold template: %w", err)}
+	}
+
+	name := strings.TrimSpace(sb.String())
+	if name == "" || name == "." || strings.Contains(name, "..") || strings.ContainsAny(name, "/"+string(filepath.Separator)) {
+		return "", &configError{fmt.Errorf("scaffold template gives folder name %q for size %q and type %q, want a single folder name", name, frameSize, frameType)}
+	}
+
+	return name, nil
+}
+
+// extraFolders returns the folders directly under root which aren't among the
+// expected folders.
+func extraFolders(root string, folders []scaffoldFolder) ([]string, error) {
+	expected := make(map[string]bool, len(folders))
+	for _, f := range folders {
+		expected[f.Name] = true
+	}
+
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return nil, fmt.Errorf("read folder %q: %w", root, err)
+	}
+
+	var extra []string
+	for _, entry := range entries {
+		if entry.IsDir() && !expected[entry.Name()] {
+			extra = append(extra, entry.Name())
+		}
+	}
+
+	return extra, nil
+}
+
+func writeScaffoldPlan(w io.Writer, root string, folders []scaffoldFolder, extra []string) error {
+	out := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(out, "ACTION\tFOLDER\tDETAILS")
+
+	existing := 0
+	for _, f := range folders {
+		switch {
+		case f.Invalid != "":
+			fmt.Fprintf(out, "skip\t%s\t(%s)\n", filepath.Join(root, f.Name), f.Invalid)
+		case f.Exists:
+			existing++
+		default:
+			fmt.Fprintf(out, "create\t%s\n", filepath.Join(root, f.Name))
+		}
+	}
+
+	for _, name := range extra {
+		fmt.Fprintf(out, "extra\t%s\t(not an expected folder)\n", filepath.Join(root, name))
+	}
+
+	if err := out.Flush(); err != nil {
+		return err
+	}
+
+	_, err := fmt.Fprintf(w, "%d of %d expected folders already exist\n", existing, len(folders))
+
+	return err
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestScaffoldFolders(t *testing.T) {
+	root := t.TempDir()
+	logger := logrus.New()
+
+	for _, name := range []string{"8x10 framed", "8x10 framd"} {
+		if err := os.Mkdir(filepath.Join(root, name), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cfg := Config{
+		Metadata: Metadata{
+			FrameType2Name: map[string][]string{"cn": {""}, "fr": {"framed"}, "fr_2pc": {"framed 2pc"}, "wd": {"wood"}},
+			FolderNamePatterns: []string{
+				`^(?P<frame_size>\d+x\d+)$`,
+				`^(?P<frame_size>\d+x\d+) (?P<frame_type>framed)$`,
+				`^(?P<frame_type>framed 2pc) (?P<frame_size>\d+x\d+)$`,
+			},
+		},
+		Scaffold: ScaffoldConfig{
+			Sizes:     []string{"8x10"},
+			Templates: []string{"{{.FrameSize}} {{.FrameType}}", "{{.FrameType}} {{.FrameSize}}"},
+		},
+	}
+
+	folders, err := scaffoldFolders(logger, cfg, root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []scaffoldFolder{
+		{Name: "8x10", FrameSize: "8x10"},
+		{Name: "8x10 framed", FrameSize: "8x10", FrameType: "framed", Exists: true},
+		{Name: "framed 2pc 8x10", FrameSize: "8x10", FrameType: "framed 2pc"},
+		{Name: "wood 8x10", FrameSize: "8x10", FrameType: "wood", Invalid: "doesn't match any folder name pattern"},
+	}
+	if !reflect.DeepEqual(folders, want) {
+		t.Errorf("got unexpected folders,\nwant=%+v\n got=%+v", want, folders)
+	}
+
+	extra, err := extraFolders(root, folders)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []string{"8x10 framd"}; !reflect.DeepEqual(extra, want) {
+		t.Errorf("got unexpected extra folders, want=%q, got=%q", want, extra)
+	}
+}
+
+func TestSplitSizes(t *testing.T) {
+	if got, want := splitSizes(" 8x10, 12x12 ,,16x20 "), []string{"8x10", "12x12", "16x20"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got unexpected sizes, want=%q, got=%q", want, got)
+	}
+}
+
+func TestScaffoldName(t *testing.T) {
+	tests := []struct {
+		template string
+		want     string
+		wantErr  bool
+	}{
+		{template: "{{.FrameSize}} {{.FrameType}}", want: "8x10 framed"},
+		{template: "{{.FrameType}}/{{.FrameSize}}", wantErr: true},
+		{template: "../{{.FrameSize}}", wantErr: true},
+		{template: "{{.FrameSize}}..{{.FrameType}}", wantErr: true},
+		{template: " ", wantErr: true},
+		{template: ".", wantErr: true},
+	}
+
+	for _, tc := range tests {
+		tmpl, err := parseTemplate(tc.template)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := scaffoldName(tmpl, "8x10", "framed")
+		if (err != nil) != tc.wantErr {
+			t.Errorf("%q: got unexpected error, want=%v, got=%v", tc.template, tc.wantErr, err)
+		}
+
+		if got != tc.want {
+			t.Errorf("%q: got unexpected name, want=%q, got=%q", tc.template, tc.want, got)
+		}
+	}
+}
@@ -0,0 +1,272 @@
+// Copyright (2023 -- present) Shahruk Hossain <shahruk10@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//		 http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// ==============================================================================
+
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/peterbourgon/ff/v3/ffcli"
+	"github.com/sirupsen/logrus"
+)
+
+// violationsFoundError is returned by commands which found violations, so that
+// the watcher exits with exitViolations without reporting an error.
+type violationsFoundError struct {
+	count int
+}
+
+func (e *violationsFoundError) Error() string {
+	return fmt.Sprintf("found %d violations", e.count)
+}
+
+func newScanCmd(logger *logrus.Logger, cfgPath *string, mode *runMode) *ffcli.Command {
+	var (
+		scanFlagSet = flag.NewFlagSet("watcher scan", flag.ExitOnError)
+		allFlag     = scanFlagSet.Bool("all", false, "Include files without violations in the report.")
+		formatFlag  = scanFlagSet.String("format", formatTable, "Output format: table, json or csv.")
+		outputFlag  = scanFlagSet.String("output", "", "File to write to instead of stdout.")
+	)
+
+	return &ffcli.Command{
+		Name:       "scan",
+		ShortUsage: "watcher [flags] scan [-all] [-format table|json|csv] [-output <file>] [<path>...]",
+		ShortHelp:  "Validate every file in the watched folders, or the given paths, once.",
+		LongHelp: "Validates the files in the watched folders, or in the given files and folders (and the folders " +
+			"below them), and prints a report. No actions are carried out and no dialogs are shown. Exits with " +
+			"status 3 if there are any violations.",
+		FlagSet: scanFlagSet,
+		Exec: func(ctx context.Context, args []string) error {
+			// Errors are only logged, as the scan is run from scripts.
+			mode.headless = true
+
+			if *formatFlag != formatTable && *formatFlag != formatJSON && *formatFlag != formatCSV {
+				return &configError{fmt.Errorf("unknown format %q", *formatFlag)}
+			}
+
+			cfg, err := loadConfig(logger, *cfgPath)
+			if err != nil {
+				return err
+			}
+
+			files, err := filesToScan(cfg, args)
+			if err != nil {
+				return err
+			}
+
+			results, failed := scan(ctx, logger, cfg, files)
+
+			out := io.Writer(os.Stdout)
+			if *outputFlag != "" {
+				f, err := os.Create(*outputFlag)
+				if err != nil {
+					return fmt.Errorf("create output file: %w", err)
+				}
+				defer f.Close()
+
+				out = f
+			}
+
+			violations := 0
+			report := make([]*Result, 0, len(results))
+			for _, r := range results {
+				if r.Verdict != VerdictCorrect {
+					violations++
+				}
+
+				if *allFlag || r.Verdict != VerdictCorrect {
+					report = append(report, r)
+				}
+			}
+
+			if err := writeScanReport(out, *formatFlag, report); err != nil {
+				return err
+			}
+
+			logger.Infof("scanned %d files, found %d violations", len(files), violations)
+
+			switch {
+			case violations > 0:
+				return &violationsFoundError{count: violations}
+			case failed > 0:
+				return fmt.Errorf("failed to check %d files", failed)
+			default:
+				return nil
+			}
+		},
+	}
+}
+
+// filesToScan returns the files directly in the watched folders, or, if paths
+// are given, the files among them and in the folders below them.
+func filesToScan(cfg Config, paths []string) ([]string, error) {
+	var files []string
+
+	if len(paths) == 0 {
+		folders, err := getFoldersToWatch(cfg.Watcher)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, folder := range folders {
+			entries, err := os.ReadDir(folder)
+			if err != nil {
+				return nil, fmt.Errorf("read folder %q: %w", folder, err)
+			}
+
+			for _, entry := range entries {
+				if entry.Type().IsRegular() {
+					files = append(files, filepath.Join(folder, entry.Name()))
+				}
+			}
+		}
+
+		return files, nil
+	}
+
+	skip := make(map[string]bool)
+	for _, dir := range append(append([]string{}, cfg.Watcher.ExcludeFolders...), cfg.Quarantine.Dir) {
+		if abs, err := filepath.Abs(dir); err == nil && dir != "" {
+			skip[abs] = true
+		}
+	}
+
+	for _, root := range paths {
+		err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+
+			if entry.IsDir() {
+				if abs, err := filepath.Abs(path); err == nil && skip[abs] {
+					return filepath.SkipDir
+				}
+
+				return nil
+			}
+
+			if entry.Type().IsRegular() {
+				files = append(files, path)
+			}
+
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("scan %q: %w", root, err)
+		}
+	}
+
+	return files, nil
+}
+
+// scan validates the files the same way as files that change in the watched
+// folders, without carrying out any actions. Multi-piece sets are checked
+// among the scanned files. It returns the results and the number of files
+// which couldn't be checked.
+func scan(ctx context.Context, logger *logrus.Logger, cfg Config, files []string) ([]*Result, int) {
+	overrides := NewOverrides(logger, cfg.Watcher)
+
+	results := make([]*Result, 0, len(files))
+	failed := 0
+
+	for _, path := range files {
+		if ctx.Err() != nil {
+			break
+		}
+
+		result, err := checkFile(logger, cfg, overrides, path)
+		if err != nil {
+			logger.Errorf("failed to check %q: %v", path, err)
+			failed++
+			continue
+		}
+
+		if result != nil {
+			result.Op = "SCAN"
+			results = append(results, result)
+		}
+	}
+
+	if cfg.Sets.Enabled {
+		var pieces []*Result
+		for _, r := range results {
+			if r.FileAttr != nil && !overrides.Resolve(filepath.Dir(r.Path)).Disabled(validatorSet) {
+				pieces = append(pieces, r)
+			}
+		}
+
+		results = append(results, checkSets(cfg.Metadata, pieces, time.Now())...)
+	}
+
+	sort.SliceStable(results, func(i, j int) bool { return results[i].Path < results[j].Path })
+
+	return results, failed
+}
+
+func writeScanReport(w io.Writer, format string, results []*Result) error {
+	details := func(r *Result) string {
+		return strings.ReplaceAll(r.Message(), "\n", "; ")
+	}
+
+	switch format {
+	case formatJSON:
+		docs := make([]webhookDocument, 0, len(results))
+		for _, r := range results {
+			docs = append(docs, newWebhookDocument(r))
+		}
+
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+
+		return enc.Encode(docs)
+
+	case formatCSV:
+		out := csv.NewWriter(w)
+		out.Write([]string{"path", "verdict", "title", "details"})
+
+		for _, r := range results {
+			out.Write([]string{r.Path, string(r.Verdict), r.Title, details(r)})
+		}
+
+		out.Flush()
+
+		return out.Error()
+
+	case formatTable:
+		out := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(out, "VERDICT\tPATH\tDETAILS")
+
+		for _, r := range results {
+			fmt.Fprintf(out, "%s\t%s\t%s\n", r.Verdict, r.Path, details(r))
+		}
+
+		return out.Flush()
+
+	default:
+		return fmt.Errorf("unknown format %q", format)
+	}
+}
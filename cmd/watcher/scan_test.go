@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/shahruk10/watcher/internal/watcher"
+	"github.com/sirupsen/logrus"
+)
+
+func TestScan(t *testing.T) {
+	root := t.TempDir()
+	logger := logrus.New()
+
+	folder := filepath.Join(root, "8x10 framed")
+	nested := filepath.Join(folder, "old")
+	for _, dir := range []string{nested, filepath.Join(root, "11x14 framed")} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, path := range []string{
+		filepath.Join(folder, "1_fr_8x10.jpg"),
+		filepath.Join(folder, "2_fr_11x14.jpg"),
+		filepath.Join(folder, "bad.jpg"),
+		filepath.Join(nested, "3_fr_8x10.jpg"),
+	} {
+		if err := os.WriteFile(path, nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cfg := Config{
+		Watcher: watcher.Config{IncludeFolders: []string{filepath.Join(root, "*")}},
+		Metadata: Metadata{
+			FrameType2Name:     map[string][]string{"fr": {"framed"}},
+			FolderNamePatterns: []string{`^(?P<frame_size>\d+x\d+) (?P<frame_type>framed)$`},
+			FileNamePatterns:   []string{`^([^_]+)_(?P<frame_type>[^_]+)_(?P<frame_size>\d+x\d+).*$`},
+		},
+	}
+
+	// The watched folders are scanned the way they are watched, without the
+	// folders below them.
+	files, err := filesToScan(cfg, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(files) != 3 {
+		t.Fatalf("got unexpected files in watched folders, want=3, got=%v", files)
+	}
+
+	// Paths given as arguments are walked.
+	files, err = filesToScan(cfg, []string{folder})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results, failed := scan(context.Background(), logger, cfg, files)
+	if failed != 0 || len(results) != 4 {
+		t.Fatalf("got unexpected results, want=4, got=%d, failed=%d", len(results), failed)
+	}
+
+	want := map[string]Verdict{
+		"1_fr_8x10.jpg":  VerdictCorrect,
+		"2_fr_11x14.jpg": VerdictWrongFolder,
+		"bad.jpg":        VerdictInvalidFileName,
+		"3_fr_8x10.jpg":  VerdictInvalidFolderName,
+	}
+
+	for _, r := range results {
+		if r.Verdict != want[filepath.Base(r.Path)] {
+			t.Errorf("%s: got unexpected verdict, want=%q, got=%q", r.Path, want[filepath.Base(r.Path)], r.Verdict)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := writeScanReport(&buf, formatCSV, results); err != nil {
+		t.Fatal(err)
+	}
+
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(rows) != 5 || rows[0][1] != "verdict" {
+		t.Errorf("got unexpected csv report, got=%q", rows)
+	}
+}
@@ -0,0 +1,381 @@
+// Copyright (2023 -- present) Shahruk Hossain <shahruk10@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//		 http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// ==============================================================================
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shahruk10/watcher/internal/notify"
+	"github.com/shahruk10/watcher/internal/watcher"
+	"github.com/sirupsen/logrus"
+)
+
+// SetsConfig controls checking that the pieces of multi-piece sets, e.g.
+// "fr_3pc", all arrive in the same folder.
+type SetsConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// Wait is how long after the first piece of a set arrives it is checked.
+	Wait time.Duration `yaml:"wait"`
+}
+
+func (cfg *SetsConfig) WaitOrDefault() time.Duration {
+	if cfg.Wait <= 0 {
+		return 10 * time.Minute
+	}
+
+	return cfg.Wait
+}
+
+// setRetention is how long a set is remembered after its last piece arrived,
+// so that late pieces are checked along with the rest of the set.
+const setRetention = 24 * time.Hour
+
+var (
+	// setSizeRegex matches the piece count of a frame type, e.g. "3pc".
+	setSizeRegex = regexp.MustCompile(`(\d+)\s*pc\b`)
+	// pieceIndexRegex matches a piece index at the end of a file name, e.g.
+	// "_2" or "-p2", if the file name patterns don't capture a "piece".
+	pieceIndexRegex = regexp.MustCompile(`[_\- ](?:p|pc|piece|part)?(\d+)$`)
+)
+
+// orderAndPiece returns the order ID, taken from the first unnamed capture
+// group of the file name pattern which matches, and the piece index of the
+// file.
+func orderAndPiece(fileName string, fileNamePatterns []string) (string, string) {
+	for _, pattern := range fileNamePatterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			continue
+		}
+
+		matches := re.FindStringSubmatch(fileName)
+		if matches == nil {
+			continue
+		}
+
+		var order, piece string
+		for i, name := range re.SubexpNames() {
+			switch {
+			case i == 0:
+			case name == "" && order == "":
+				order = strings.TrimSpace(matches[i])
+			case name == attrPiece:
+				piece = strings.TrimLeft(strings.TrimSpace(matches[i]), "0")
+			}
+		}
+
+		if piece == "" {
+			if m := pieceIndexRegex.FindStringSubmatch(fileName); m != nil {
+				piece = strings.TrimLeft(m[1], "0")
+			}
+		}
+
+		return order, piece
+	}
+
+	return "", ""
+}
+
+// setSize returns the number of pieces in sets of the frame type, from the
+// "Npc" suffix of the frame type or of the folder names it maps to.
+func setSize(metadata Metadata, frameType string) int {
+	for _, name := range append([]string{frameType}, metadata.FrameType2Name[frameType]...) {
+		if m := setSizeRegex.FindStringSubmatch(name); m != nil {
+			if n, err := strconv.Atoi(m[1]); err == nil {
+				return n
+			}
+		}
+	}
+
+	return 0
+}
+
+// setOf returns the set the file of the result is a piece of, and the number
+// of pieces in the set, if it belongs to one.
+func setOf(metadata Metadata, r *Result) (setKey, int, bool) {
+	order, frameType := r.FileAttr[attrOrderID], r.FileAttr[attrFrameType]
+	if order == "" {
+		return setKey{}, 0, false
+	}
+
+	size := setSize(metadata, frameType)
+	if size < 2 {
+		return setKey{}, 0, false
+	}
+
+	return setKey{order: order, frameType: frameType, frameSize: r.FileAttr[attrFrameSize]}, size, true
+}
+
+type setKey struct {
+	order     string
+	frameType string
+	frameSize string
+}
+
+func (k setKey) String() string {
+	return fmt.Sprintf("%s %s %s", k.order, k.frameType, k.frameSize)
+}
+
+// pieceSet holds the pieces of a set which have arrived, keyed by path.
+type pieceSet struct {
+	key      setKey
+	size     int
+	pieces   map[string]string
+	lastSeen time.Time
+	timer    *time.Timer
+}
+
+// Sets tracks the pieces of multi-piece sets as they arrive, and checks each
+// set once it has had time to arrive in full.
+type Sets struct {
+	cfg       SetsConfig
+	metadata  Metadata
+	pipeline  Pipeline
+	recorders []Recorder
+
+	mu   sync.Mutex
+	sets map[setKey]*pieceSet
+}
+
+func NewSets(cfg Config, pipeline Pipeline, recorders []Recorder) *Sets {
+	return &Sets{cfg: cfg.Sets, metadata: cfg.Metadata, pipeline: pipeline, recorders: recorders, sets: make(map[setKey]*pieceSet)}
+}
+
+// Add records the file of the result as a piece of its set, if it belongs to
+// one.
+func (s *Sets) Add(ctx context.Context, logger *logrus.Logger, r *Result) {
+	key, size, ok := setOf(s.metadata, r)
+	if !ok {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.purge(r.Time)
+
+	set, ok := s.sets[key]
+	if !ok {
+		set = &pieceSet{key: key, size: size, pieces: make(map[string]string)}
+		s.sets[key] = set
+	}
+
+	set.pieces[r.Path] = r.FileAttr[attrPiece]
+	set.lastSeen = r.Time
+
+	// Sets are checked once, after waiting for the rest of the pieces; pieces
+	// arriving after that get the set checked again.
+	if set.timer == nil {
+		set.timer = time.AfterFunc(s.cfg.WaitOrDefault(), func() { s.check(ctx, logger, key) })
+	}
+}
+
+// Removed forgets the file as a piece of any set.
+func (s *Sets) Removed(path string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, set := range s.sets {
+		delete(set.pieces, path)
+	}
+}
+
+// purge forgets sets which haven't had a piece arrive in a while.
+func (s *Sets) purge(now time.Time) {
+	for key, set := range s.sets {
+		if set.timer == nil && now.Sub(set.lastSeen) > setRetention {
+			delete(s.sets, key)
+		}
+	}
+}
+
+func (s *Sets) check(ctx context.Context, logger *logrus.Logger, key setKey) {
+	s.mu.Lock()
+
+	set, ok := s.sets[key]
+	if !ok {
+		s.mu.Unlock()
+		return
+	}
+
+	set.timer = nil
+	results := set.check(time.Now())
+
+	s.mu.Unlock()
+
+	for _, r := range results {
+		if err := s.pipeline.Run(ctx, logger, r); err != nil {
+			logger.Errorf("set %s: %v", key, err)
+		}
+
+		for _, recorder := range s.recorders {
+			if err := recorder.Record(r); err != nil {
+				logger.Errorf("failed to record result: %v", err)
+			}
+		}
+	}
+}
+
+// checkSets returns the problems with the multi-piece sets the files of the
+// results belong to, checking them at once rather than waiting for the rest
+// of their pieces to arrive.
+func checkSets(metadata Metadata, results []*Result, now time.Time) []*Result {
+	sets := make(map[setKey]*pieceSet)
+	keys := make([]setKey, 0)
+
+	for _, r := range results {
+		key, size, ok := setOf(metadata, r)
+		if !ok {
+			continue
+		}
+
+		set, ok := sets[key]
+		if !ok {
+			set = &pieceSet{key: key, size: size, pieces: make(map[string]string)}
+			sets[key] = set
+			keys = append(keys, key)
+		}
+
+		set.pieces[r.Path] = r.FileAttr[attrPiece]
+	}
+
+	var problems []*Result
+	for _, key := range keys {
+		problems = append(problems, sets[key].check(now)...)
+	}
+
+	return problems
+}
+
+// check returns a result for each problem with the set.
+func (set *pieceSet) check(now time.Time) []*Result {
+	if len(set.pieces) == 0 {
+		return nil
+	}
+
+	paths := make([]string, 0, len(set.pieces))
+	for path := range set.pieces {
+		paths = append(paths, path)
+	}
+
+	sort.Strings(paths)
+
+	byPiece := make(map[string][]string)
+	byDir := make(map[string][]string)
+	for _, path := range paths {
+		if piece := set.pieces[path]; piece != "" {
+			byPiece[piece] = append(byPiece[piece], filepath.Base(path))
+		}
+
+		byDir[filepath.Dir(path)] = append(byDir[filepath.Dir(path)], filepath.Base(path))
+	}
+
+	result := func(verdict Verdict, title string, problems ...notify.Field) *Result {
+		fields := []notify.Field{
+			{Label: "🧾 order", Value: set.key.order},
+			{Label: "🖼 set", Value: strings.TrimSpace(set.key.frameType + " " + set.key.frameSize)},
+		}
+
+		return &Result{
+			Time:     now,
+			Path:     paths[0],
+			Verdict:  verdict,
+			Title:    title,
+			Fields:   append(fields, problems...),
+			FileAttr: map[string]string{attrOrderID: set.key.order, attrFrameType: set.key.frameType, attrFrameSize: set.key.frameSize},
+		}
+	}
+
+	var results []*Result
+
+	var duplicates []string
+	for piece, names := range byPiece {
+		if len(names) > 1 {
+			duplicates = append(duplicates, fmt.Sprintf("piece %s: %s", piece, strings.Join(names, ", ")))
+		}
+	}
+
+	if len(duplicates) > 0 {
+		sort.Strings(duplicates)
+		results = append(results, result(VerdictDuplicatePiece, "DUPLICATE PIECES",
+			notify.Field{Label: "❌ duplicates", Value: strings.Join(duplicates, "; ")}))
+	}
+
+	if len(byDir) > 1 {
+		folders := make([]string, 0, len(byDir))
+		for dir, names := range byDir {
+			folders = append(folders, fmt.Sprintf("%s: %s", filepath.Base(dir), strings.Join(names, ", ")))
+		}
+
+		sort.Strings(folders)
+		results = append(results, result(VerdictSplitSet, "SET SPLIT ACROSS FOLDERS",
+			notify.Field{Label: "❌ folders", Value: strings.Join(folders, "; ")}))
+	}
+
+	// Pieces without an index are counted, but can't say which are missing.
+	count := len(byPiece) + len(paths) - countIndexed(set.pieces)
+
+	if count < set.size {
+		problems := []notify.Field{{Label: "❌ pieces", Value: fmt.Sprintf("%d of %d arrived", count, set.size)}}
+
+		var missing []string
+		for i := 1; i <= set.size && len(byPiece) > 0; i++ {
+			if _, ok := byPiece[strconv.Itoa(i)]; !ok {
+				missing = append(missing, strconv.Itoa(i))
+			}
+		}
+
+		if len(missing) > 0 {
+			problems = append(problems, notify.Field{Label: "❌ missing", Value: strings.Join(missing, ", ")})
+		}
+
+		results = append(results, result(VerdictIncompleteSet, "INCOMPLETE SET", problems...))
+	}
+
+	return results
+}
+
+func countIndexed(pieces map[string]string) int {
+	n := 0
+	for _, piece := range pieces {
+		if piece != "" {
+			n++
+		}
+	}
+
+	return n
+}
+
+// TrackSetPieces forgets pieces of sets which are removed or renamed.
+func TrackSetPieces(sets *Sets) watcher.Callback {
+	return func(ctx context.Context, logger *logrus.Logger, e watcher.Event) error {
+		if !e.HasOp(watcher.RemoveOp) && !e.HasOp(watcher.RenameOp) {
+			return nil
+		}
+
+		sets.Removed(e.Name)
+
+		return nil
+	}
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestOrderAndPiece(t *testing.T) {
+	patterns := []string{
+		`^([^_]+)_(?P<frame_type>[^_]+)_(?P<frame_size>\d+x\d+).*$`,
+		`^([^_]+)_(?P<frame_type>[^_]+_[^_]+)_(?P<frame_size>\d+x\d+).*$`,
+	}
+
+	tests := []struct {
+		fileName  string
+		wantOrder string
+		wantPiece string
+	}{
+		{"1042_fr_3pc_11x14_2", "1042", "2"},
+		{"1042_fr_3pc_11x14-p03", "1042", "3"},
+		{"1042_fr_3pc_11x14", "1042", ""},
+		{"1042_fr_11x14", "1042", ""},
+		{"no pattern", "", ""},
+	}
+
+	for _, tc := range tests {
+		order, piece := orderAndPiece(tc.fileName, patterns)
+		if order != tc.wantOrder || piece != tc.wantPiece {
+			t.Errorf("%s: got unexpected order and piece, want=%q,%q, got=%q,%q", tc.fileName, tc.wantOrder, tc.wantPiece, order, piece)
+		}
+	}
+
+	metadata := Metadata{FrameType2Name: map[string][]string{"fr_3pc": {"framed 3pc"}, "sqw": {""}, "cs": {"canvas 2pc"}}}
+	for frameType, want := range map[string]int{"fr_3pc": 3, "sqw": 0, "cs": 2, "wd_9pc": 9} {
+		if got := setSize(metadata, frameType); got != want {
+			t.Errorf("%s: got unexpected set size, want=%d, got=%d", frameType, want, got)
+		}
+	}
+}
+
+func TestSets(t *testing.T) {
+	dir := t.TempDir()
+	logger := logrus.New()
+
+	var (
+		mu  sync.Mutex
+		got = make(map[Verdict]*Result)
+	)
+
+	record := func(ctx context.Context, logger *logrus.Logger, r *Result) error {
+		mu.Lock()
+		defer mu.Unlock()
+		got[r.Verdict] = r
+		return nil
+	}
+
+	pipeline := Pipeline{}
+	for _, v := range []Verdict{VerdictIncompleteSet, VerdictDuplicatePiece, VerdictSplitSet} {
+		pipeline[v] = []pipelineStep{{actionType: "record", run: record}}
+	}
+
+	cfg := Config{
+		Metadata: Metadata{FrameType2Name: map[string][]string{"fr_4pc": {"framed 4pc"}, "fr": {"framed"}}},
+		Sets:     SetsConfig{Enabled: true, Wait: 100 * time.Millisecond},
+	}
+
+	sets := NewSets(cfg, pipeline, nil)
+
+	// Piece 2 arrives twice, piece 3 lands in the wrong folder, piece 4 never
+	// arrives and piece 1 is removed again.
+	pieces := []struct {
+		dir, name, piece string
+	}{
+		{"framed 4pc 11x14", "1042_fr_4pc_11x14_1.jpg", "1"},
+		{"framed 4pc 11x14", "1042_fr_4pc_11x14_2.jpg", "2"},
+		{"framed 4pc 11x14", "1042_fr_4pc_11x14_2 copy.jpg", "2"},
+		{"framed 11x14", "1042_fr_4pc_11x14_3.jpg", "3"},
+		{"framed 11x14", "1042_fr_11x14.jpg", ""},
+	}
+
+	for _, p := range pieces {
+		frameType := "fr_4pc"
+		if p.piece == "" {
+			frameType = "fr"
+		}
+
+		sets.Add(context.Background(), logger, &Result{
+			Time:     time.Now(),
+			Path:     filepath.Join(dir, p.dir, p.name),
+			FileAttr: map[string]string{attrOrderID: "1042", attrFrameType: frameType, attrFrameSize: "11x14", attrPiece: p.piece},
+		})
+	}
+
+	sets.Removed(filepath.Join(dir, "framed 4pc 11x14", "1042_fr_4pc_11x14_1.jpg"))
+
+	time.Sleep(300 * time.Millisecond)
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if len(got) != 3 {
+		t.Fatalf("got unexpected verdicts, want=3, got=%v", got)
+	}
+
+	if msg := got[VerdictIncompleteSet].Message(); !strings.HasSuffix(msg, "❌ pieces: 2 of 4 arrived\n❌ missing: 1, 4") {
+		t.Errorf("got unexpected incomplete set message, got=%q", msg)
+	}
+}
@@ -0,0 +1,226 @@
+// Copyright (2023 -- present) Shahruk Hossain <shahruk10@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//		 http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// ==============================================================================
+
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/shahruk10/watcher/internal/imageinfo"
+	"github.com/shahruk10/watcher/internal/notify"
+	"github.com/shahruk10/watcher/internal/pdfinfo"
+)
+
+// Verdict is the outcome of validating a file.
+type Verdict string
+
+const (
+	VerdictCorrect           Verdict = "correct"
+	VerdictWrongFolder       Verdict = "wrong_folder"
+	VerdictUnknownType       Verdict = "unknown_type"
+	VerdictInvalidFileName   Verdict = "invalid_file_name"
+	VerdictInvalidFolderName Verdict = "invalid_folder_name"
+	VerdictWrongAspectRatio  Verdict = "wrong_aspect_ratio"
+	VerdictWrongOrientation  Verdict = "wrong_orientation"
+	VerdictLowResolution     Verdict = "low_resolution"
+	VerdictWrongColor        Verdict = "wrong_color"
+	VerdictCorruptFile       Verdict = "corrupt_file"
+	VerdictMislabelledFile   Verdict = "mislabelled_file"
+	VerdictMetadataMismatch  Verdict = "metadata_mismatch"
+	VerdictIncompleteSet     Verdict = "incomplete_set"
+	VerdictDuplicatePiece    Verdict = "duplicate_piece"
+	VerdictSplitSet          Verdict = "split_set"
+	VerdictWrongPageSize     Verdict = "wrong_page_size"
+	VerdictEncryptedPDF      Verdict = "encrypted_pdf"
+	VerdictMalformedPDF      Verdict = "malformed_pdf"
+)
+
+var verdicts = []Verdict{
+	VerdictCorrect,
+	VerdictWrongFolder,
+	VerdictUnknownType,
+	VerdictInvalidFileName,
+	VerdictInvalidFolderName,
+	VerdictWrongAspectRatio,
+	VerdictWrongOrientation,
+	VerdictLowResolution,
+	VerdictWrongColor,
+	VerdictCorruptFile,
+	VerdictMislabelledFile,
+	VerdictMetadataMismatch,
+	VerdictIncompleteSet,
+	VerdictDuplicatePiece,
+	VerdictSplitSet,
+	VerdictWrongPageSize,
+	VerdictEncryptedPDF,
+	VerdictMalformedPDF,
+}
+
+func isKnownVerdict(v Verdict) bool {
+	for _, known := range verdicts {
+		if v == known {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Result holds the verdict for a file along with everything that was worked
+// out while validating it.
+type Result struct {
+	Time    time.Time
+	Path    string
+	Op      string
+	Verdict Verdict
+
+	FileAttr map[string]string
+	DirAttr  map[string]string
+
+	// FilePattern and FolderPattern are the 1-based indices of the name
+	// patterns the attributes were parsed with, counting the file name
+	// patterns of override files first; 0 if no pattern was used.
+	FilePattern   int
+	FolderPattern int
+
+	// Owner is the user owning the file, if it was looked up.
+	Owner string
+
+	// Image describes the file's image, if it was inspected.
+	Image *imageinfo.Info
+	// PDF describes the file's document, if it was inspected.
+	PDF *pdfinfo.Info
+
+	// CorrectDirNames lists the names of the folders the file could belong
+	// in, if it is in the wrong folder.
+	CorrectDirNames []string
+
+	// Title and Fields describe the verdict for notifications.
+	Title  string
+	Fields []notify.Field
+
+	// Actions lists the actions carried out for the verdict, and MovedTo is
+	// where they moved the file, if anywhere. Moving the file also updates
+	// Path, so that later actions find it, keeping the path it was checked at
+	// in MovedFrom.
+	Actions   []ActionOutcome
+	MovedTo   string
+	MovedFrom string
+}
+
+// Recorder keeps a record of each result once its actions are carried out.
+type Recorder interface {
+	Record(r *Result) error
+}
+
+// SkipRecorder is a Recorder which also records files which weren't checked.
+type SkipRecorder interface {
+	Skipped(path, op string) error
+}
+
+// Message returns the fields describing the verdict as text.
+func (r *Result) Message() string {
+	n := notify.Notification{Fields: r.Fields}
+	return n.Message()
+}
+
+// Notification returns the notification describing the verdict.
+func (r *Result) Notification() notify.Notification {
+	severity := notify.SeverityError
+	if r.Verdict == VerdictCorrect {
+		severity = notify.SeverityInfo
+	}
+
+	summary := filepath.Base(r.Path)
+	if len(r.CorrectDirNames) > 0 {
+		summary = fmt.Sprintf("%s ➜ %s", summary, r.CorrectDirName())
+	}
+
+	return notify.Notification{
+		Severity: severity,
+		Title:    r.Title,
+		Fields:   r.Fields,
+		Summary:  summary,
+		Path:     r.Path,
+		Verdict:  string(r.Verdict),
+		Time:     r.Time,
+	}
+}
+
+// moved records that an action moved the file to path.
+func (r *Result) moved(path string) {
+	if r.MovedFrom == "" {
+		r.MovedFrom = r.Path
+	}
+
+	r.Path, r.MovedTo = path, path
+}
+
+// CheckedPath returns the path the file was checked at, before any actions
+// moved it.
+func (r *Result) CheckedPath() string {
+	if r.MovedFrom != "" {
+		return r.MovedFrom
+	}
+
+	return r.Path
+}
+
+// CorrectDirName returns the possible correct folder names joined together.
+func (r *Result) CorrectDirName() string {
+	return strings.Join(r.CorrectDirNames, " OR ")
+}
+
+// templateData is made available to templated action parameters.
+type templateData struct {
+	Time       time.Time
+	Path       string
+	MovedFrom  string
+	Name       string
+	Dir        string
+	DirName    string
+	Op         string
+	Verdict    Verdict
+	Title      string
+	Message    string
+	CorrectDir string
+	File       map[string]string
+	Folder     map[string]string
+	Image      *imageinfo.Info
+	PDF        *pdfinfo.Info
+}
+
+func (r *Result) templateData() templateData {
+	return templateData{
+		Time:       r.Time,
+		Path:       r.Path,
+		MovedFrom:  r.MovedFrom,
+		Name:       filepath.Base(r.Path),
+		Dir:        filepath.Dir(r.Path),
+		DirName:    filepath.Base(filepath.Dir(r.Path)),
+		Op:         r.Op,
+		Verdict:    r.Verdict,
+		Title:      r.Title,
+		Message:    r.Message(),
+		CorrectDir: r.CorrectDirName(),
+		File:       r.FileAttr,
+		Folder:     r.DirAttr,
+		Image:      r.Image,
+		PDF:        r.PDF,
+	}
+}
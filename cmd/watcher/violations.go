@@ -0,0 +1,459 @@
+// Copyright (2023 -- present) Shahruk Hossain <shahruk10@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//		 http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// ==============================================================================
+
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"strconv"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/peterbourgon/ff/v3/ffcli"
+	"github.com/shahruk10/watcher/internal/fileutil"
+	"github.com/shahruk10/watcher/internal/notify"
+	"github.com/shahruk10/watcher/internal/watcher"
+	"github.com/sirupsen/logrus"
+)
+
+const defaultViolationsPath = "watcher-violations.json"
+
+// Ways violations are resolved.
+const (
+	resolutionFixed   = "fixed"
+	resolutionRemoved = "removed"
+	resolutionChanged = "verdict changed"
+)
+
+// Statuses of violations.
+const (
+	statusOpen      = "open"
+	statusEscalated = "escalated"
+	statusResolved  = "resolved"
+)
+
+// ViolationsConfig controls tracking of violations until they are resolved.
+type ViolationsConfig struct {
+	Enabled bool   `yaml:"enabled"`
+	File    string `yaml:"file"`
+	// Deadline is how long a violation may stay open before it is escalated.
+	// Zero never escalates.
+	Deadline time.Duration `yaml:"deadline"`
+	// Retention is how long resolved violations are kept.
+	Retention time.Duration `yaml:"retention"`
+}
+
+func (cfg *ViolationsConfig) Path() string {
+	if cfg.File == "" {
+		return defaultViolationsPath
+	}
+
+	return cfg.File
+}
+
+func (cfg *ViolationsConfig) RetentionOrDefault() time.Duration {
+	if cfg.Retention <= 0 {
+		return 30 * 24 * time.Hour
+	}
+
+	return cfg.Retention
+}
+
+// Violation is a problem found with a file, which stays open until a later
+// event shows the file was fixed or removed.
+type Violation struct {
+	ID          int        `json:"id"`
+	Path        string     `json:"path"`
+	Verdict     Verdict    `json:"verdict"`
+	Title       string     `json:"title"`
+	Message     string     `json:"message"`
+	OpenedAt    time.Time  `json:"opened_at"`
+	EscalatedAt *time.Time `json:"escalated_at,omitempty"`
+	ResolvedAt  *time.Time `json:"resolved_at,omitempty"`
+	Resolution  string     `json:"resolution,omitempty"`
+}
+
+func (v *Violation) Status() string {
+	switch {
+	case v.ResolvedAt != nil:
+		return statusResolved
+	case v.EscalatedAt != nil:
+		return statusEscalated
+	default:
+		return statusOpen
+	}
+}
+
+// Violations is the registry of violations, saved to a file whenever it
+// changes.
+type Violations struct {
+	mu   sync.Mutex
+	cfg  ViolationsConfig
+	data violationsData
+}
+
+type violationsData struct {
+	NextID     int          `json:"next_id"`
+	Violations []*Violation `json:"violations"`
+}
+
+// OpenViolations loads the registry from the configured file, if it exists.
+func OpenViolations(cfg ViolationsConfig) (*Violations, error) {
+	v := &Violations{cfg: cfg, data: violationsData{NextID: 1}}
+
+	raw, err := os.ReadFile(cfg.Path())
+	if errors.Is(err, fs.ErrNotExist) {
+		return v, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("read violations: %w", err)
+	}
+
+	if err := json.Unmarshal(raw, &v.data); err != nil {
+		return nil, fmt.Errorf("read violations %q: %w", cfg.Path(), err)
+	}
+
+	return v, nil
+}
+
+// save writes the registry, dropping resolved violations older than the
+// retention period.
+func (v *Violations) save(now time.Time) error {
+	kept := v.data.Violations[:0]
+	for _, violation := range v.data.Violations {
+		if violation.ResolvedAt == nil || now.Sub(*violation.ResolvedAt) < v.cfg.RetentionOrDefault() {
+			kept = append(kept, violation)
+		}
+	}
+
+	v.data.Violations = kept
+
+	raw, err := json.MarshalIndent(v.data, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode violations: %w", err)
+	}
+
+	if err := fileutil.WriteFile(v.cfg.Path(), raw, 0o644); err != nil {
+		return fmt.Errorf("write violations: %w", err)
+	}
+
+	return nil
+}
+
+// open returns the unresolved violation for the path, if there is one.
+func (v *Violations) open(path string) *Violation {
+	for _, violation := range v.data.Violations {
+		if violation.ResolvedAt == nil && violation.Path == path {
+			return violation
+		}
+	}
+
+	return nil
+}
+
+func resolve(logger *logrus.Logger, violation *Violation, resolution string, now time.Time) {
+	violation.ResolvedAt, violation.Resolution = &now, resolution
+	logger.Infof("violation #%d (%s) for %q resolved: %s", violation.ID, violation.Verdict, violation.Path, resolution)
+}
+
+// Record opens a violation if the result reports a problem with the file, or
+// resolves the open violation for the file if it is now correct.
+func (v *Violations) Record(logger *logrus.Logger, r *Result) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	path := absPath(r.Path)
+	existing := v.open(path)
+
+	switch {
+	case r.Verdict == VerdictCorrect && existing == nil:
+		return nil
+	case r.Verdict == VerdictCorrect:
+		resolve(logger, existing, resolutionFixed, r.Time)
+		return v.save(r.Time)
+	case existing != nil && existing.Verdict == r.Verdict:
+		return nil
+	case existing != nil:
+		resolve(logger, existing, resolutionChanged, r.Time)
+	}
+
+	violation := &Violation{
+		ID:       v.data.NextID,
+		Path:     path,
+		Verdict:  r.Verdict,
+		Title:    r.Title,
+		Message:  r.Message(),
+		OpenedAt: r.Time,
+	}
+
+	v.data.NextID++
+	v.data.Violations = append(v.data.Violations, violation)
+	logger.Infof("violation #%d (%s) opened for %q", violation.ID, violation.Verdict, path)
+
+	return v.save(r.Time)
+}
+
+// Removed resolves the open violation for the file if it no longer exists,
+// having been deleted, moved or renamed. A file renamed within the watched
+// folders is checked again under its new name like any new file, and so only
+// gets a violation of its own if the new name is invalid too.
+func (v *Violations) Removed(logger *logrus.Logger, path string) error {
+	if _, err := os.Lstat(path); !errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	existing := v.open(absPath(path))
+	if existing == nil {
+		return nil
+	}
+
+	now := time.Now()
+	resolve(logger, existing, resolutionRemoved, now)
+
+	return v.save(now)
+}
+
+// Escalate notifies about violations which have been open for longer than
+// the deadline. Violations for files which have disappeared while the watcher
+// wasn't looking are resolved instead. If the alert state is given, violations
+// of muted files, or whose alert is snoozed, are escalated once that's over.
+func (v *Violations) Escalate(ctx context.Context, logger *logrus.Logger, notifier notify.Notifier, state *AlertState, now time.Time) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	changed := false
+	overdue := make([]*Violation, 0)
+
+	for _, violation := range v.data.Violations {
+		if violation.ResolvedAt != nil {
+			continue
+		}
+
+		if _, err := os.Lstat(violation.Path); errors.Is(err, fs.ErrNotExist) {
+			resolve(logger, violation, resolutionRemoved, now)
+			changed = true
+
+			continue
+		}
+
+		if v.cfg.Deadline > 0 && violation.EscalatedAt == nil && now.Sub(violation.OpenedAt) >= v.cfg.Deadline {
+			if state != nil {
+				raise, reason, err := state.shouldRaise(violation.Path, string(violation.Verdict), now)
+				if err != nil {
+					logger.Errorf("failed to update alert state: %v", err)
+				}
+
+				if !raise {
+					logger.Debugf("not escalating violation #%d for %q yet: %s", violation.ID, violation.Path, reason)
+					continue
+				}
+			}
+
+			escalatedAt := now
+			violation.EscalatedAt = &escalatedAt
+			overdue = append(overdue, violation)
+			changed = true
+		}
+	}
+
+	if !changed {
+		return nil
+	}
+
+	if err := v.save(now); err != nil {
+		return err
+	}
+
+	for _, violation := range overdue {
+		logger.Warnf("violation #%d (%s) for %q escalated", violation.ID, violation.Verdict, violation.Path)
+
+		if err := notifier.Notify(ctx, violation.Notification(now)); err != nil {
+			logger.Errorf("failed to escalate violation #%d: %v", violation.ID, err)
+		}
+	}
+
+	return nil
+}
+
+// Run checks for overdue violations every interval until the context is
+// cancelled.
+func (v *Violations) Run(ctx context.Context, logger *logrus.Logger, notifier notify.Notifier, state *AlertState, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := v.Escalate(ctx, logger, notifier, state, time.Now()); err != nil {
+			logger.Errorf("failed to check violations: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// List returns the unresolved violations, or all of them.
+func (v *Violations) List(all bool) []Violation {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	list := make([]Violation, 0, len(v.data.Violations))
+	for _, violation := range v.data.Violations {
+		if all || violation.ResolvedAt == nil {
+			list = append(list, *violation)
+		}
+	}
+
+	return list
+}
+
+// Notification escalates the violation.
+func (v *Violation) Notification(now time.Time) notify.Notification {
+	return notify.Notification{
+		Severity: notify.SeverityError,
+		Title:    "ESCALATED: " + v.Title,
+		Fields: []notify.Field{
+			{Label: "📁 file", Value: v.Path},
+			{Label: "⏰ open since", Value: v.OpenedAt.Format(time.RFC3339)},
+			{Value: v.Message},
+		},
+		Summary: fmt.Sprintf("#%d %s open for %s", v.ID, v.Path, now.Sub(v.OpenedAt).Round(time.Minute)),
+		Path:    v.Path,
+		Verdict: string(v.Verdict),
+		Time:    now,
+	}
+}
+
+// TrackRemovedFiles resolves violations for files which are removed or
+// renamed.
+func TrackRemovedFiles(violations *Violations) watcher.Callback {
+	return func(ctx context.Context, logger *logrus.Logger, e watcher.Event) error {
+		if !e.HasOp(watcher.RemoveOp) && !e.HasOp(watcher.RenameOp) {
+			return nil
+		}
+
+		return violations.Removed(logger, e.Name)
+	}
+}
+
+// Formats violations can be listed in.
+const (
+	formatTable = "table"
+	formatJSON  = "json"
+	formatCSV   = "csv"
+)
+
+func newViolationsCmd(logger *logrus.Logger, cfgPath *string) *ffcli.Command {
+	var (
+		violationsFlagSet = flag.NewFlagSet("watcher violations", flag.ExitOnError)
+		allFlag           = violationsFlagSet.Bool("all", false, "Include resolved violations.")
+		formatFlag        = violationsFlagSet.String("format", formatTable, "Output format: table, json or csv.")
+		outputFlag        = violationsFlagSet.String("output", "", "File to write to instead of stdout.")
+	)
+
+	return &ffcli.Command{
+		Name:       "violations",
+		ShortUsage: "watcher [flags] violations [-all] [-format table|json|csv] [-output <file>]",
+		ShortHelp:  "List or export open violations.",
+		FlagSet:    violationsFlagSet,
+		Exec: func(ctx context.Context, args []string) error {
+			if *formatFlag != formatTable && *formatFlag != formatJSON && *formatFlag != formatCSV {
+				return &configError{fmt.Errorf("unknown format %q", *formatFlag)}
+			}
+
+			cfg, err := loadConfig(logger, *cfgPath)
+			if err != nil {
+				return err
+			}
+
+			violations, err := OpenViolations(cfg.Violations)
+			if err != nil {
+				return err
+			}
+
+			out := io.Writer(os.Stdout)
+			if *outputFlag != "" {
+				f, err := os.Create(*outputFlag)
+				if err != nil {
+					return fmt.Errorf("create output file: %w", err)
+				}
+				defer f.Close()
+
+				out = f
+			}
+
+			return writeViolations(out, *formatFlag, violations.List(*allFlag))
+		},
+	}
+}
+
+func writeViolations(w io.Writer, format string, list []Violation) error {
+	timeOrEmpty := func(t *time.Time) string {
+		if t == nil {
+			return ""
+		}
+
+		return t.Format(time.RFC3339)
+	}
+
+	switch format {
+	case formatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+
+		return enc.Encode(list)
+
+	case formatCSV:
+		out := csv.NewWriter(w)
+		out.Write([]string{"id", "status", "verdict", "path", "opened_at", "escalated_at", "resolved_at", "resolution", "title"})
+
+		for _, v := range list {
+			out.Write([]string{
+				strconv.Itoa(v.ID), v.Status(), string(v.Verdict), v.Path, v.OpenedAt.Format(time.RFC3339),
+				timeOrEmpty(v.EscalatedAt), timeOrEmpty(v.ResolvedAt), v.Resolution, v.Title,
+			})
+		}
+
+		out.Flush()
+
+		return out.Error()
+
+	case formatTable:
+		out := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(out, "ID\tSTATUS\tVERDICT\tOPENED\tPATH")
+
+		for _, v := range list {
+			fmt.Fprintf(out, "%d\t%s\t%s\t%s\t%s\n", v.ID, v.Status(), v.Verdict, v.OpenedAt.Format("2006-01-02 15:04"), v.Path)
+		}
+
+		return out.Flush()
+
+	default:
+		return fmt.Errorf("unknown format %q", format)
+	}
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shahruk10/watcher/internal/notify"
+	"github.com/sirupsen/logrus"
+)
+
+func TestViolations(t *testing.T) {
+	dir := t.TempDir()
+	cfg := ViolationsConfig{File: filepath.Join(dir, "violations.json"), Deadline: time.Hour}
+	logger := logrus.New()
+
+	misplaced, renamed := filepath.Join(dir, "a.jpg"), filepath.Join(dir, "b.jpg")
+	for _, path := range []string{misplaced, renamed} {
+		if err := os.WriteFile(path, []byte("jpg"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	violations, err := OpenViolations(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	opened := time.Now()
+	for _, r := range []*Result{
+		{Time: opened, Path: misplaced, Verdict: VerdictWrongFolder, Title: "WRONG FOLDER"},
+		{Time: opened, Path: misplaced, Verdict: VerdictWrongFolder, Title: "WRONG FOLDER"},
+		{Time: opened, Path: renamed, Verdict: VerdictInvalidFileName, Title: "INVALID FILE NAME"},
+	} {
+		if err := violations.Record(logger, r); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if got := violations.List(false); len(got) != 2 {
+		t.Fatalf("got unexpected number of open violations, want=2, got=%d", len(got))
+	}
+
+	// Escalation only happens once the deadline has passed, and only once.
+	rec := &recorder{}
+	for _, at := range []time.Time{opened.Add(time.Minute), opened.Add(2 * time.Hour), opened.Add(3 * time.Hour)} {
+		if err := violations.Escalate(context.Background(), logger, rec, nil, at); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if len(rec.got) != 2 || !strings.HasPrefix(rec.got[0].Title, "ESCALATED: ") {
+		t.Errorf("got unexpected escalations, got=%+v", rec.got)
+	}
+
+	// The violations are persisted, and resolved by a correct verdict or the
+	// file being removed.
+	violations, err = OpenViolations(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := violations.Record(logger, &Result{Time: time.Now(), Path: misplaced, Verdict: VerdictCorrect}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := violations.Removed(logger, renamed); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := violations.List(false); len(got) != 1 || got[0].Path != renamed {
+		t.Fatalf("got violation resolved for file which still exists, got=%+v", got)
+	}
+
+	if err := os.Remove(renamed); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := violations.Removed(logger, renamed); err != nil {
+		t.Fatal(err)
+	}
+
+	all := violations.List(true)
+	if len(violations.List(false)) != 0 || len(all) != 2 {
+		t.Fatalf("got unexpected violations after resolving, got=%+v", all)
+	}
+
+	if all[0].Resolution != resolutionFixed || all[1].Resolution != resolutionRemoved {
+		t.Errorf("got unexpected resolutions, want=%q,%q, got=%q,%q", resolutionFixed, resolutionRemoved, all[0].Resolution, all[1].Resolution)
+	}
+
+	var buf bytes.Buffer
+	if err := writeViolations(&buf, formatCSV, all); err != nil {
+		t.Fatal(err)
+	}
+
+	if lines := strings.Split(strings.TrimSpace(buf.String()), "\n"); len(lines) != 3 || !strings.HasPrefix(lines[1], "1,resolved,wrong_folder,") {
+		t.Errorf("got unexpected csv export, got=%q", buf.String())
+	}
+}
+
+func TestViolationsEscalationAlertState(t *testing.T) {
+	opened := time.Now()
+	cases := []struct {
+		name  string
+		setup func(state *AlertState, path string) error
+	}{
+		{
+			name: "muted file",
+			setup: func(state *AlertState, path string) error {
+				return state.Mute(path, opened.Add(3*time.Hour))
+			},
+		},
+		{
+			name: "snoozed alert",
+			setup: func(state *AlertState, path string) error {
+				_, _, err := state.shouldRaise(path, string(VerdictWrongFolder), opened)
+				return err
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			dir := t.TempDir()
+			logger := logrus.New()
+			path := filepath.Join(dir, "a.jpg")
+			if err := os.WriteFile(path, []byte("jpg"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			state, err := OpenAlertState(AlertsConfig{Snooze: 3 * time.Hour, StateFile: filepath.Join(dir, "state.json")})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err := c.setup(state, path); err != nil {
+				t.Fatal(err)
+			}
+
+			violations, err := OpenViolations(ViolationsConfig{File: filepath.Join(dir, "violations.json"), Deadline: time.Hour})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err := violations.Record(logger, &Result{Time: opened, Path: path, Verdict: VerdictWrongFolder, Title: "WRONG FOLDER"}); err != nil {
+				t.Fatal(err)
+			}
+
+			// The overdue violation isn't escalated while its alerts are held
+			// back, but is once that's over.
+			rec := &recorder{}
+			if err := violations.Escalate(context.Background(), logger, rec, state, opened.Add(2*time.Hour)); err != nil {
+				t.Fatal(err)
+			}
+
+			if len(rec.got) != 0 {
+				t.Fatalf("got unexpected escalations, want=none, got=%+v", rec.got)
+			}
+
+			if err := violations.Escalate(context.Background(), logger, rec, state, opened.Add(4*time.Hour)); err != nil {
+				t.Fatal(err)
+			}
+
+			if len(rec.got) != 1 {
+				t.Errorf("got unexpected number of escalations, want=1, got=%d", len(rec.got))
+			}
+		})
+	}
+}
+
+// recorder records notifications it is asked to deliver.
+type recorder struct {
+	got []notify.Notification
+}
+
+func (r *recorder) Notify(ctx context.Context, n notify.Notification) error {
+	r.got = append(r.got, n)
+	return nil
+}
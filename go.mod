@@ -7,10 +7,11 @@ require (
 	github.com/peterbourgon/ff/v3 v3.3.0
 	github.com/sirupsen/logrus v1.9.0
 	github.com/sqweek/dialog v0.0.0-20220809060634-e981b270ebbf
+	go.etcd.io/bbolt v1.3.7
+	golang.org/x/sys v0.5.0
 	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
 	github.com/TheTitanrain/w32 v0.0.0-20180517000239-4f5cfb03fabf // indirect
-	golang.org/x/sys v0.5.0 // indirect
 )
@@ -0,0 +1,174 @@
+// Copyright (2023 -- present) Shahruk Hossain <shahruk10@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//		 http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// ==============================================================================
+
+package fileutil
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// Move moves the file at src to dst without ever overwriting an existing file
+// at dst; fs.ErrExist is returned if dst already exists. The destination only
+// becomes visible once it has been completely written, even when src and dst
+// are on different file systems.
+func Move(src, dst string) error {
+	err := os.Link(src, dst)
+	if err == nil {
+		return os.Remove(src)
+	}
+
+	if errors.Is(err, fs.ErrExist) {
+		return fmt.Errorf("move %q to %q: %w", src, dst, fs.ErrExist)
+	}
+
+	// Hard links can't be created across file systems (or at all on some file
+	// systems), so copy to a temporary file next to the destination first and
+	// then put it in place.
+	tmp, err := copyToTemp(src, filepath.Dir(dst))
+	if err != nil {
+		return fmt.Errorf("move %q to %q: %w", src, dst, err)
+	}
+
+	if err := place(tmp, dst); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("move %q to %q: %w", src, dst, err)
+	}
+
+	return os.Remove(src)
+}
+
+// Copy copies the file at src to dst without overwriting an existing file at
+// dst. The destination only becomes visible once it has been completely
+// written.
+func Copy(src, dst string) error {
+	tmp, err := copyToTemp(src, filepath.Dir(dst))
+	if err != nil {
+		return fmt.Errorf("copy %q to %q: %w", src, dst, err)
+	}
+
+	if err := place(tmp, dst); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("copy %q to %q: %w", src, dst, err)
+	}
+
+	return nil
+}
+
+// WriteFile replaces the contents of the file at path with data. Readers see
+// either the old or the new contents, never a partially written file.
+func WriteFile(path string, data []byte, perm fs.FileMode) error {
+	out, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("write %q: %w", path, err)
+	}
+
+	_, err = out.Write(data)
+	if err == nil {
+		err = out.Sync()
+	}
+
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err == nil {
+		err = os.Chmod(out.Name(), perm)
+	}
+
+	if err == nil {
+		err = os.Rename(out.Name(), path)
+	}
+
+	if err != nil {
+		os.Remove(out.Name())
+		return fmt.Errorf("write %q: %w", path, err)
+	}
+
+	return nil
+}
+
+// place atomically renames tmp to dst, failing if dst exists.
+func place(tmp, dst string) error {
+	err := os.Link(tmp, dst)
+	if err == nil {
+		return os.Remove(tmp)
+	}
+
+	if errors.Is(err, fs.ErrExist) {
+		return fs.ErrExist
+	}
+
+	// The destination file system doesn't support hard links; fall back to a
+	// rename which doesn't replace the destination.
+	return renameNoReplace(tmp, dst)
+}
+
+// checkAndRename renames src to dst after checking dst is free. A file created
+// at dst by another process between the check and the rename is replaced, so
+// it's only used where the platform or file system offers nothing better.
+func checkAndRename(src, dst string) error {
+	if _, err := os.Lstat(dst); err == nil {
+		return fs.ErrExist
+	}
+
+	return os.Rename(src, dst)
+}
+
+func copyToTemp(src, dir string) (string, error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return "", err
+	}
+	defer in.Close()
+
+	info, err := in.Stat()
+	if err != nil {
+		return "", err
+	}
+
+	out, err := os.CreateTemp(dir, "."+filepath.Base(src)+".*.tmp")
+	if err != nil {
+		return "", err
+	}
+
+	_, err = io.Copy(out, in)
+	if err == nil {
+		err = out.Sync()
+	}
+
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err == nil {
+		err = os.Chmod(out.Name(), info.Mode().Perm())
+	}
+
+	if err == nil {
+		err = os.Chtimes(out.Name(), info.ModTime(), info.ModTime())
+	}
+
+	if err != nil {
+		os.Remove(out.Name())
+		return "", err
+	}
+
+	return out.Name(), nil
+}
@@ -0,0 +1,107 @@
+package fileutil
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"os/user"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestMove(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "a.jpg")
+	dst := filepath.Join(dir, "b.jpg")
+
+	if err := os.WriteFile(src, []byte("src"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(dst, []byte("dst"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Move(src, dst); !errors.Is(err, fs.ErrExist) {
+		t.Errorf("got unexpected error when moving onto existing file, want=%v, got=%v", fs.ErrExist, err)
+	}
+
+	if data, _ := os.ReadFile(dst); string(data) != "dst" {
+		t.Errorf("got existing file overwritten, want=dst, got=%s", data)
+	}
+
+	if err := os.Remove(dst); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Move(src, dst); err != nil {
+		t.Fatalf("got unexpected error when moving file, want=nil, got=%v", err)
+	}
+
+	if data, _ := os.ReadFile(dst); string(data) != "src" {
+		t.Errorf("got unexpected moved file contents, want=src, got=%s", data)
+	}
+
+	if _, err := os.Stat(src); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("got source file left behind after move, err=%v", err)
+	}
+}
+
+func TestRenameNoReplace(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "a.jpg")
+	dst := filepath.Join(dir, "b.jpg")
+
+	for path, data := range map[string]string{src: "src", dst: "dst"} {
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := renameNoReplace(src, dst); !errors.Is(err, fs.ErrExist) {
+		t.Errorf("got unexpected error when renaming onto existing file, want=%v, got=%v", fs.ErrExist, err)
+	}
+
+	if data, _ := os.ReadFile(dst); string(data) != "dst" {
+		t.Errorf("got existing file overwritten, want=dst, got=%s", data)
+	}
+
+	free := filepath.Join(dir, "c.jpg")
+	if err := renameNoReplace(src, free); err != nil {
+		t.Fatalf("got unexpected error when renaming file, want=nil, got=%v", err)
+	}
+
+	if data, _ := os.ReadFile(free); string(data) != "src" {
+		t.Errorf("got unexpected renamed file contents, want=src, got=%s", data)
+	}
+}
+
+func TestOwner(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test compares against the POSIX user name")
+	}
+
+	path := filepath.Join(t.TempDir(), "a.jpg")
+	if err := os.WriteFile(path, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	current, err := user.Current()
+	if err != nil {
+		t.Skip(err)
+	}
+
+	owner, err := Owner(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if owner != current.Username {
+		t.Errorf("got unexpected owner, want=%q, got=%q", current.Username, owner)
+	}
+
+	if _, err := Owner(filepath.Join(t.TempDir(), "missing.jpg")); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("got unexpected error for missing file: %v", err)
+	}
+}
@@ -0,0 +1,47 @@
+// Copyright (2023 -- present) Shahruk Hossain <shahruk10@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//		 http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// ==============================================================================
+
+package fileutil
+
+import (
+	"fmt"
+
+	"golang.org/x/sys/windows"
+)
+
+// Owner returns the account owning the file at path, as DOMAIN\name, or its
+// security identifier if the account can't be looked up.
+func Owner(path string) (string, error) {
+	sd, err := windows.GetNamedSecurityInfo(path, windows.SE_FILE_OBJECT, windows.OWNER_SECURITY_INFORMATION)
+	if err != nil {
+		return "", fmt.Errorf("owner of %q: %w", path, err)
+	}
+
+	sid, _, err := sd.Owner()
+	if err != nil {
+		return "", fmt.Errorf("owner of %q: %w", path, err)
+	}
+
+	account, domain, _, err := sid.LookupAccount("")
+	if err != nil {
+		return sid.String(), nil
+	}
+
+	if domain != "" {
+		return domain + `\` + account, nil
+	}
+
+	return account, nil
+}
@@ -0,0 +1,25 @@
+// Copyright (2023 -- present) Shahruk Hossain <shahruk10@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//		 http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// ==============================================================================
+
+//go:build !linux && !windows
+
+package fileutil
+
+// renameNoReplace renames src to dst, failing with fs.ErrExist if dst exists.
+// Without a way to do so in a single step on this platform, the check and
+// the rename are separate; see checkAndRename.
+func renameNoReplace(src, dst string) error {
+	return checkAndRename(src, dst)
+}
@@ -0,0 +1,114 @@
+// Copyright (2023 -- present) Shahruk Hossain <shahruk10@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//		 http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// ==============================================================================
+
+package imageinfo
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"unicode/utf16"
+)
+
+// iccHeaderSize is the size of the fixed header of an ICC profile, which is
+// followed by the tag table.
+const iccHeaderSize = 128
+
+// iccDescription returns the profile description of an ICC profile, or an
+// empty string if it has none.
+func iccDescription(profile []byte) string {
+	if len(profile) < iccHeaderSize+4 {
+		return ""
+	}
+
+	be := binary.BigEndian
+	count := int(be.Uint32(profile[iccHeaderSize:]))
+
+	for i := 0; i < count; i++ {
+		entry := iccHeaderSize + 4 + 12*i
+		if entry+12 > len(profile) {
+			return ""
+		}
+
+		if string(profile[entry:entry+4]) != "desc" {
+			continue
+		}
+
+		start, size := int(be.Uint32(profile[entry+4:])), int(be.Uint32(profile[entry+8:]))
+		if start < 0 || size < 0 || start+size > len(profile) {
+			return ""
+		}
+
+		return iccText(profile[start : start+size])
+	}
+
+	return ""
+}
+
+// iccText decodes a textDescriptionType (ICC v2) or multiLocalizedUnicodeType
+// (ICC v4) tag, preferring English for the latter.
+func iccText(tag []byte) string {
+	if len(tag) < 12 {
+		return ""
+	}
+
+	be := binary.BigEndian
+
+	switch string(tag[:4]) {
+	case "desc":
+		n := int(be.Uint32(tag[8:12]))
+		if n > len(tag)-12 {
+			n = len(tag) - 12
+		}
+
+		return strings.TrimRight(string(tag[12:12+n]), "\x00")
+
+	case "mluc":
+		if len(tag) < 16 {
+			return ""
+		}
+
+		records, recordSize := int(be.Uint32(tag[8:12])), int(be.Uint32(tag[12:16]))
+		if recordSize < 12 {
+			return ""
+		}
+
+		text := ""
+		for i := 0; i < records; i++ {
+			record := 16 + recordSize*i
+			if record+12 > len(tag) {
+				break
+			}
+
+			length, offset := int(be.Uint32(tag[record+4:])), int(be.Uint32(tag[record+8:]))
+			if offset+length > len(tag) || length%2 != 0 {
+				continue
+			}
+
+			units := make([]uint16, length/2)
+			for j := range units {
+				units[j] = be.Uint16(tag[offset+2*j:])
+			}
+
+			if text == "" || bytes.Equal(tag[record:record+2], []byte("en")) {
+				text = string(utf16.Decode(units))
+			}
+		}
+
+		return strings.TrimRight(text, "\x00")
+	}
+
+	return ""
+}
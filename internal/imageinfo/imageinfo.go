@@ -0,0 +1,436 @@
+// Copyright (2023 -- present) Shahruk Hossain <shahruk10@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//		 http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// ==============================================================================
+
+// Package imageinfo reads the dimensions and colour space of JPEG, PNG and TIFF
+// images from their headers, without decoding the images.
+package imageinfo
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Image formats.
+const (
+	FormatJPEG = "jpeg"
+	FormatPNG  = "png"
+	FormatTIFF = "tiff"
+)
+
+// ErrUnsupported is returned for files which aren't JPEG, PNG or TIFF images.
+var ErrUnsupported = errors.New("unsupported image format")
+
+// Info describes an image.
+type Info struct {
+	Format string `json:"format"`
+
+	// Width and Height of the image in pixels, as stored.
+	Width  int `json:"width"`
+	Height int `json:"height"`
+
+	// Orientation is the EXIF orientation, from 1 to 8; images with an
+	// orientation of 5 to 8 are rotated by 90 degrees when displayed.
+	Orientation int `json:"orientation"`
+
+	// ColorModel is one of the ColorModel constants, or empty if unknown.
+	ColorModel string `json:"color_model,omitempty"`
+	// BitDepth is the number of bits per sample.
+	BitDepth int `json:"bit_depth,omitempty"`
+	// ICCProfile is the description of the embedded ICC profile, if any.
+	ICCProfile string `json:"icc_profile,omitempty"`
+}
+
+// Colour models. Alpha channels are not taken into account.
+const (
+	ColorGray    = "gray"
+	ColorRGB     = "rgb"
+	ColorCMYK    = "cmyk"
+	ColorIndexed = "indexed"
+	ColorLab     = "lab"
+)
+
+// DisplaySize returns the width and height of the image as displayed, after
+// applying its orientation.
+func (i *Info) DisplaySize() (int, int) {
+	if i.Orientation >= 5 && i.Orientation <= 8 {
+		return i.Height, i.Width
+	}
+
+	return i.Width, i.Height
+}
+
+// ReadFile reads the header of the image file at path.
+func ReadFile(path string) (*Info, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	return Read(f, info.Size())
+}
+
+// Read reads the header of the image of the given size.
+func Read(r io.ReaderAt, size int64) (*Info, error) {
+	magic := make([]byte, 8)
+	if _, err := r.ReadAt(magic, 0); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+
+	var (
+		info *Info
+		err  error
+	)
+
+	switch {
+	case bytes.HasPrefix(magic, []byte{0xff, 0xd8, 0xff}):
+		info, err = readJPEG(bufio.NewReader(io.NewSectionReader(r, 0, size)))
+	case bytes.Equal(magic, pngSignature):
+		info, err = readPNG(r, size)
+	case bytes.HasPrefix(magic, []byte("II*\x00")) || bytes.HasPrefix(magic, []byte("MM\x00*")):
+		info, err = readTIFF(r)
+	default:
+		return nil, ErrUnsupported
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if info.Width == 0 || info.Height == 0 {
+		return nil, fmt.Errorf("read %s: image dimensions missing", info.Format)
+	}
+
+	if info.Orientation == 0 {
+		info.Orientation = 1
+	}
+
+	return info, nil
+}
+
+// JPEG markers.
+const (
+	markerSOI  = 0xd8
+	markerEOI  = 0xd9
+	markerSOS  = 0xda
+	markerAPP1 = 0xe1
+	markerAPP2 = 0xe2
+	markerTEM  = 0x01
+	markerRST0 = 0xd0
+	markerRST7 = 0xd7
+)
+
+// isSOF returns true for the start of frame markers, which hold the image
+// dimensions. DHT, JPG and DAC share the range but aren't frames.
+func isSOF(marker byte) bool {
+	return marker >= 0xc0 && marker <= 0xcf && marker != 0xc4 && marker != 0xc8 && marker != 0xcc
+}
+
+var (
+	exifHeader = []byte("Exif\x00\x00")
+	iccHeader  = []byte("ICC_PROFILE\x00")
+)
+
+// jpegColorModels maps the number of components in a JPEG frame to the colour
+// model; 3 components are YCbCr encoded RGB, and 4 are CMYK or YCCK.
+var jpegColorModels = map[byte]string{1: ColorGray, 3: ColorRGB, 4: ColorCMYK}
+
+func readJPEG(r *bufio.Reader) (*Info, error) {
+	info := &Info{Format: FormatJPEG}
+
+	// ICC profiles may be split across several APP2 segments, which are
+	// numbered from 1.
+	iccChunks := make(map[byte][]byte)
+
+	if _, err := r.Discard(2); err != nil {
+		return nil, fmt.Errorf("read jpeg: %w", err)
+	}
+
+	for {
+		marker, err := nextMarker(r)
+		if err != nil {
+			return nil, fmt.Errorf("read jpeg: %w", err)
+		}
+
+		if marker == markerTEM || (marker >= markerRST0 && marker <= markerRST7) || marker == markerSOI {
+			continue
+		}
+
+		if marker == markerSOS || marker == markerEOI {
+			return nil, fmt.Errorf("read jpeg: no frame header before image data")
+		}
+
+		var length uint16
+		if err := binary.Read(r, binary.BigEndian, &length); err != nil {
+			return nil, fmt.Errorf("read jpeg: %w", err)
+		}
+
+		if length < 2 {
+			return nil, fmt.Errorf("read jpeg: invalid segment length %d", length)
+		}
+
+		segment := make([]byte, length-2)
+		if _, err := io.ReadFull(r, segment); err != nil {
+			return nil, fmt.Errorf("read jpeg: %w", err)
+		}
+
+		switch {
+		case isSOF(marker):
+			if len(segment) < 6 {
+				return nil, fmt.Errorf("read jpeg: frame header too short")
+			}
+
+			info.BitDepth = int(segment[0])
+			info.Height = int(binary.BigEndian.Uint16(segment[1:3]))
+			info.Width = int(binary.BigEndian.Uint16(segment[3:5]))
+			info.ColorModel = jpegColorModels[segment[5]]
+
+			var profile []byte
+			for i := byte(1); iccChunks[i] != nil; i++ {
+				profile = append(profile, iccChunks[i]...)
+			}
+
+			info.ICCProfile = iccDescription(profile)
+
+			return info, nil
+
+		case marker == markerAPP2 && bytes.HasPrefix(segment, iccHeader) && len(segment) > len(iccHeader)+2:
+			iccChunks[segment[len(iccHeader)]] = segment[len(iccHeader)+2:]
+
+		case marker == markerAPP1 && bytes.HasPrefix(segment, exifHeader):
+			exif, err := readTIFF(bytes.NewReader(segment[len(exifHeader):]))
+			if err == nil {
+				info.Orientation = exif.Orientation
+			}
+		}
+	}
+}
+
+// nextMarker reads up to and including the next marker, skipping fill bytes.
+func nextMarker(r *bufio.Reader) (byte, error) {
+	b, err := r.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+
+	if b != 0xff {
+		return 0, fmt.Errorf("expected marker, got 0x%02x", b)
+	}
+
+	for b == 0xff {
+		if b, err = r.ReadByte(); err != nil {
+			return 0, err
+		}
+	}
+
+	return b, nil
+}
+
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+// pngColorModels maps PNG colour types to colour models.
+var pngColorModels = map[byte]string{0: ColorGray, 2: ColorRGB, 3: ColorIndexed, 4: ColorGray, 6: ColorRGB}
+
+// srgbDescription is reported as the ICC profile of PNG images with an sRGB
+// chunk, which stands in for the standard sRGB profile.
+const srgbDescription = "sRGB IEC61966-2.1"
+
+// maxICCProfileSize limits the size of ICC profiles read, both compressed and
+// decompressed.
+const maxICCProfileSize = 4 << 20
+
+func readPNG(r io.ReaderAt, size int64) (*Info, error) {
+	header := make([]byte, 8+8+13)
+	if _, err := r.ReadAt(header, 0); err != nil {
+		return nil, fmt.Errorf("read png: %w", err)
+	}
+
+	if string(header[12:16]) != "IHDR" {
+		return nil, fmt.Errorf("read png: first chunk is %q, not IHDR", header[12:16])
+	}
+
+	info := &Info{
+		Format:     FormatPNG,
+		Width:      int(binary.BigEndian.Uint32(header[16:20])),
+		Height:     int(binary.BigEndian.Uint32(header[20:24])),
+		BitDepth:   int(header[24]),
+		ColorModel: pngColorModels[header[25]],
+	}
+
+	// Colour space chunks come before the image data.
+	chunk := make([]byte, 8)
+	for offset := int64(8 + 8 + 13 + 4); ; {
+		if _, err := r.ReadAt(chunk, offset); err != nil {
+			return nil, fmt.Errorf("read png: %w", err)
+		}
+
+		length, chunkType := int64(binary.BigEndian.Uint32(chunk[:4])), string(chunk[4:8])
+		if chunkType == "IDAT" || chunkType == "IEND" {
+			return info, nil
+		} else if offset+8+length+4 > size {
+			return nil, fmt.Errorf("read png: %s chunk of %d bytes is truncated", chunkType, length)
+		}
+
+		switch chunkType {
+		case "sRGB":
+			if info.ICCProfile == "" {
+				info.ICCProfile = srgbDescription
+			}
+
+		case "iCCP":
+			// Oversized profiles are skipped rather than read into memory.
+			if length > maxICCProfileSize {
+				break
+			}
+
+			data := make([]byte, length)
+			if _, err := r.ReadAt(data, offset+8); err != nil {
+				return nil, fmt.Errorf("read png: %w", err)
+			}
+
+			// The profile name is followed by a null byte, the compression
+			// method and the zlib compressed profile.
+			if i := bytes.IndexByte(data, 0); i >= 0 && i+2 <= len(data) {
+				if zr, err := zlib.NewReader(bytes.NewReader(data[i+2:])); err == nil {
+					profile, _ := io.ReadAll(io.LimitReader(zr, maxICCProfileSize))
+					info.ICCProfile = iccDescription(profile)
+				}
+			}
+		}
+
+		offset += 8 + length + 4
+	}
+}
+
+// TIFF tags.
+const (
+	tagImageWidth    = 256
+	tagImageLength   = 257
+	tagBitsPerSample = 258
+	tagPhotometric   = 262
+	tagOrientation   = 274
+	tagICCProfile    = 34675
+)
+
+// tiffEntrySize is the size of an entry in an image file directory.
+const tiffEntrySize = 12
+
+// TIFF field types.
+const (
+	typeUndefined = 7
+	typeShort     = 3
+	typeLong      = 4
+)
+
+// tiffColorModels maps TIFF photometric interpretations to colour models.
+var tiffColorModels = map[int]string{
+	0: ColorGray, 1: ColorGray, 2: ColorRGB, 3: ColorIndexed, 5: ColorCMYK, 6: ColorRGB, 8: ColorLab, 9: ColorLab, 10: ColorLab,
+}
+
+// readTIFF reads the first image file directory of a TIFF file, or of the
+// EXIF data of a JPEG.
+func readTIFF(r io.ReaderAt) (*Info, error) {
+	header := make([]byte, 8)
+	if _, err := r.ReadAt(header, 0); err != nil {
+		return nil, fmt.Errorf("read tiff: %w", err)
+	}
+
+	var order binary.ByteOrder
+	switch string(header[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return nil, fmt.Errorf("read tiff: invalid byte order %q", header[:2])
+	}
+
+	if order.Uint16(header[2:4]) != 42 {
+		return nil, fmt.Errorf("read tiff: unsupported version %d", order.Uint16(header[2:4]))
+	}
+
+	offset := int64(order.Uint32(header[4:8]))
+
+	count := make([]byte, 2)
+	if _, err := r.ReadAt(count, offset); err != nil {
+		return nil, fmt.Errorf("read tiff: %w", err)
+	}
+
+	entries := make([]byte, tiffEntrySize*int(order.Uint16(count)))
+	if _, err := r.ReadAt(entries, offset+2); err != nil {
+		return nil, fmt.Errorf("read tiff: %w", err)
+	}
+
+	info := &Info{Format: FormatTIFF}
+
+	for i := 0; i < len(entries); i += tiffEntrySize {
+		entry := entries[i : i+tiffEntrySize]
+		tag, fieldType, n := order.Uint16(entry[0:2]), order.Uint16(entry[2:4]), order.Uint32(entry[4:8])
+
+		if tag == tagICCProfile && fieldType == typeUndefined && n > 4 && n <= maxICCProfileSize {
+			profile := make([]byte, n)
+			if _, err := r.ReadAt(profile, int64(order.Uint32(entry[8:12]))); err == nil {
+				info.ICCProfile = iccDescription(profile)
+			}
+
+			continue
+		}
+
+		var value int
+		switch {
+		case fieldType == typeShort && n <= 2:
+			value = int(order.Uint16(entry[8:10]))
+		case fieldType == typeShort:
+			// Values which don't fit in the entry are stored at an offset;
+			// only the first is needed.
+			first := make([]byte, 2)
+			if _, err := r.ReadAt(first, int64(order.Uint32(entry[8:12]))); err != nil {
+				continue
+			}
+
+			value = int(order.Uint16(first))
+		case fieldType == typeLong:
+			value = int(order.Uint32(entry[8:12]))
+		default:
+			continue
+		}
+
+		switch tag {
+		case tagImageWidth:
+			info.Width = value
+		case tagImageLength:
+			info.Height = value
+		case tagOrientation:
+			info.Orientation = value
+		case tagBitsPerSample:
+			info.BitDepth = value
+		case tagPhotometric:
+			info.ColorModel = tiffColorModels[value]
+		}
+	}
+
+	return info, nil
+}
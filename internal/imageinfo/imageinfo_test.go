@@ -0,0 +1,241 @@
+package imageinfo
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+func encodeJPEG(t *testing.T, w, h int) []byte {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, image.NewGray(image.Rect(0, 0, w, h)), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
+
+// tiffHeader returns a little endian TIFF header with a single directory
+// holding the given SHORT valued tags.
+func tiffHeader(tags map[uint16]uint16) []byte {
+	buf := bytes.NewBuffer([]byte("II*\x00"))
+	binary.Write(buf, binary.LittleEndian, uint32(8))
+	binary.Write(buf, binary.LittleEndian, uint16(len(tags)))
+
+	for _, tag := range []uint16{tagImageWidth, tagImageLength, tagOrientation} {
+		if value, ok := tags[tag]; ok {
+			binary.Write(buf, binary.LittleEndian, []uint16{tag, typeShort, 1, 0, value, 0})
+		}
+	}
+
+	return buf.Bytes()
+}
+
+// withExifOrientation inserts an APP1 segment with the orientation after the
+// SOI marker of the JPEG.
+func withExifOrientation(jpg []byte, orientation uint16) []byte {
+	exif := append([]byte("Exif\x00\x00"), tiffHeader(map[uint16]uint16{tagOrientation: orientation})...)
+
+	segment := []byte{0xff, markerAPP1, 0, 0}
+	binary.BigEndian.PutUint16(segment[2:], uint16(len(exif)+2))
+	segment = append(segment, exif...)
+
+	return append(append(append([]byte{}, jpg[:2]...), segment...), jpg[2:]...)
+}
+
+func TestRead(t *testing.T) {
+	var pngBuf bytes.Buffer
+	if err := png.Encode(&pngBuf, image.NewGray(image.Rect(0, 0, 30, 20))); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		data       []byte
+		want       Info
+		displayW   int
+		displayH   int
+		wantErr    error
+		wantAnyErr bool
+	}{
+		{name: "jpeg", data: encodeJPEG(t, 40, 20), want: Info{Format: FormatJPEG, Width: 40, Height: 20, Orientation: 1, ColorModel: ColorGray, BitDepth: 8}, displayW: 40, displayH: 20},
+		{name: "jpeg rotated", data: withExifOrientation(encodeJPEG(t, 40, 20), 6), want: Info{Format: FormatJPEG, Width: 40, Height: 20, Orientation: 6, ColorModel: ColorGray, BitDepth: 8}, displayW: 20, displayH: 40},
+		{name: "png", data: pngBuf.Bytes(), want: Info{Format: FormatPNG, Width: 30, Height: 20, Orientation: 1, ColorModel: ColorGray, BitDepth: 8}, displayW: 30, displayH: 20},
+		{name: "tiff", data: tiffHeader(map[uint16]uint16{tagImageWidth: 10, tagImageLength: 50, tagOrientation: 8}), want: Info{Format: FormatTIFF, Width: 10, Height: 50, Orientation: 8}, displayW: 50, displayH: 10},
+		{name: "unsupported", data: []byte("%PDF-1.7\n"), wantErr: ErrUnsupported},
+		{name: "truncated jpeg", data: encodeJPEG(t, 40, 20)[:20], wantAnyErr: true},
+		{name: "png with oversized iccp chunk", data: append(append(append([]byte{}, pngBuf.Bytes()[:33]...), "\xff\xff\xff\xf0iCCP"...), pngBuf.Bytes()[33:]...), wantAnyErr: true},
+		{name: "tiff without dimensions", data: tiffHeader(map[uint16]uint16{tagOrientation: 1}), wantAnyErr: true},
+	}
+
+	for _, tc := range tests {
+		info, err := Read(bytes.NewReader(tc.data), int64(len(tc.data)))
+		if tc.wantErr != nil || tc.wantAnyErr {
+			if err == nil || (tc.wantErr != nil && !errors.Is(err, tc.wantErr)) {
+				t.Errorf("%s: got unexpected error, want=%v, got=%v", tc.name, tc.wantErr, err)
+			}
+
+			continue
+		}
+
+		if err != nil {
+			t.Fatalf("%s: got unexpected error, want=nil, got=%v", tc.name, err)
+		}
+
+		if *info != tc.want {
+			t.Errorf("%s: got unexpected info, want=%+v, got=%+v", tc.name, tc.want, *info)
+		}
+
+		if w, h := info.DisplaySize(); w != tc.displayW || h != tc.displayH {
+			t.Errorf("%s: got unexpected display size, want=%dx%d, got=%dx%d", tc.name, tc.displayW, tc.displayH, w, h)
+		}
+	}
+}
+
+// iccProfile returns a minimal ICC profile holding only the description tag.
+func iccProfile(desc []byte) []byte {
+	profile := make([]byte, iccHeaderSize, iccHeaderSize+16+len(desc))
+	profile = append(profile, 0, 0, 0, 1, 'd', 'e', 's', 'c')
+	profile = append(profile, be32(uint32(iccHeaderSize+16))...)
+	profile = append(profile, be32(uint32(len(desc)))...)
+
+	return append(profile, desc...)
+}
+
+// descV2 returns an ICC v2 textDescriptionType tag.
+func descV2(text string) []byte {
+	tag := append([]byte("desc\x00\x00\x00\x00"), be32(uint32(len(text)+1))...)
+	return append(append(tag, text...), 0)
+}
+
+// descV4 returns an ICC v4 multiLocalizedUnicodeType tag with a German and
+// an English record.
+func descV4(de, en string) []byte {
+	tag := append([]byte("mluc\x00\x00\x00\x00"), be32(2)...)
+	tag = append(tag, be32(12)...)
+
+	text := make([]byte, 0)
+	for _, r := range []struct{ lang, s string }{{"de", de}, {"en", en}} {
+		utf := make([]byte, 0)
+		for _, c := range r.s {
+			utf = append(utf, 0, byte(c))
+		}
+
+		tag = append(tag, r.lang+"DE"...)
+		tag = append(tag, be32(uint32(len(utf)))...)
+		tag = append(tag, be32(uint32(16+24+len(text)))...)
+		text = append(text, utf...)
+	}
+
+	return append(tag, text...)
+}
+
+func be32(v uint32) []byte {
+	b := make([]byte, 4)
+	binary.BigEndian.PutUint32(b, v)
+
+	return b
+}
+
+// withICCSegments inserts the profile after the SOI marker of the JPEG, split
+// over two APP2 segments.
+func withICCSegments(jpg, profile []byte) []byte {
+	out := append([]byte{}, jpg[:2]...)
+	half := len(profile) / 2
+
+	for i, chunk := range [][]byte{profile[:half], profile[half:]} {
+		data := append(append([]byte("ICC_PROFILE\x00"), byte(i+1), 2), chunk...)
+		out = append(out, 0xff, markerAPP2, byte((len(data)+2)>>8), byte(len(data)+2))
+		out = append(out, data...)
+	}
+
+	return append(out, jpg[2:]...)
+}
+
+// withPNGChunk inserts a chunk after the IHDR chunk of the PNG.
+func withPNGChunk(pngData []byte, chunkType string, data []byte) []byte {
+	chunk := append(be32(uint32(len(data))), chunkType...)
+	chunk = append(chunk, data...)
+	chunk = append(chunk, be32(crc32.ChecksumIEEE(chunk[4:]))...)
+
+	return append(append(append([]byte{}, pngData[:33]...), chunk...), pngData[33:]...)
+}
+
+func TestReadColor(t *testing.T) {
+	var rgbJPEG bytes.Buffer
+	if err := jpeg.Encode(&rgbJPEG, image.NewRGBA(image.Rect(0, 0, 4, 4)), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	var rgbaPNG bytes.Buffer
+	if err := png.Encode(&rgbaPNG, image.NewNRGBA(image.Rect(0, 0, 4, 4))); err != nil {
+		t.Fatal(err)
+	}
+
+	var compressed bytes.Buffer
+	zw := zlib.NewWriter(&compressed)
+	zw.Write(iccProfile(descV4("Gestrichen FOGRA39", "Coated FOGRA39")))
+	zw.Close()
+
+	// A CMYK TIFF with 8 bits in each of its 4 samples and an ICC profile,
+	// both stored after the directory.
+	profile := iccProfile(descV2("U.S. Web Coated (SWOP) v2"))
+	cmykTIFF := bytes.NewBuffer([]byte("MM\x00*\x00\x00\x00\x08\x00\x05"))
+	dataOffset := uint32(8 + 2 + 5*12)
+	for _, entry := range [][]uint32{
+		{tagImageWidth, typeShort, 1, 10 << 16},
+		{tagImageLength, typeShort, 1, 20 << 16},
+		{tagBitsPerSample, typeShort, 4, dataOffset},
+		{tagPhotometric, typeShort, 1, 5 << 16},
+		{tagICCProfile, typeUndefined, uint32(len(profile)), dataOffset + 8},
+	} {
+		binary.Write(cmykTIFF, binary.BigEndian, []uint16{uint16(entry[0]), uint16(entry[1])})
+		binary.Write(cmykTIFF, binary.BigEndian, entry[2:])
+	}
+	binary.Write(cmykTIFF, binary.BigEndian, []uint16{8, 8, 8, 8})
+	cmykTIFF.Write(profile)
+
+	tests := []struct {
+		name string
+		data []byte
+		want Info
+	}{
+		{
+			name: "jpeg with split icc profile",
+			data: withICCSegments(rgbJPEG.Bytes(), iccProfile(descV2("sRGB IEC61966-2.1"))),
+			want: Info{Format: FormatJPEG, Width: 4, Height: 4, Orientation: 1, ColorModel: ColorRGB, BitDepth: 8, ICCProfile: "sRGB IEC61966-2.1"},
+		},
+		{
+			name: "png with icc v4 profile",
+			data: withPNGChunk(rgbaPNG.Bytes(), "iCCP", append([]byte("icc\x00\x00"), compressed.Bytes()...)),
+			want: Info{Format: FormatPNG, Width: 4, Height: 4, Orientation: 1, ColorModel: ColorRGB, BitDepth: 8, ICCProfile: "Coated FOGRA39"},
+		},
+		{
+			name: "png with srgb chunk",
+			data: withPNGChunk(rgbaPNG.Bytes(), "sRGB", []byte{0}),
+			want: Info{Format: FormatPNG, Width: 4, Height: 4, Orientation: 1, ColorModel: ColorRGB, BitDepth: 8, ICCProfile: srgbDescription},
+		},
+		{
+			name: "cmyk tiff",
+			data: cmykTIFF.Bytes(),
+			want: Info{Format: FormatTIFF, Width: 10, Height: 20, Orientation: 1, ColorModel: ColorCMYK, BitDepth: 8, ICCProfile: "U.S. Web Coated (SWOP) v2"},
+		},
+	}
+
+	for _, tc := range tests {
+		info, err := Read(bytes.NewReader(tc.data), int64(len(tc.data)))
+		if err != nil {
+			t.Fatalf("%s: got unexpected error, want=nil, got=%v", tc.name, err)
+		}
+
+		if *info != tc.want {
+			t.Errorf("%s: got unexpected info, want=%+v, got=%+v", tc.name, tc.want, *info)
+		}
+	}
+}
@@ -0,0 +1,392 @@
+// Copyright (2023 -- present) Shahruk Hossain <shahruk10@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//		 http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// ==============================================================================
+
+package imageinfo
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Metadata holds the text properties embedded in an image. EXIF properties are
+// keyed by tag name, e.g. "exif:ImageDescription", and XMP properties by their
+// prefixed name in the packet, e.g. "xmp:photoshop:Instructions".
+type Metadata map[string]string
+
+// exifTags names the EXIF text tags which are read.
+var exifTags = map[uint16]string{
+	270:   "ImageDescription",
+	271:   "Make",
+	272:   "Model",
+	305:   "Software",
+	315:   "Artist",
+	33432: "Copyright",
+	37510: "UserComment",
+	42016: "ImageUniqueID",
+}
+
+// More TIFF tags and field types.
+const (
+	tagXMP         = 700
+	tagExifIFD     = 34665
+	tagUserComment = 37510
+
+	typeByte  = 1
+	typeASCII = 2
+)
+
+var xmpHeader = []byte("http://ns.adobe.com/xap/1.0/\x00")
+
+// maxMetadataSize limits the size of metadata values and XMP packets read.
+const maxMetadataSize = 1 << 20
+
+// ReadMetadataFile reads the metadata embedded in the image file at path.
+func ReadMetadataFile(path string) (Metadata, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	return ReadMetadata(f, info.Size())
+}
+
+// ReadMetadata reads the EXIF and XMP metadata embedded in the image of the
+// given size.
+func ReadMetadata(r io.ReaderAt, size int64) (Metadata, error) {
+	md := make(Metadata)
+
+	var err error
+	switch Sniff(r) {
+	case FormatJPEG:
+		err = readJPEGMetadata(bufio.NewReader(io.NewSectionReader(r, 0, size)), md)
+	case FormatPNG:
+		err = readPNGMetadata(r, size, md)
+	case FormatTIFF:
+		err = readExif(r, md)
+	default:
+		return nil, ErrUnsupported
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("read metadata: %w", err)
+	}
+
+	return md, nil
+}
+
+func readJPEGMetadata(r *bufio.Reader, md Metadata) error {
+	if _, err := r.Discard(2); err != nil {
+		return err
+	}
+
+	for {
+		marker, err := nextMarker(r)
+		if err != nil {
+			return err
+		}
+
+		if marker == markerTEM || (marker >= markerRST0 && marker <= markerRST7) || marker == markerSOI {
+			continue
+		}
+
+		// Metadata segments come before the image data.
+		if marker == markerSOS || marker == markerEOI {
+			return nil
+		}
+
+		var length uint16
+		if err := binary.Read(r, binary.BigEndian, &length); err != nil {
+			return err
+		}
+
+		if length < 2 {
+			return fmt.Errorf("invalid segment length %d", length)
+		}
+
+		if marker != markerAPP1 {
+			if _, err := r.Discard(int(length) - 2); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		segment := make([]byte, length-2)
+		if _, err := io.ReadFull(r, segment); err != nil {
+			return err
+		}
+
+		switch {
+		case bytes.HasPrefix(segment, exifHeader):
+			if err := readExif(bytes.NewReader(segment[len(exifHeader):]), md); err != nil {
+				return err
+			}
+		case bytes.HasPrefix(segment, xmpHeader):
+			if err := readXMP(segment[len(xmpHeader):], md); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// pngXMPKeyword is the keyword of the iTXt chunk holding an XMP packet.
+const pngXMPKeyword = "XML:com.adobe.xmp"
+
+func readPNGMetadata(r io.ReaderAt, size int64, md Metadata) error {
+	// Text chunks may come after the image data, so every chunk is visited.
+	header := make([]byte, 8)
+	for offset := int64(len(pngSignature)); offset+8 <= size; {
+		if _, err := r.ReadAt(header, offset); err != nil {
+			return err
+		}
+
+		length, chunkType := int64(binary.BigEndian.Uint32(header[:4])), string(header[4:8])
+		if chunkType == "IEND" {
+			return nil
+		}
+
+		if (chunkType == "eXIf" || chunkType == "iTXt") && length <= maxMetadataSize {
+			data := make([]byte, length)
+			if _, err := r.ReadAt(data, offset+8); err != nil {
+				return err
+			}
+
+			switch chunkType {
+			case "eXIf":
+				if err := readExif(bytes.NewReader(data), md); err != nil {
+					return err
+				}
+			case "iTXt":
+				if packet, ok := pngXMPPacket(data); ok {
+					if err := readXMP(packet, md); err != nil {
+						return err
+					}
+				}
+			}
+		}
+
+		offset += 8 + length + 4
+	}
+
+	return nil
+}
+
+// pngXMPPacket returns the XMP packet held in the iTXt chunk data, if it holds
+// one.
+func pngXMPPacket(data []byte) ([]byte, bool) {
+	// The keyword is followed by a null byte, the compression flag and
+	// method, and null terminated language tag and translated keyword.
+	keyword, rest, ok := bytes.Cut(data, []byte{0})
+	if !ok || string(keyword) != pngXMPKeyword || len(rest) < 2 {
+		return nil, false
+	}
+
+	compressed := rest[0] == 1
+	rest = rest[2:]
+
+	for i := 0; i < 2; i++ {
+		if _, rest, ok = bytes.Cut(rest, []byte{0}); !ok {
+			return nil, false
+		}
+	}
+
+	if !compressed {
+		return rest, true
+	}
+
+	zr, err := zlib.NewReader(bytes.NewReader(rest))
+	if err != nil {
+		return nil, false
+	}
+
+	packet, err := io.ReadAll(io.LimitReader(zr, maxMetadataSize))
+	if err != nil {
+		return nil, false
+	}
+
+	return packet, true
+}
+
+// readExif reads the text tags of the first image file directory and the EXIF
+// directory of a TIFF file, or of the EXIF data of a JPEG or PNG, along with
+// any XMP packet it holds.
+func readExif(r io.ReaderAt, md Metadata) error {
+	header := make([]byte, 8)
+	if _, err := r.ReadAt(header, 0); err != nil {
+		return err
+	}
+
+	var order binary.ByteOrder
+	switch string(header[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return fmt.Errorf("invalid byte order %q", header[:2])
+	}
+
+	first := int64(order.Uint32(header[4:8]))
+	offsets := []int64{first}
+
+	for len(offsets) > 0 {
+		offset := offsets[0]
+		offsets = offsets[1:]
+
+		count := make([]byte, 2)
+		if _, err := r.ReadAt(count, offset); err != nil {
+			return err
+		}
+
+		entries := make([]byte, tiffEntrySize*int(order.Uint16(count)))
+		if _, err := r.ReadAt(entries, offset+2); err != nil {
+			return err
+		}
+
+		for i := 0; i < len(entries); i += tiffEntrySize {
+			entry := entries[i : i+tiffEntrySize]
+			tag, fieldType, n := order.Uint16(entry[0:2]), order.Uint16(entry[2:4]), int64(order.Uint32(entry[4:8]))
+
+			// The EXIF directory is only followed from the first directory.
+			if tag == tagExifIFD && fieldType == typeLong && offset == first {
+				offsets = append(offsets, int64(order.Uint32(entry[8:12])))
+				continue
+			}
+
+			name, isText := exifTags[tag]
+			isXMP := tag == tagXMP && (fieldType == typeByte || fieldType == typeUndefined)
+			if (!isText && !isXMP) || n > maxMetadataSize {
+				continue
+			}
+
+			// Values which don't fit in the entry are stored at an offset.
+			var data []byte
+			if n <= 4 {
+				data = entry[8 : 8+n]
+			} else {
+				data = make([]byte, n)
+				if _, err := r.ReadAt(data, int64(order.Uint32(entry[8:12]))); err != nil {
+					continue
+				}
+			}
+
+			switch {
+			case isXMP:
+				if err := readXMP(data, md); err != nil {
+					return err
+				}
+			case fieldType == typeASCII:
+				setMetadata(md, "exif:"+name, string(bytes.TrimRight(data, "\x00")))
+			case fieldType == typeUndefined && tag == tagUserComment:
+				setMetadata(md, "exif:"+name, userComment(data, order))
+			}
+		}
+	}
+
+	return nil
+}
+
+// userComment decodes an EXIF user comment, which starts with an 8 byte code
+// naming its character set.
+func userComment(data []byte, order binary.ByteOrder) string {
+	if len(data) < 8 {
+		return ""
+	}
+
+	code, text := string(bytes.TrimRight(data[:8], "\x00 ")), data[8:]
+	if code != "UNICODE" {
+		return string(bytes.TrimRight(text, "\x00 "))
+	}
+
+	runes := make([]rune, 0, len(text)/2)
+	for i := 0; i+1 < len(text); i += 2 {
+		runes = append(runes, rune(order.Uint16(text[i:])))
+	}
+
+	return strings.TrimRight(string(runes), "\x00 ")
+}
+
+// XMP namespaces whose elements only structure the packet.
+var xmpStructural = map[string]bool{"x": true, "rdf": true, "xml": true, "xmlns": true}
+
+// readXMP reads the simple properties of an XMP packet. Properties may be
+// written as elements or as attributes of rdf:Description; for arrays and
+// language alternatives, the first item is used.
+func readXMP(packet []byte, md Metadata) error {
+	d := xml.NewDecoder(bytes.NewReader(packet))
+	d.Strict = false
+
+	// Names are kept with the prefixes used in the packet, rather than
+	// resolved to namespace URIs, so that they can be configured as written.
+	var stack []xml.Name
+
+	for {
+		tok, err := d.RawToken()
+		if errors.Is(err, io.EOF) {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("read xmp: %w", err)
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			stack = append(stack, t.Name)
+
+			for _, attr := range t.Attr {
+				if attr.Name.Space != "" && !xmpStructural[attr.Name.Space] {
+					setMetadata(md, "xmp:"+attr.Name.Space+":"+attr.Name.Local, attr.Value)
+				}
+			}
+
+		case xml.EndElement:
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+
+		case xml.CharData:
+			// Text belongs to the innermost property element.
+			for i := len(stack) - 1; i >= 0; i-- {
+				if name := stack[i]; name.Space != "" && !xmpStructural[name.Space] {
+					setMetadata(md, "xmp:"+name.Space+":"+name.Local, string(t))
+					break
+				}
+			}
+		}
+	}
+}
+
+// setMetadata sets the property unless it is blank or already set.
+func setMetadata(md Metadata, key, value string) {
+	value = strings.TrimSpace(value)
+	if _, ok := md[key]; ok || value == "" {
+		return
+	}
+
+	md[key] = value
+}
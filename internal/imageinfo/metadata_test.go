@@ -0,0 +1,125 @@
+package imageinfo
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/png"
+	"testing"
+)
+
+const testXMP = `<?xpacket begin="" id="W5M0MpCehiHzreSzNTczkc9d"?>
+<x:xmpmeta xmlns:x="adobe:ns:meta/">
+ <rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#">
+  <rdf:Description rdf:about="" xmlns:ws="http://example.com/ws/1.0/" xmlns:dc="http://purl.org/dc/elements/1.1/"
+    ws:FrameSize="11x14">
+   <ws:FrameType>fr</ws:FrameType>
+   <dc:title><rdf:Alt><rdf:li xml:lang="x-default">Sunset</rdf:li><rdf:li xml:lang="de">Sonnenuntergang</rdf:li></rdf:Alt></dc:title>
+  </rdf:Description>
+ </rdf:RDF>
+</x:xmpmeta>
+<?xpacket end="w"?>`
+
+// exifText returns little endian EXIF data with an ASCII image description
+// and a user comment in the EXIF directory.
+func exifText(description, comment string) []byte {
+	desc := append([]byte(description), 0)
+	userComment := append([]byte("ASCII\x00\x00\x00"), comment...)
+
+	buf := bytes.NewBuffer([]byte("II*\x00"))
+	binary.Write(buf, binary.LittleEndian, uint32(8))
+
+	// The first directory has two entries, followed by the EXIF directory
+	// with one, and then the values.
+	exifIFD := uint32(8 + 2 + 2*12 + 4)
+	descOffset := exifIFD + 2 + 12 + 4
+	commentOffset := descOffset + uint32(len(desc))
+
+	binary.Write(buf, binary.LittleEndian, uint16(2))
+	binary.Write(buf, binary.LittleEndian, []uint16{270, typeASCII})
+	binary.Write(buf, binary.LittleEndian, []uint32{uint32(len(desc)), descOffset})
+	binary.Write(buf, binary.LittleEndian, []uint16{tagExifIFD, typeLong})
+	binary.Write(buf, binary.LittleEndian, []uint32{1, exifIFD})
+	binary.Write(buf, binary.LittleEndian, uint32(0))
+
+	binary.Write(buf, binary.LittleEndian, uint16(1))
+	binary.Write(buf, binary.LittleEndian, []uint16{tagUserComment, typeUndefined})
+	binary.Write(buf, binary.LittleEndian, []uint32{uint32(len(userComment)), commentOffset})
+	binary.Write(buf, binary.LittleEndian, uint32(0))
+
+	buf.Write(desc)
+	buf.Write(userComment)
+
+	return buf.Bytes()
+}
+
+// withAPP1 inserts an APP1 segment with the data after the SOI marker of the
+// JPEG.
+func withAPP1(jpg, data []byte) []byte {
+	segment := []byte{0xff, markerAPP1, 0, 0}
+	binary.BigEndian.PutUint16(segment[2:], uint16(len(data)+2))
+	segment = append(segment, data...)
+
+	return append(append(append([]byte{}, jpg[:2]...), segment...), jpg[2:]...)
+}
+
+func TestReadMetadata(t *testing.T) {
+	var pngBuf bytes.Buffer
+	if err := png.Encode(&pngBuf, image.NewGray(image.Rect(0, 0, 4, 4))); err != nil {
+		t.Fatal(err)
+	}
+
+	itxt := append([]byte(pngXMPKeyword+"\x00\x00\x00\x00\x00"), testXMP...)
+
+	jpg := encodeJPEG(t, 4, 4)
+	jpg = withAPP1(jpg, append([]byte("Exif\x00\x00"), exifText("order 42", "wood 8x10")...))
+	jpg = withAPP1(jpg, append(append([]byte{}, xmpHeader...), testXMP...))
+
+	tests := []struct {
+		name string
+		data []byte
+		want Metadata
+	}{
+		{
+			name: "jpeg",
+			data: jpg,
+			want: Metadata{
+				"exif:ImageDescription": "order 42",
+				"exif:UserComment":      "wood 8x10",
+				"xmp:ws:FrameSize":      "11x14",
+				"xmp:ws:FrameType":      "fr",
+				"xmp:dc:title":          "Sunset",
+			},
+		},
+		{
+			name: "png",
+			data: withPNGChunk(pngBuf.Bytes(), "iTXt", itxt),
+			want: Metadata{"xmp:ws:FrameSize": "11x14", "xmp:ws:FrameType": "fr", "xmp:dc:title": "Sunset"},
+		},
+		{
+			name: "tiff",
+			data: exifText("order 42", "wood 8x10"),
+			want: Metadata{"exif:ImageDescription": "order 42", "exif:UserComment": "wood 8x10"},
+		},
+		{name: "none", data: pngBuf.Bytes(), want: Metadata{}},
+	}
+
+	for _, tc := range tests {
+		md, err := ReadMetadata(bytes.NewReader(tc.data), int64(len(tc.data)))
+		if err != nil {
+			t.Errorf("%s: got unexpected error, want=nil, got=%v", tc.name, err)
+			continue
+		}
+
+		if len(md) != len(tc.want) {
+			t.Errorf("%s: got unexpected metadata, want=%v, got=%v", tc.name, tc.want, md)
+			continue
+		}
+
+		for k, v := range tc.want {
+			if md[k] != v {
+				t.Errorf("%s: got unexpected %s, want=%q, got=%q", tc.name, k, v, md[k])
+			}
+		}
+	}
+}
@@ -0,0 +1,295 @@
+// Copyright (2023 -- present) Shahruk Hossain <shahruk10@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//		 http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// ==============================================================================
+
+package imageinfo
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrCorrupt is returned for images which are truncated or damaged.
+var ErrCorrupt = errors.New("corrupt image")
+
+// extensionFormats maps file extensions to the image format they imply.
+var extensionFormats = map[string]string{
+	".jpg":  FormatJPEG,
+	".jpeg": FormatJPEG,
+	".png":  FormatPNG,
+	".tif":  FormatTIFF,
+	".tiff": FormatTIFF,
+}
+
+// FormatForExtension returns the image format implied by the extension of the
+// file name, or an empty string if it isn't an image extension.
+func FormatForExtension(name string) string {
+	return extensionFormats[strings.ToLower(filepath.Ext(name))]
+}
+
+// Sniff returns the format of the image from its magic bytes, or an empty
+// string if it isn't a JPEG, PNG or TIFF image.
+func Sniff(r io.ReaderAt) string {
+	magic := make([]byte, 8)
+	if n, _ := r.ReadAt(magic, 0); n < len(magic) {
+		magic = magic[:n]
+	}
+
+	switch {
+	case bytes.HasPrefix(magic, []byte{0xff, 0xd8, 0xff}):
+		return FormatJPEG
+	case bytes.Equal(magic, pngSignature):
+		return FormatPNG
+	case bytes.HasPrefix(magic, []byte("II*\x00")) || bytes.HasPrefix(magic, []byte("MM\x00*")):
+		return FormatTIFF
+	default:
+		return ""
+	}
+}
+
+// VerifyFile checks the structure of the image file at path; see Verify.
+func VerifyFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return "", err
+	}
+
+	return Verify(f, info.Size())
+}
+
+// Verify returns the format of the image after checking that its structure is
+// intact: that a JPEG ends with an EOI marker, that every PNG chunk has a valid
+// CRC and the last is IEND, and that every offset in a TIFF file lies within
+// it. Errors for damaged images wrap ErrCorrupt; ErrUnsupported is returned for
+// files which aren't images at all.
+func Verify(r io.ReaderAt, size int64) (string, error) {
+	format := Sniff(r)
+
+	var err error
+	switch format {
+	case FormatJPEG:
+		err = verifyJPEG(r, size)
+	case FormatPNG:
+		err = verifyPNG(r, size)
+	case FormatTIFF:
+		err = verifyTIFF(r, size)
+	default:
+		return "", ErrUnsupported
+	}
+
+	if err != nil {
+		return format, fmt.Errorf("%w: %s: %v", ErrCorrupt, format, err)
+	}
+
+	return format, nil
+}
+
+// jpegTrailerSize is how much of the end of a JPEG is searched for the EOI
+// marker, since some writers pad files after it.
+const jpegTrailerSize = 4096
+
+func verifyJPEG(r io.ReaderAt, size int64) error {
+	if _, err := Read(r, size); err != nil {
+		return err
+	}
+
+	n := int64(jpegTrailerSize)
+	if size < n {
+		n = size
+	}
+
+	trailer := make([]byte, n)
+	if _, err := r.ReadAt(trailer, size-n); err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+
+	trailer = bytes.TrimRight(trailer, "\x00")
+	if !bytes.HasSuffix(trailer, []byte{0xff, markerEOI}) {
+		return fmt.Errorf("missing EOI marker, file is truncated")
+	}
+
+	return nil
+}
+
+func verifyPNG(r io.ReaderAt, size int64) error {
+	header := make([]byte, 8)
+
+	for offset := int64(len(pngSignature)); ; {
+		if offset+12 > size {
+			return fmt.Errorf("missing IEND chunk, file is truncated")
+		}
+
+		if _, err := r.ReadAt(header, offset); err != nil {
+			return err
+		}
+
+		length, chunkType := int64(binary.BigEndian.Uint32(header[:4])), string(header[4:8])
+		if offset+8+length+4 > size {
+			return fmt.Errorf("%s chunk at %d runs past the end of the file, file is truncated", chunkType, offset)
+		}
+
+		// The CRC covers the chunk type and data.
+		crc := crc32.NewIEEE()
+		if _, err := io.Copy(crc, io.NewSectionReader(r, offset+4, 4+length)); err != nil {
+			return err
+		}
+
+		want := make([]byte, 4)
+		if _, err := r.ReadAt(want, offset+8+length); err != nil {
+			return err
+		}
+
+		if crc.Sum32() != binary.BigEndian.Uint32(want) {
+			return fmt.Errorf("%s chunk at %d has a bad CRC", chunkType, offset)
+		}
+
+		if chunkType == "IEND" {
+			return nil
+		}
+
+		offset += 8 + length + 4
+	}
+}
+
+// TIFF tags pointing at image data.
+const (
+	tagStripOffsets    = 273
+	tagStripByteCounts = 279
+	tagTileOffsets     = 324
+	tagTileByteCounts  = 325
+)
+
+// tiffTypeSizes holds the size in bytes of each TIFF field type.
+var tiffTypeSizes = map[uint16]int64{1: 1, 2: 1, 3: 2, 4: 4, 5: 8, 6: 1, 7: 1, 8: 2, 9: 4, 10: 8, 11: 4, 12: 8, 13: 4}
+
+// maxTIFFDirectories guards against image file directories which form a loop.
+const maxTIFFDirectories = 1024
+
+func verifyTIFF(r io.ReaderAt, size int64) error {
+	header := make([]byte, 8)
+	if _, err := r.ReadAt(header, 0); err != nil {
+		return err
+	}
+
+	order := binary.ByteOrder(binary.LittleEndian)
+	if string(header[:2]) == "MM" {
+		order = binary.BigEndian
+	}
+
+	offset := int64(order.Uint32(header[4:8]))
+
+	for i := 0; offset != 0; i++ {
+		if i == maxTIFFDirectories {
+			return fmt.Errorf("too many image file directories")
+		}
+
+		if offset+2 > size {
+			return fmt.Errorf("image file directory at %d is past the end of the file", offset)
+		}
+
+		count := make([]byte, 2)
+		if _, err := r.ReadAt(count, offset); err != nil {
+			return err
+		}
+
+		n := int64(order.Uint16(count))
+		if offset+2+n*tiffEntrySize+4 > size {
+			return fmt.Errorf("image file directory at %d runs past the end of the file", offset)
+		}
+
+		entries := make([]byte, n*tiffEntrySize+4)
+		if _, err := r.ReadAt(entries, offset+2); err != nil {
+			return err
+		}
+
+		if err := verifyTIFFDirectory(r, size, order, entries[:n*tiffEntrySize]); err != nil {
+			return err
+		}
+
+		offset = int64(order.Uint32(entries[n*tiffEntrySize:]))
+	}
+
+	return nil
+}
+
+func verifyTIFFDirectory(r io.ReaderAt, size int64, order binary.ByteOrder, entries []byte) error {
+	values := make(map[uint16][]int64)
+
+	for i := 0; i < len(entries); i += tiffEntrySize {
+		entry := entries[i : i+tiffEntrySize]
+		tag, fieldType, n := order.Uint16(entry[0:2]), order.Uint16(entry[2:4]), int64(order.Uint32(entry[4:8]))
+
+		typeSize, ok := tiffTypeSizes[fieldType]
+		if !ok {
+			continue
+		}
+
+		// Values which don't fit in the entry are stored at an offset.
+		data := entry[8:12]
+		if n*typeSize > 4 {
+			offset := int64(order.Uint32(entry[8:12]))
+			if offset+n*typeSize > size {
+				return fmt.Errorf("tag %d data at %d runs past the end of the file", tag, offset)
+			}
+
+			if tag != tagStripOffsets && tag != tagStripByteCounts && tag != tagTileOffsets && tag != tagTileByteCounts {
+				continue
+			}
+
+			data = make([]byte, n*typeSize)
+			if _, err := r.ReadAt(data, offset); err != nil {
+				return err
+			}
+		}
+
+		switch tag {
+		case tagStripOffsets, tagStripByteCounts, tagTileOffsets, tagTileByteCounts:
+			if fieldType != typeShort && fieldType != typeLong {
+				return fmt.Errorf("tag %d has invalid type %d", tag, fieldType)
+			}
+
+			for j := int64(0); j < n; j++ {
+				if fieldType == typeShort {
+					values[tag] = append(values[tag], int64(order.Uint16(data[2*j:])))
+				} else {
+					values[tag] = append(values[tag], int64(order.Uint32(data[4*j:])))
+				}
+			}
+		}
+	}
+
+	for _, pair := range [][2]uint16{{tagStripOffsets, tagStripByteCounts}, {tagTileOffsets, tagTileByteCounts}} {
+		offsets, counts := values[pair[0]], values[pair[1]]
+		for j := 0; j < len(offsets) && j < len(counts); j++ {
+			if offsets[j]+counts[j] > size {
+				return fmt.Errorf("image data at %d runs past the end of the file, file is truncated", offsets[j])
+			}
+		}
+	}
+
+	return nil
+}
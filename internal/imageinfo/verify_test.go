@@ -0,0 +1,75 @@
+package imageinfo
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/png"
+	"testing"
+)
+
+// stripTIFF returns a little endian TIFF with a single strip of image data.
+func stripTIFF(data []byte) []byte {
+	buf := bytes.NewBuffer([]byte("II*\x00"))
+	binary.Write(buf, binary.LittleEndian, uint32(8))
+	binary.Write(buf, binary.LittleEndian, uint16(4))
+
+	dataOffset := uint32(8 + 2 + 4*12 + 4)
+	for _, entry := range [][]uint32{
+		{tagImageWidth, typeLong, 1, 2},
+		{tagImageLength, typeLong, 1, 2},
+		{tagStripOffsets, typeLong, 1, dataOffset},
+		{tagStripByteCounts, typeLong, 1, 4},
+	} {
+		binary.Write(buf, binary.LittleEndian, []uint16{uint16(entry[0]), uint16(entry[1])})
+		binary.Write(buf, binary.LittleEndian, entry[2:])
+	}
+
+	binary.Write(buf, binary.LittleEndian, uint32(0))
+	buf.Write(data)
+
+	return buf.Bytes()
+}
+
+func TestVerify(t *testing.T) {
+	jpg := encodeJPEG(t, 16, 16)
+
+	var pngBuf bytes.Buffer
+	if err := png.Encode(&pngBuf, image.NewGray(image.Rect(0, 0, 16, 16))); err != nil {
+		t.Fatal(err)
+	}
+
+	badCRC := append([]byte{}, pngBuf.Bytes()...)
+	badCRC[30] ^= 0xff
+
+	tiff := stripTIFF([]byte{1, 2, 3, 4})
+
+	tests := []struct {
+		name    string
+		data    []byte
+		format  string
+		wantErr error
+	}{
+		{name: "jpeg", data: jpg, format: FormatJPEG},
+		{name: "jpeg padded", data: append(append([]byte{}, jpg...), 0, 0, 0), format: FormatJPEG},
+		{name: "jpeg truncated", data: jpg[:len(jpg)-100], format: FormatJPEG, wantErr: ErrCorrupt},
+		{name: "png", data: pngBuf.Bytes(), format: FormatPNG},
+		{name: "png truncated", data: pngBuf.Bytes()[:pngBuf.Len()-12], format: FormatPNG, wantErr: ErrCorrupt},
+		{name: "png bad crc", data: badCRC, format: FormatPNG, wantErr: ErrCorrupt},
+		{name: "tiff", data: tiff, format: FormatTIFF},
+		{name: "tiff truncated", data: tiff[:len(tiff)-2], format: FormatTIFF, wantErr: ErrCorrupt},
+		{name: "zeros", data: make([]byte, 64), wantErr: ErrUnsupported},
+	}
+
+	for _, tc := range tests {
+		format, err := Verify(bytes.NewReader(tc.data), int64(len(tc.data)))
+		if !errors.Is(err, tc.wantErr) || (tc.wantErr == nil && err != nil) {
+			t.Errorf("%s: got unexpected error, want=%v, got=%v", tc.name, tc.wantErr, err)
+		}
+
+		if format != tc.format {
+			t.Errorf("%s: got unexpected format, want=%q, got=%q", tc.name, tc.format, format)
+		}
+	}
+}
@@ -0,0 +1,190 @@
+// Copyright (2023 -- present) Shahruk Hossain <shahruk10@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//		 http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// ==============================================================================
+
+package notify
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Aggregator groups notifications by verdict and folder. The first
+// notification of a group is delivered right away, and any more arriving
+// within the window are held back until none more arrive, and then delivered
+// on their own or, if there are several, as a single summary about the
+// folder. A steady stream of notifications is still delivered once the group
+// has been open for maxWindows windows.
+type Aggregator struct {
+	logger   *logrus.Logger
+	next     Notifier
+	window   time.Duration
+	maxItems int
+	clock    clock
+
+	mu     sync.Mutex
+	groups map[string]*group
+}
+
+// maxWindows bounds how long a group is held open by notifications arriving
+// within the window of each other.
+const maxWindows = 10
+
+type group struct {
+	folder   string
+	pending  []Notification
+	started  time.Time
+	deadline time.Time
+	timer    timer
+}
+
+// clock lets tests control the passing of time.
+type clock interface {
+	Now() time.Time
+	AfterFunc(d time.Duration, f func()) timer
+}
+
+type timer interface {
+	Reset(d time.Duration) bool
+	Stop() bool
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) AfterFunc(d time.Duration, f func()) timer {
+	return time.AfterFunc(d, f)
+}
+
+// NewAggregator wraps next, listing at most maxItems notifications in each
+// summary.
+func NewAggregator(logger *logrus.Logger, next Notifier, window time.Duration, maxItems int) *Aggregator {
+	return &Aggregator{
+		logger:   logger,
+		next:     next,
+		window:   window,
+		maxItems: maxItems,
+		clock:    realClock{},
+		groups:   make(map[string]*group),
+	}
+}
+
+// Notify delivers the first notification of a group, and holds back the rest;
+// delivery errors for those are logged once the group is delivered.
+func (a *Aggregator) Notify(ctx context.Context, n Notification) error {
+	if !a.open(n) {
+		return nil
+	}
+
+	return a.next.Notify(ctx, n)
+}
+
+// open opens the notification's group and returns true if there is none, in
+// which case the caller delivers the notification. Otherwise it holds the
+// notification back with the group and extends the group's window.
+func (a *Aggregator) open(n Notification) bool {
+	folder := filepath.Dir(n.Path)
+	key := n.Verdict + "\x00" + folder
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	now := a.clock.Now()
+
+	g, ok := a.groups[key]
+	if ok {
+		g.deadline = now.Add(a.window)
+		if limit := g.started.Add(maxWindows * a.window); g.deadline.After(limit) {
+			g.deadline = limit
+		}
+
+		g.timer.Reset(g.deadline.Sub(now))
+		g.pending = append(g.pending, n)
+
+		return false
+	}
+
+	g = &group{folder: folder, started: now, deadline: now.Add(a.window)}
+	g.timer = a.clock.AfterFunc(a.window, func() { a.flush(key, g) })
+	a.groups[key] = g
+
+	return true
+}
+
+func (a *Aggregator) flush(key string, g *group) {
+	a.mu.Lock()
+
+	// The timer may have fired just before the window was extended, in which
+	// case it fires again at the new deadline.
+	if a.groups[key] != g || a.clock.Now().Before(g.deadline) {
+		a.mu.Unlock()
+		return
+	}
+
+	delete(a.groups, key)
+	a.mu.Unlock()
+
+	var err error
+	switch len(g.pending) {
+	case 0:
+		return
+	case 1:
+		err = a.next.Notify(context.Background(), g.pending[0])
+	default:
+		err = a.next.Notify(context.Background(), a.summarize(g))
+	}
+
+	if err != nil {
+		a.logger.Errorf("failed to deliver alert summary: %v", err)
+	}
+}
+
+// summarize returns a summary of the group's held back notifications, logging
+// the ones left out of it.
+func (a *Aggregator) summarize(g *group) Notification {
+	first := g.pending[0]
+
+	summary := Notification{
+		Severity: first.Severity,
+		Title:    fmt.Sprintf("%s (%d more files)", first.Title, len(g.pending)),
+		Fields:   []Field{{Label: "📁 folder", Value: g.folder}},
+		Path:     g.folder,
+		Verdict:  first.Verdict,
+		Time:     a.clock.Now(),
+	}
+
+	for i, n := range g.pending {
+		if a.maxItems > 0 && i == a.maxItems {
+			summary.Fields = append(summary.Fields, Field{Value: fmt.Sprintf("... and %d more, see log", len(g.pending)-i)})
+
+			for _, n := range g.pending[i:] {
+				a.logger.Warnf("%s: %q: %s", n.Title, n.Path, n.SummaryLine())
+			}
+
+			break
+		}
+
+		summary.Fields = append(summary.Fields, Field{Value: n.SummaryLine()})
+	}
+
+	return summary
+}
@@ -0,0 +1,53 @@
+// Copyright (2023 -- present) Shahruk Hossain <shahruk10@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//		 http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// ==============================================================================
+
+package notify
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// errNoChooser is returned by chooseDialog if no selection dialog can be shown
+// on this system.
+var errNoChooser = errors.New("no selection dialog available")
+
+// numberChoices numbers the choices from 1, as they are listed in dialogs.
+func numberChoices(choices []string) []string {
+	numbered := make([]string, len(choices))
+	for i, choice := range choices {
+		numbered[i] = fmt.Sprintf("%d) %s", i+1, choice)
+	}
+
+	return numbered
+}
+
+// parseChoice returns the index of the choice numbered at the start of the
+// output of a selection dialog, or NoChoice if there isn't one of the n.
+func parseChoice(out []byte, n int) int {
+	s := strings.TrimSpace(string(out))
+	if i := strings.IndexByte(s, ')'); i >= 0 {
+		s = s[:i]
+	}
+
+	choice, err := strconv.Atoi(s)
+	if err != nil || choice < 1 || choice > n {
+		return NoChoice
+	}
+
+	return choice - 1
+}
@@ -0,0 +1,41 @@
+// Copyright (2023 -- present) Shahruk Hossain <shahruk10@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//		 http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// ==============================================================================
+
+package notify
+
+import (
+	"fmt"
+	"os/exec"
+)
+
+// chooseScript shows a list dialog with the title, prompt and items given as
+// arguments, printing the chosen item.
+const chooseScript = `on run argv
+	set picked to choose from list (items 3 thru -1 of argv) with title (item 1 of argv) with prompt (item 2 of argv)
+	if picked is false then return ""
+	return item 1 of picked
+end run`
+
+// chooseDialog lists the numbered choices in an AppleScript list dialog.
+func chooseDialog(title, text string, choices []string) (int, error) {
+	args := append([]string{"-e", chooseScript, title, text}, numberChoices(choices)...)
+
+	out, err := exec.Command("osascript", args...).Output()
+	if err != nil {
+		return NoChoice, fmt.Errorf("run osascript: %w", err)
+	}
+
+	return parseChoice(out, len(choices)), nil
+}
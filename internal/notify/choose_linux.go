@@ -0,0 +1,51 @@
+// Copyright (2023 -- present) Shahruk Hossain <shahruk10@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//		 http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// ==============================================================================
+
+package notify
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strconv"
+)
+
+// chooseDialog lists the choices in a zenity radio list dialog.
+func chooseDialog(title, text string, choices []string) (int, error) {
+	zenity, err := exec.LookPath("zenity")
+	if err != nil {
+		return NoChoice, errNoChooser
+	}
+
+	args := []string{
+		"--list", "--radiolist", "--title", title, "--text", text,
+		"--column", "", "--column", "#", "--column", "Choice", "--hide-column", "2", "--print-column", "2",
+	}
+	for i, choice := range choices {
+		args = append(args, "FALSE", strconv.Itoa(i+1), choice)
+	}
+
+	out, err := exec.Command(zenity, args...).Output()
+
+	// zenity exits with status 1 when the dialog is cancelled.
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+		return NoChoice, nil
+	} else if err != nil {
+		return NoChoice, fmt.Errorf("run zenity: %w", err)
+	}
+
+	return parseChoice(out, len(choices)), nil
+}
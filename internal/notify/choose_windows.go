@@ -0,0 +1,54 @@
+// Copyright (2023 -- present) Shahruk Hossain <shahruk10@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//		 http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// ==============================================================================
+
+package notify
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+)
+
+// chooseScript lists the choices, one per line in WATCHER_CHOICES, in a grid
+// view window, printing the number of the chosen one.
+const chooseScript = "$i = 0; " +
+	"$picked = $env:WATCHER_CHOICES -split \"`n\" | ForEach-Object { $i++; [pscustomobject]@{ '#' = $i; Choice = $_ } } | " +
+	"Out-GridView -Title $env:WATCHER_TITLE -OutputMode Single; " +
+	"if ($picked) { $picked.'#' }"
+
+// chooseDialog lists the choices in a PowerShell grid view window. The window
+// only has room for a title, so the text is shown on a single line after it.
+func chooseDialog(title, text string, choices []string) (int, error) {
+	powershell, err := exec.LookPath("powershell")
+	if err != nil {
+		return NoChoice, errNoChooser
+	}
+
+	cmd := exec.Command(powershell, "-NoProfile", "-NonInteractive", "-Command", chooseScript)
+	cmd.Env = append(os.Environ(),
+		"WATCHER_TITLE="+title+": "+strings.Join(strings.Fields(text), " "),
+		"WATCHER_CHOICES="+strings.Join(choices, "\n"),
+	)
+	cmd.SysProcAttr = &syscall.SysProcAttr{HideWindow: true}
+
+	out, err := cmd.Output()
+	if err != nil {
+		return NoChoice, fmt.Errorf("run powershell: %w", err)
+	}
+
+	return parseChoice(out, len(choices)), nil
+}
@@ -0,0 +1,304 @@
+// Copyright (2023 -- present) Shahruk Hossain <shahruk10@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//		 http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// ==============================================================================
+
+package notify
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sqweek/dialog"
+)
+
+// dialogMu ensures only one dialog window is shown at a time.
+var dialogMu sync.Mutex
+
+// Dialog shows notifications in desktop message dialogs. Notify blocks until
+// the dialog is dismissed.
+type Dialog struct {
+	// Mute, if set, is called when the operator chooses to mute alerts about
+	// the file or folder a verdict alert is about for MuteFor. Other
+	// notifications are shown as plain messages.
+	Mute    func(path string, d time.Duration) error
+	MuteFor time.Duration
+
+	// ui shows the dialogs; desktop dialogs if nil.
+	ui dialogUI
+}
+
+// dialogUI shows the dialogs Dialog is made of.
+type dialogUI interface {
+	// message shows an information or error message.
+	message(title, text string, info bool)
+	// yesNo asks a question, returning true if the answer is yes.
+	yesNo(title, text string) bool
+	// choose asks for one of the choices, returning its index or NoChoice, or
+	// errNoChooser if there is no way to show such a dialog.
+	choose(title, text string, choices []string) (int, error)
+}
+
+type desktopUI struct{}
+
+func (desktopUI) message(title, text string, info bool) {
+	msg := dialog.Message("%s", text).Title(title)
+	if info {
+		msg.Info()
+	} else {
+		msg.Error()
+	}
+}
+
+func (desktopUI) yesNo(title, text string) bool {
+	return dialog.Message("%s", text).Title(title).YesNo()
+}
+
+func (desktopUI) choose(title, text string, choices []string) (int, error) {
+	return chooseDialog(title, text, choices)
+}
+
+func (d Dialog) dialogs() dialogUI {
+	if d.ui == nil {
+		return desktopUI{}
+	}
+
+	return d.ui
+}
+
+func (d Dialog) Notify(ctx context.Context, n Notification) error {
+	dialogMu.Lock()
+	defer dialogMu.Unlock()
+
+	if d.canMute(n) {
+		return d.offerMute(n)
+	}
+
+	d.dialogs().message(n.Title, n.Message(), n.Severity == SeverityInfo)
+
+	return nil
+}
+
+func (d Dialog) CanPrompt() bool {
+	return true
+}
+
+// Prompt asks whether to go ahead with a single choice with a Yes/No dialog,
+// and asks for one of several in a single selection dialog. Where no selection
+// dialog can be shown, the choices are only listed and none is chosen. If
+// nothing is chosen, it offers to mute alerts about the file.
+func (d Dialog) Prompt(ctx context.Context, n Notification, choices []string) (int, error) {
+	dialogMu.Lock()
+	defer dialogMu.Unlock()
+
+	ui := d.dialogs()
+
+	switch len(choices) {
+	case 0:
+	case 1:
+		if ui.yesNo(n.Title, fmt.Sprintf("%s\n\n%s?", n.Message(), choices[0])) {
+			return 0, nil
+		}
+	default:
+		choice, err := ui.choose(n.Title, n.Message(), choices)
+		switch {
+		case errors.Is(err, errNoChooser):
+			ui.message(n.Title, fmt.Sprintf("%s\n\n%s", n.Message(), strings.Join(numberChoices(choices), "\n")), false)
+			return NoChoice, nil
+		case err != nil:
+			return NoChoice, fmt.Errorf("show selection dialog: %w", err)
+		case choice != NoChoice:
+			return choice, nil
+		}
+	}
+
+	if d.canMute(n) {
+		return NoChoice, d.offerMute(n)
+	}
+
+	return NoChoice, nil
+}
+
+func (d Dialog) canMute(n Notification) bool {
+	return d.Mute != nil && n.Path != "" && n.Verdict != "" && n.Severity != SeverityInfo
+}
+
+func (d Dialog) offerMute(n Notification) error {
+	question := fmt.Sprintf("%s\n\nMute alerts about %q for %s?", n.Message(), n.Path, formatDuration(d.MuteFor))
+	if !d.dialogs().yesNo(n.Title, question) {
+		return nil
+	}
+
+	return d.Mute(n.Path, d.MuteFor)
+}
+
+// formatDuration formats whole hours and minutes without trailing zero units.
+func formatDuration(d time.Duration) string {
+	s := d.Round(time.Minute).String()
+	s = strings.TrimSuffix(s, "0s")
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+
+	return s
+}
+
+// Console writes notifications to a terminal.
+type Console struct {
+	mu sync.Mutex
+	w  io.Writer
+	// inMu makes prompts wait their turn for the terminal's input, without
+	// holding up notifications written while the operator decides.
+	inMu sync.Mutex
+	in   *bufio.Reader
+}
+
+// NewConsole returns a notifier writing to w, or to stderr if w is nil.
+func NewConsole(w io.Writer) *Console {
+	if w == nil {
+		w = os.Stderr
+	}
+
+	return &Console{w: w}
+}
+
+// NewPromptingConsole returns a console notifier which also prompts for
+// choices, reading the answers from in.
+func NewPromptingConsole(in io.Reader, w io.Writer) *Console {
+	c := NewConsole(w)
+	c.in = bufio.NewReader(in)
+
+	return c
+}
+
+func (c *Console) Notify(ctx context.Context, n Notification) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	_, err := io.WriteString(c.w, formatConsole(n))
+
+	return err
+}
+
+func (c *Console) CanPrompt() bool {
+	return c.in != nil
+}
+
+// Prompt lists the choices by number and reads the chosen number from the
+// terminal. Anything other than one of the listed numbers ignores the
+// notification.
+func (c *Console) Prompt(ctx context.Context, n Notification, choices []string) (int, error) {
+	var sb strings.Builder
+
+	sb.WriteString(formatConsole(n))
+	for i, choice := range choices {
+		fmt.Fprintf(&sb, "  %d) %s\n", i+1, choice)
+	}
+
+	sb.WriteString("  0) Ignore\nChoice [0]: ")
+
+	c.inMu.Lock()
+	defer c.inMu.Unlock()
+
+	c.mu.Lock()
+	_, err := io.WriteString(c.w, sb.String())
+	c.mu.Unlock()
+
+	if err != nil {
+		return NoChoice, err
+	}
+
+	line, err := c.in.ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return NoChoice, fmt.Errorf("read choice: %w", err)
+	}
+
+	choice, err := strconv.Atoi(strings.TrimSpace(line))
+	if err != nil || choice < 1 || choice > len(choices) {
+		return NoChoice, nil
+	}
+
+	return choice - 1, nil
+}
+
+func formatConsole(n Notification) string {
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "[%s] %s\n", strings.ToUpper(n.Severity.String()), n.Title)
+	for _, line := range strings.Split(n.Message(), "\n") {
+		fmt.Fprintf(&sb, "    %s\n", line)
+	}
+
+	return sb.String()
+}
+
+// Log writes notifications to a logger.
+type Log struct {
+	Logger *logrus.Logger
+}
+
+func (l Log) Notify(ctx context.Context, n Notification) error {
+	level := logrus.InfoLevel
+	switch n.Severity {
+	case SeverityWarning:
+		level = logrus.WarnLevel
+	case SeverityError:
+		level = logrus.ErrorLevel
+	}
+
+	l.Logger.Logf(level, "<< %s >> %q", n.Title, n.Message())
+
+	return nil
+}
+
+// File appends notifications to a file as JSON, one per line.
+type File struct {
+	mu   sync.Mutex
+	path string
+}
+
+func NewFile(path string) *File {
+	return &File{path: path}
+}
+
+func (f *File) Notify(ctx context.Context, n Notification) error {
+	data, err := json.Marshal(n)
+	if err != nil {
+		return fmt.Errorf("encode notification: %w", err)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	out, err := os.OpenFile(f.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return fmt.Errorf("open notification file: %w", err)
+	}
+
+	if _, err := out.Write(append(data, '\n')); err != nil {
+		out.Close()
+		return fmt.Errorf("write notification file: %w", err)
+	}
+
+	return out.Close()
+}
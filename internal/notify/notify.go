@@ -0,0 +1,139 @@
+// Copyright (2023 -- present) Shahruk Hossain <shahruk10@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//		 http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// ==============================================================================
+
+package notify
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Severity describes how urgent a notification is.
+type Severity int
+
+const (
+	SeverityInfo Severity = iota
+	SeverityWarning
+	SeverityError
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityInfo:
+		return "info"
+	case SeverityWarning:
+		return "warning"
+	default:
+		return "error"
+	}
+}
+
+func (s Severity) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// Field is a single labelled line of a notification message.
+type Field struct {
+	Label string `json:"label"`
+	Value string `json:"value"`
+}
+
+// Notification describes something an operator should be told about, along
+// with the event that caused it.
+type Notification struct {
+	Severity Severity `json:"severity"`
+	Title    string   `json:"title"`
+	Fields   []Field  `json:"fields"`
+
+	// Summary describes the notification in a single line, for when several
+	// notifications are listed together.
+	Summary string `json:"summary,omitempty"`
+
+	// Path and Verdict of the event the notification is about, if any.
+	Path    string    `json:"path,omitempty"`
+	Verdict string    `json:"verdict,omitempty"`
+	Time    time.Time `json:"time"`
+}
+
+// SummaryLine returns the summary of the notification, falling back to the
+// name of the file it is about.
+func (n *Notification) SummaryLine() string {
+	if n.Summary != "" {
+		return n.Summary
+	}
+
+	if n.Path != "" {
+		return filepath.Base(n.Path)
+	}
+
+	return n.Title
+}
+
+// Message renders the fields of the notification as text, one per line.
+func (n *Notification) Message() string {
+	lines := make([]string, 0, len(n.Fields))
+	for _, f := range n.Fields {
+		if f.Label == "" {
+			lines = append(lines, f.Value)
+			continue
+		}
+
+		lines = append(lines, fmt.Sprintf("%s: %s", f.Label, f.Value))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// Notifier delivers notifications to an operator.
+type Notifier interface {
+	Notify(ctx context.Context, n Notification) error
+}
+
+// Fanout delivers every notification to all of its notifiers.
+type Fanout []Notifier
+
+func (f Fanout) Notify(ctx context.Context, n Notification) error {
+	errs := make([]string, 0)
+
+	for _, notifier := range f {
+		if err := notifier.Notify(ctx, n); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("notify: %s", strings.Join(errs, "; "))
+	}
+
+	return nil
+}
+
+// Router delivers notifications to the notifiers routed to for their verdict,
+// or to the default notifiers for verdicts without a route.
+type Router struct {
+	Routes  map[string]Fanout
+	Default Fanout
+}
+
+func (r *Router) Notify(ctx context.Context, n Notification) error {
+	if route, ok := r.Routes[n.Verdict]; ok {
+		return route.Notify(ctx, n)
+	}
+
+	return r.Default.Notify(ctx, n)
+}
@@ -0,0 +1,524 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+type recorder struct {
+	mu  sync.Mutex
+	got []Notification
+}
+
+func (r *recorder) Notify(ctx context.Context, n Notification) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.got = append(r.got, n)
+
+	return nil
+}
+
+func (r *recorder) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return len(r.got)
+}
+
+func (r *recorder) last() Notification {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.got[len(r.got)-1]
+}
+
+func TestRouter(t *testing.T) {
+	wrongFolder, fallback := &recorder{}, &recorder{}
+
+	router := &Router{
+		Routes:  map[string]Fanout{"wrong_folder": {wrongFolder}},
+		Default: Fanout{fallback},
+	}
+
+	for _, verdict := range []string{"wrong_folder", "invalid_file_name", ""} {
+		if err := router.Notify(context.Background(), Notification{Verdict: verdict}); err != nil {
+			t.Fatalf("got unexpected error routing %q, want=nil, got=%v", verdict, err)
+		}
+	}
+
+	if len(wrongFolder.got) != 1 || wrongFolder.got[0].Verdict != "wrong_folder" {
+		t.Errorf("got unexpected notifications on routed notifier, got=%v", wrongFolder.got)
+	}
+
+	if len(fallback.got) != 2 {
+		t.Errorf("got unexpected number of notifications on default notifier, want=2, got=%d", len(fallback.got))
+	}
+}
+
+func TestConsole(t *testing.T) {
+	var buf bytes.Buffer
+
+	n := Notification{
+		Severity: SeverityError,
+		Title:    "WRONG FOLDER",
+		Fields:   []Field{{Label: "📁 file", Value: "a.jpg"}, {Label: "❌ wrong", Value: "12x12"}},
+	}
+
+	if err := NewConsole(&buf).Notify(context.Background(), n); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "[ERROR] WRONG FOLDER\n    📁 file: a.jpg\n    ❌ wrong: 12x12\n"
+	if buf.String() != want {
+		t.Errorf("got unexpected console output, want=%q, got=%q", want, buf.String())
+	}
+}
+
+// fakeClock only moves forward when advanced, running the timers which are
+// due then.
+type fakeClock struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers []*fakeTimer
+}
+
+type fakeTimer struct {
+	clock *fakeClock
+	due   time.Time
+	f     func()
+	armed bool
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+func (c *fakeClock) AfterFunc(d time.Duration, f func()) timer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	t := &fakeTimer{clock: c, due: c.now.Add(d), f: f, armed: true}
+	c.timers = append(c.timers, t)
+
+	return t
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	c.now = c.now.Add(d)
+
+	var due []*fakeTimer
+	for _, t := range c.timers {
+		if t.armed && !t.due.After(c.now) {
+			t.armed = false
+			due = append(due, t)
+		}
+	}
+	c.mu.Unlock()
+
+	for _, t := range due {
+		t.f()
+	}
+}
+
+func (t *fakeTimer) Reset(d time.Duration) bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+
+	wasArmed := t.armed
+	t.due, t.armed = t.clock.now.Add(d), true
+
+	return wasArmed
+}
+
+func (t *fakeTimer) Stop() bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+
+	wasArmed := t.armed
+	t.armed = false
+
+	return wasArmed
+}
+
+func TestAggregator(t *testing.T) {
+	var logged bytes.Buffer
+
+	logger := logrus.New()
+	logger.SetOutput(&logged)
+
+	rec, clock := &recorder{}, &fakeClock{now: time.Date(2023, 5, 6, 12, 0, 0, 0, time.UTC)}
+	agg := NewAggregator(logger, rec, time.Second, 2)
+	agg.clock = clock
+
+	wrongFolder := func(path string) Notification {
+		return Notification{Title: "WRONG FOLDER", Verdict: "wrong_folder", Path: path, Summary: filepath.Base(path) + " ➜ 8x10"}
+	}
+
+	// The first notification of a group is delivered right away.
+	if err := agg.Notify(context.Background(), wrongFolder("/hot/12x12/a.jpg")); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := rec.count(); got != 1 || rec.last().Path != "/hot/12x12/a.jpg" {
+		t.Fatalf("got first notification held back, want=/hot/12x12/a.jpg, got=%+v", rec.last())
+	}
+
+	// The rest are held back, and the window slides with each of them.
+	clock.Advance(800 * time.Millisecond)
+
+	for _, name := range []string{"b.jpg", "c.jpg", "d.jpg", "e.jpg"} {
+		if err := agg.Notify(context.Background(), wrongFolder("/hot/12x12/"+name)); err != nil {
+			t.Fatal(err)
+		}
+
+		clock.Advance(800 * time.Millisecond)
+	}
+
+	// Different folders are grouped separately.
+	if err := agg.Notify(context.Background(), wrongFolder("/hot/8x10/f.jpg")); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := rec.count(); got != 2 {
+		t.Fatalf("got unexpected notifications within the window, want=2, got=%d", got)
+	}
+
+	clock.Advance(200 * time.Millisecond)
+
+	if got := rec.count(); got != 3 {
+		t.Fatalf("got unexpected number of notifications after the window, want=3, got=%d", got)
+	}
+
+	summary := rec.last()
+	want := "📁 folder: /hot/12x12\nb.jpg ➜ 8x10\nc.jpg ➜ 8x10\n... and 2 more, see log"
+
+	if summary.Title != "WRONG FOLDER (4 more files)" || summary.Message() != want {
+		t.Errorf("got unexpected summary, want=%q, got=%q: %q", want, summary.Title, summary.Message())
+	}
+
+	for _, name := range []string{"d.jpg", "e.jpg"} {
+		if !strings.Contains(logged.String(), "/hot/12x12/"+name) {
+			t.Errorf("got %s left out of the summary and the log:\n%s", name, logged.String())
+		}
+	}
+
+	// A lone notification isn't delivered again when its window closes.
+	clock.Advance(time.Second)
+
+	if got := rec.count(); got != 3 {
+		t.Fatalf("got unexpected notifications for a lone notification, want=3, got=%d", got)
+	}
+
+	// A single held back notification is delivered on its own.
+	for _, name := range []string{"g.jpg", "h.jpg"} {
+		if err := agg.Notify(context.Background(), wrongFolder("/hot/8x10/"+name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	clock.Advance(time.Second)
+
+	if got := rec.count(); got != 5 || rec.last().Path != "/hot/8x10/h.jpg" {
+		t.Fatalf("got unexpected single notification, want=/hot/8x10/h.jpg, got=%+v", rec.last())
+	}
+
+	// A steady stream is delivered once the group has been open long enough.
+	for i := 0; i < 2*maxWindows; i++ {
+		if err := agg.Notify(context.Background(), wrongFolder(fmt.Sprintf("/hot/12x12/%d.jpg", i))); err != nil {
+			t.Fatal(err)
+		}
+
+		clock.Advance(time.Second / 2)
+	}
+
+	if got := rec.count(); got != 7 {
+		t.Errorf("got unexpected notifications for a steady stream, want=7, got=%d", got)
+	}
+}
+
+// scripted is a prompter which answers prompts from a script.
+type scripted struct {
+	recorder
+	answers []int
+	err     error
+}
+
+func (s *scripted) CanPrompt() bool {
+	return true
+}
+
+func (s *scripted) Prompt(ctx context.Context, n Notification, choices []string) (int, error) {
+	if s.err != nil {
+		return NoChoice, s.err
+	}
+
+	if err := s.Notify(ctx, n); err != nil {
+		return NoChoice, err
+	}
+
+	answer := s.answers[0]
+	s.answers = s.answers[1:]
+
+	return answer, nil
+}
+
+func TestFanoutPrompt(t *testing.T) {
+	log, prompter, second := &recorder{}, &scripted{answers: []int{1}}, &scripted{answers: []int{0}}
+
+	choice, err := Prompt(context.Background(), Fanout{log, prompter, second}, Notification{Title: "WRONG FOLDER"}, []string{"a", "b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if choice != 1 {
+		t.Errorf("got unexpected choice, want=1, got=%d", choice)
+	}
+
+	// Only the first prompter is asked; the others are just notified.
+	if log.count() != 1 || prompter.count() != 1 || second.count() != 1 || len(second.answers) != 1 {
+		t.Errorf("got notification delivered unexpectedly, log=%d, prompter=%d, second=%d", log.count(), prompter.count(), second.count())
+	}
+
+	if choice, err := Prompt(context.Background(), Fanout{log}, Notification{}, []string{"a"}); err != nil || choice != NoChoice {
+		t.Errorf("got unexpected result from notifiers which can't prompt, want=%d, got=%d (%v)", NoChoice, choice, err)
+	}
+
+	// A prompter which fails doesn't keep the notification from the rest.
+	broken, rest := &scripted{err: errors.New("no display")}, &recorder{}
+
+	choice, err = Prompt(context.Background(), Fanout{broken, rest}, Notification{Title: "WRONG FOLDER"}, []string{"a"})
+	if err == nil || choice != NoChoice {
+		t.Errorf("got unexpected result from failed prompt, want=%d and error, got=%d (%v)", NoChoice, choice, err)
+	}
+
+	if rest.count() != 1 {
+		t.Errorf("got notification not delivered after failed prompt")
+	}
+}
+
+func TestConsolePrompt(t *testing.T) {
+	tests := []struct {
+		input string
+		want  int
+	}{
+		{input: "2\n", want: 1},
+		{input: "0\n", want: NoChoice},
+		{input: "\n", want: NoChoice},
+		{input: "x\n", want: NoChoice},
+		{input: "3", want: NoChoice},
+		{input: "", want: NoChoice},
+	}
+
+	for _, tc := range tests {
+		var buf bytes.Buffer
+
+		c := NewPromptingConsole(strings.NewReader(tc.input), &buf)
+
+		got, err := c.Prompt(context.Background(), Notification{Title: "WRONG FOLDER"}, []string{"Move to '8x10'", "Move to '8x12'"})
+		if err != nil {
+			t.Fatalf("%q: got unexpected error, want=nil, got=%v", tc.input, err)
+		}
+
+		if got != tc.want {
+			t.Errorf("%q: got unexpected choice, want=%d, got=%d", tc.input, tc.want, got)
+		}
+
+		if !strings.Contains(buf.String(), "  2) Move to '8x12'\n  0) Ignore\n") {
+			t.Errorf("%q: got unexpected prompt %q", tc.input, buf.String())
+		}
+	}
+}
+
+func TestDialogCanMute(t *testing.T) {
+	d := Dialog{Mute: func(string, time.Duration) error { return nil }, MuteFor: time.Hour}
+	tests := []struct {
+		name string
+		n    Notification
+		want bool
+	}{
+		{name: "verdict alert", n: Notification{Severity: SeverityWarning, Path: "a.jpg", Verdict: "wrong_folder"}, want: true},
+		{name: "failed action", n: Notification{Severity: SeverityError, Path: "a.jpg"}, want: false},
+		{name: "info", n: Notification{Severity: SeverityInfo, Path: "a.jpg", Verdict: "correct"}, want: false},
+		{name: "no path", n: Notification{Severity: SeverityError, Verdict: "wrong_folder"}, want: false},
+	}
+
+	for _, tc := range tests {
+		if got := d.canMute(tc.n); got != tc.want {
+			t.Errorf("%s: got unexpected result, want=%v, got=%v", tc.name, tc.want, got)
+		}
+	}
+
+	if (Dialog{}).canMute(tests[0].n) {
+		t.Errorf("got mute offered without a mute function")
+	}
+}
+
+// promptWriter closes asked once the prompt for a choice has been written.
+type promptWriter struct {
+	asked chan struct{}
+}
+
+func (w *promptWriter) Write(p []byte) (int, error) {
+	if strings.Contains(string(p), "Choice [0]: ") {
+		close(w.asked)
+	}
+
+	return len(p), nil
+}
+
+func TestConsoleNotifyWhilePrompting(t *testing.T) {
+	in, answer := io.Pipe()
+	out := &promptWriter{asked: make(chan struct{})}
+	c := NewPromptingConsole(in, out)
+
+	chosen := make(chan int, 1)
+	go func() {
+		choice, _ := c.Prompt(context.Background(), Notification{Title: "WRONG FOLDER"}, []string{"Move"})
+		chosen <- choice
+	}()
+
+	<-out.asked
+
+	notified := make(chan error, 1)
+	go func() {
+		notified <- c.Notify(context.Background(), Notification{Title: "INVALID FILE NAME"})
+	}()
+
+	select {
+	case err := <-notified:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("got notification held up by a prompt waiting for input")
+	}
+
+	if _, err := io.WriteString(answer, "1\n"); err != nil {
+		t.Fatal(err)
+	}
+
+	if choice := <-chosen; choice != 0 {
+		t.Errorf("got unexpected choice, want=0, got=%d", choice)
+	}
+}
+
+// scriptedUI answers dialogs from a script, recording what was shown.
+type scriptedUI struct {
+	yes    bool
+	choice int
+	err    error
+	shown  []string
+}
+
+func (u *scriptedUI) message(title, text string, info bool) {
+	u.shown = append(u.shown, "message: "+text)
+}
+
+func (u *scriptedUI) yesNo(title, text string) bool {
+	u.shown = append(u.shown, "yes/no: "+text)
+	return u.yes
+}
+
+func (u *scriptedUI) choose(title, text string, choices []string) (int, error) {
+	u.shown = append(u.shown, "choose: "+strings.Join(choices, ", "))
+	return u.choice, u.err
+}
+
+func TestDialogPrompt(t *testing.T) {
+	n := Notification{Title: "WRONG FOLDER", Severity: SeverityWarning, Path: "a.jpg", Verdict: "wrong_folder", Fields: []Field{{Value: "a.jpg"}}}
+	tests := []struct {
+		name      string
+		ui        scriptedUI
+		choices   []string
+		want      int
+		wantShown []string
+		wantMuted bool
+	}{
+		{
+			name:      "single candidate",
+			ui:        scriptedUI{yes: true},
+			choices:   []string{"Move to '8x10'"},
+			want:      0,
+			wantShown: []string{"yes/no: a.jpg\n\nMove to '8x10'?"},
+		},
+		{
+			name:      "several candidates",
+			ui:        scriptedUI{choice: 2},
+			choices:   []string{"Move to '8x10'", "Move to '8x12'", "Move to '8x14'"},
+			want:      2,
+			wantShown: []string{"choose: Move to '8x10', Move to '8x12', Move to '8x14'"},
+		},
+		{
+			name:      "several candidates, none chosen",
+			ui:        scriptedUI{choice: NoChoice, yes: true},
+			choices:   []string{"Move to '8x10'", "Move to '8x12'"},
+			want:      NoChoice,
+			wantShown: []string{"choose: Move to '8x10', Move to '8x12'", "yes/no: a.jpg\n\nMute alerts about \"a.jpg\" for 1h?"},
+			wantMuted: true,
+		},
+		{
+			name:      "no selection dialog",
+			ui:        scriptedUI{choice: NoChoice, err: errNoChooser},
+			choices:   []string{"Move to '8x10'", "Move to '8x12'"},
+			want:      NoChoice,
+			wantShown: []string{"choose: Move to '8x10', Move to '8x12'", "message: a.jpg\n\n1) Move to '8x10'\n2) Move to '8x12'"},
+		},
+	}
+
+	for _, tc := range tests {
+		muted := false
+		ui := tc.ui
+		d := Dialog{Mute: func(string, time.Duration) error { muted = true; return nil }, MuteFor: time.Hour, ui: &ui}
+
+		got, err := d.Prompt(context.Background(), n, tc.choices)
+		if err != nil {
+			t.Fatalf("%s: got unexpected error, want=nil, got=%v", tc.name, err)
+		}
+
+		if got != tc.want {
+			t.Errorf("%s: got unexpected choice, want=%d, got=%d", tc.name, tc.want, got)
+		}
+
+		if strings.Join(ui.shown, "|") != strings.Join(tc.wantShown, "|") {
+			t.Errorf("%s: got unexpected dialogs,\nwant=%q\n got=%q", tc.name, tc.wantShown, ui.shown)
+		}
+
+		if muted != tc.wantMuted {
+			t.Errorf("%s: got unexpected mute, want=%v, got=%v", tc.name, tc.wantMuted, muted)
+		}
+	}
+}
+
+func TestParseChoice(t *testing.T) {
+	tests := []struct {
+		out  string
+		want int
+	}{
+		{out: "2\n", want: 1},
+		{out: "3) Move to '8x14'\n", want: 2},
+		{out: "4\n", want: NoChoice},
+		{out: "0", want: NoChoice},
+		{out: "\n", want: NoChoice},
+	}
+
+	for _, tc := range tests {
+		if got := parseChoice([]byte(tc.out), 3); got != tc.want {
+			t.Errorf("%q: got unexpected choice, want=%d, got=%d", tc.out, tc.want, got)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+// Copyright (2023 -- present) Shahruk Hossain <shahruk10@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//		 http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// ==============================================================================
+
+package notify
+
+import (
+	"context"
+	"fmt"
+)
+
+// NoChoice is returned by Prompt if the operator chose to ignore the
+// notification, or could not be asked.
+const NoChoice = -1
+
+// Prompter is a notifier which can also ask the operator to choose how to
+// respond to a notification.
+type Prompter interface {
+	Notifier
+
+	// CanPrompt returns false if Prompt would only deliver the notification
+	// without asking the operator anything.
+	CanPrompt() bool
+
+	// Prompt delivers the notification, offering the given choices. It
+	// returns the index of the chosen one, or NoChoice.
+	Prompt(ctx context.Context, n Notification, choices []string) (int, error)
+}
+
+// Prompt offers the choices through the notifier if it can prompt, or just
+// delivers the notification otherwise.
+func Prompt(ctx context.Context, notifier Notifier, n Notification, choices []string) (int, error) {
+	if p, ok := notifier.(Prompter); ok && p.CanPrompt() {
+		return p.Prompt(ctx, n, choices)
+	}
+
+	return NoChoice, notifier.Notify(ctx, n)
+}
+
+func canPrompt(notifier Notifier) bool {
+	p, ok := notifier.(Prompter)
+	return ok && p.CanPrompt()
+}
+
+func (f Fanout) CanPrompt() bool {
+	for _, notifier := range f {
+		if canPrompt(notifier) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Prompt offers the choices through the first notifier which can prompt, and
+// delivers the notification to the rest. If prompting fails, the notification
+// is still delivered to the rest before the error is returned.
+func (f Fanout) Prompt(ctx context.Context, n Notification, choices []string) (int, error) {
+	choice, prompted := NoChoice, false
+	notified := make(Fanout, 0, len(f))
+
+	var promptErr error
+	for _, notifier := range f {
+		if prompted || !canPrompt(notifier) {
+			notified = append(notified, notifier)
+			continue
+		}
+
+		if choice, promptErr = notifier.(Prompter).Prompt(ctx, n, choices); promptErr != nil {
+			choice = NoChoice
+		}
+
+		prompted = true
+	}
+
+	err := notified.Notify(ctx, n)
+	switch {
+	case promptErr != nil && err != nil:
+		return NoChoice, fmt.Errorf("prompt: %v; %w", promptErr, err)
+	case promptErr != nil:
+		return NoChoice, fmt.Errorf("prompt: %w", promptErr)
+	}
+
+	return choice, err
+}
+
+func (r *Router) CanPrompt() bool {
+	if r.Default.CanPrompt() {
+		return true
+	}
+
+	for _, route := range r.Routes {
+		if route.CanPrompt() {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (r *Router) Prompt(ctx context.Context, n Notification, choices []string) (int, error) {
+	if route, ok := r.Routes[n.Verdict]; ok {
+		return route.Prompt(ctx, n, choices)
+	}
+
+	return r.Default.Prompt(ctx, n, choices)
+}
+
+func (a *Aggregator) CanPrompt() bool {
+	return canPrompt(a.next)
+}
+
+// Prompt offers the choices for the first notification of a group right away;
+// any more arriving within the window are only delivered with the group.
+func (a *Aggregator) Prompt(ctx context.Context, n Notification, choices []string) (int, error) {
+	if !a.open(n) {
+		return NoChoice, nil
+	}
+
+	return Prompt(ctx, a.next, n, choices)
+}
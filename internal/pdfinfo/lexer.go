@@ -0,0 +1,380 @@
+// Copyright (2023 -- present) Shahruk Hossain <shahruk10@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//		 http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// ==============================================================================
+
+package pdfinfo
+
+import (
+	"bufio"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// PDF objects are represented as nil, bool, int64, float64, string, name,
+// array, dict, ref and *stream values.
+type (
+	object  interface{}
+	name    string
+	keyword string
+	array   []object
+	dict    map[name]object
+)
+
+// ref refers to an indirect object.
+type ref struct {
+	num, gen int64
+}
+
+// stream is a stream object; its data starts at offset in the file.
+type stream struct {
+	hdr    dict
+	offset int64
+}
+
+// lexer reads the tokens and objects of a PDF file.
+type lexer struct {
+	r   *bufio.Reader
+	pos int64
+
+	// unread holds tokens which were looked ahead at, last first.
+	unread []interface{}
+}
+
+func newLexer(r io.Reader, pos int64) *lexer {
+	return &lexer{r: bufio.NewReader(r), pos: pos}
+}
+
+func isSpace(c byte) bool {
+	return c == 0 || c == '\t' || c == '\n' || c == '\f' || c == '\r' || c == ' '
+}
+
+func isDelimiter(c byte) bool {
+	return strings.IndexByte("()<>[]{}/%", c) >= 0
+}
+
+func (l *lexer) readByte() (byte, error) {
+	c, err := l.r.ReadByte()
+	if err == nil {
+		l.pos++
+	}
+
+	return c, err
+}
+
+func (l *lexer) peekByte() (byte, bool) {
+	b, err := l.r.Peek(1)
+	if err != nil {
+		return 0, false
+	}
+
+	return b[0], true
+}
+
+// regular reads the rest of a run of regular characters.
+func (l *lexer) regular(s []byte) []byte {
+	for {
+		c, ok := l.peekByte()
+		if !ok || isSpace(c) || isDelimiter(c) {
+			return s
+		}
+
+		l.readByte()
+		s = append(s, c)
+	}
+}
+
+// next returns the next token: a keyword, name, string, int64 or float64.
+func (l *lexer) next() (interface{}, error) {
+	if n := len(l.unread); n > 0 {
+		t := l.unread[n-1]
+		l.unread = l.unread[:n-1]
+		return t, nil
+	}
+
+	c, err := l.readByte()
+	for ; err == nil; c, err = l.readByte() {
+		if c == '%' {
+			for err == nil && c != '\r' && c != '\n' {
+				c, err = l.readByte()
+			}
+		} else if !isSpace(c) {
+			break
+		}
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	switch c {
+	case '/':
+		return name(decodeName(l.regular(nil))), nil
+
+	case '(':
+		return l.literalString()
+
+	case '<':
+		if next, ok := l.peekByte(); ok && next == '<' {
+			l.readByte()
+			return keyword("<<"), nil
+		}
+
+		return l.hexString()
+
+	case '>':
+		if next, ok := l.peekByte(); ok && next == '>' {
+			l.readByte()
+			return keyword(">>"), nil
+		}
+
+		return nil, fmt.Errorf("unexpected '>' at %d", l.pos)
+
+	case '[', ']', '{', '}':
+		return keyword([]byte{c}), nil
+
+	case ')':
+		return nil, fmt.Errorf("unexpected ')' at %d", l.pos)
+	}
+
+	s := string(l.regular([]byte{c}))
+	if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return i, nil
+	}
+
+	if f, err := strconv.ParseFloat(s, 64); err == nil && strings.Trim(s, "+-.0123456789") == "" {
+		return f, nil
+	}
+
+	return keyword(s), nil
+}
+
+func (l *lexer) back(t interface{}) {
+	l.unread = append(l.unread, t)
+}
+
+// decodeName replaces the #xx escapes in a name.
+func decodeName(s []byte) string {
+	out := make([]byte, 0, len(s))
+	for i := 0; i < len(s); i++ {
+		if s[i] == '#' && i+2 < len(s) {
+			if b, err := hex.DecodeString(string(s[i+1 : i+3])); err == nil {
+				out = append(out, b[0])
+				i += 2
+				continue
+			}
+		}
+
+		out = append(out, s[i])
+	}
+
+	return string(out)
+}
+
+func (l *lexer) literalString() (string, error) {
+	var s []byte
+	for depth := 1; ; {
+		c, err := l.readByte()
+		if err != nil {
+			return "", fmt.Errorf("unterminated string: %w", err)
+		}
+
+		switch c {
+		case '\\':
+			if c, err = l.readByte(); err != nil {
+				return "", fmt.Errorf("unterminated string: %w", err)
+			}
+		case '(':
+			depth++
+		case ')':
+			if depth--; depth == 0 {
+				return string(s), nil
+			}
+		}
+
+		s = append(s, c)
+	}
+}
+
+func (l *lexer) hexString() (string, error) {
+	var s []byte
+	for {
+		c, err := l.readByte()
+		if err != nil {
+			return "", fmt.Errorf("unterminated hex string: %w", err)
+		}
+
+		if c == '>' {
+			break
+		}
+
+		if !isSpace(c) {
+			s = append(s, c)
+		}
+	}
+
+	if len(s)%2 == 1 {
+		s = append(s, '0')
+	}
+
+	b, err := hex.DecodeString(string(s))
+	if err != nil {
+		return "", fmt.Errorf("invalid hex string: %w", err)
+	}
+
+	return string(b), nil
+}
+
+// object reads the next object.
+func (l *lexer) object() (object, error) {
+	t, err := l.next()
+	if err != nil {
+		return nil, err
+	}
+
+	switch t := t.(type) {
+	case keyword:
+		switch t {
+		case "<<":
+			return l.dict()
+		case "[":
+			return l.array()
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		case "null":
+			return nil, nil
+		default:
+			return nil, fmt.Errorf("unexpected %q at %d", t, l.pos)
+		}
+
+	case int64:
+		// Integers may start a reference, "num gen R".
+		gen, err := l.next()
+		if err != nil {
+			return t, nil
+		}
+
+		if _, ok := gen.(int64); ok {
+			r, err := l.next()
+			if err == nil && r == keyword("R") {
+				return ref{num: t, gen: gen.(int64)}, nil
+			} else if err == nil {
+				l.back(r)
+			}
+		}
+
+		l.back(gen)
+
+		return t, nil
+
+	default:
+		return t, nil
+	}
+}
+
+func (l *lexer) array() (array, error) {
+	a := array{}
+	for {
+		t, err := l.next()
+		if err != nil {
+			return nil, fmt.Errorf("unterminated array: %w", err)
+		}
+
+		if t == keyword("]") {
+			return a, nil
+		}
+
+		l.back(t)
+
+		o, err := l.object()
+		if err != nil {
+			return nil, err
+		}
+
+		a = append(a, o)
+	}
+}
+
+func (l *lexer) dict() (dict, error) {
+	d := dict{}
+	for {
+		t, err := l.next()
+		if err != nil {
+			return nil, fmt.Errorf("unterminated dictionary: %w", err)
+		}
+
+		if t == keyword(">>") {
+			return d, nil
+		}
+
+		key, ok := t.(name)
+		if !ok {
+			return nil, fmt.Errorf("dictionary key at %d is %v, not a name", l.pos, t)
+		}
+
+		o, err := l.object()
+		if err != nil {
+			return nil, err
+		}
+
+		d[key] = o
+	}
+}
+
+// indirect reads an indirect object, "num gen obj ... endobj", returning its
+// object number and the object.
+func (l *lexer) indirect() (int64, object, error) {
+	var header [3]interface{}
+	for i := range header {
+		t, err := l.next()
+		if err != nil {
+			return 0, nil, err
+		}
+
+		header[i] = t
+	}
+
+	num, ok := header[0].(int64)
+	if _, isGen := header[1].(int64); !ok || !isGen || header[2] != keyword("obj") {
+		return 0, nil, fmt.Errorf("expected object header at %d", l.pos)
+	}
+
+	o, err := l.object()
+	if err != nil {
+		return 0, nil, err
+	}
+
+	d, ok := o.(dict)
+	if !ok {
+		return num, o, nil
+	}
+
+	t, err := l.next()
+	if err != nil || t != keyword("stream") {
+		return num, d, nil
+	}
+
+	// The stream keyword is followed by CRLF or LF before the data.
+	if c, err := l.readByte(); err == nil && c == '\r' {
+		if next, ok := l.peekByte(); ok && next == '\n' {
+			l.readByte()
+		}
+	}
+
+	return num, &stream{hdr: d, offset: l.pos}, nil
+}
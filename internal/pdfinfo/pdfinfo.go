@@ -0,0 +1,584 @@
+// Copyright (2023 -- present) Shahruk Hossain <shahruk10@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//		 http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// ==============================================================================
+
+// Package pdfinfo reads the page sizes of PDF documents, without rendering
+// them.
+package pdfinfo
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+)
+
+var (
+	// ErrUnsupported is returned for files which aren't PDF documents.
+	ErrUnsupported = errors.New("not a pdf document")
+	// ErrEncrypted is returned for encrypted PDF documents.
+	ErrEncrypted = errors.New("pdf document is encrypted")
+	// ErrMalformed is returned for PDF documents whose structure is damaged.
+	ErrMalformed = errors.New("malformed pdf document")
+)
+
+// pointsPerInch is the size of the default PDF user space unit.
+const pointsPerInch = 72
+
+// Box is a page boundary, as the coordinates of its lower left and upper
+// right corners in points.
+type Box [4]float64
+
+// Width returns the width of the box in points.
+func (b Box) Width() float64 { return math.Abs(b[2] - b[0]) }
+
+// Height returns the height of the box in points.
+func (b Box) Height() float64 { return math.Abs(b[3] - b[1]) }
+
+// Page describes a page of a document.
+type Page struct {
+	MediaBox Box  `json:"media_box"`
+	TrimBox  *Box `json:"trim_box,omitempty"`
+	// Rotate is the number of degrees the page is rotated clockwise when
+	// displayed.
+	Rotate int `json:"rotate,omitempty"`
+}
+
+// Size returns the width and height of the page in inches as displayed, using
+// the trim box if there is one, after applying its rotation.
+func (p Page) Size() (float64, float64) {
+	box := p.MediaBox
+	if p.TrimBox != nil {
+		box = *p.TrimBox
+	}
+
+	w, h := box.Width()/pointsPerInch, box.Height()/pointsPerInch
+	if p.Rotate%180 != 0 {
+		return h, w
+	}
+
+	return w, h
+}
+
+// Info describes a document.
+type Info struct {
+	PageCount int `json:"page_count"`
+	// Pages holds the pages which were read, in order.
+	Pages []Page `json:"pages"`
+}
+
+// ReadFile reads the first page, or every page, of the PDF document at path.
+func ReadFile(path string, allPages bool) (*Info, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	return Read(f, info.Size(), allPages)
+}
+
+// Read reads the first page, or every page, of the PDF document of the given
+// size. Errors for damaged documents wrap ErrMalformed.
+func Read(r io.ReaderAt, size int64, allPages bool) (*Info, error) {
+	header := make([]byte, 1024)
+	n, _ := r.ReadAt(header, 0)
+	if !bytes.Contains(header[:n], []byte("%PDF-")) {
+		return nil, ErrUnsupported
+	}
+
+	d := &document{r: r, size: size, xref: make(map[int64]xrefEntry), cache: make(map[int64]object)}
+
+	info, err := d.read(allPages)
+	if errors.Is(err, ErrEncrypted) {
+		return nil, err
+	} else if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMalformed, err)
+	}
+
+	return info, nil
+}
+
+// Limits guarding against documents which are damaged or malicious.
+const (
+	maxStreamSize = 64 << 20
+	maxDepth      = 32
+	maxPages      = 100000
+)
+
+// xrefEntry locates an object, either at an offset in the file, or at an
+// index in an object stream.
+type xrefEntry struct {
+	offset int64
+	stream int64
+	index  int
+}
+
+type document struct {
+	r    io.ReaderAt
+	size int64
+
+	xref    map[int64]xrefEntry
+	trailer dict
+	cache   map[int64]object
+	depth   int
+}
+
+func (d *document) read(allPages bool) (*Info, error) {
+	if err := d.readXref(); err != nil {
+		return nil, err
+	}
+
+	if _, ok := d.trailer["Encrypt"]; ok {
+		return nil, ErrEncrypted
+	}
+
+	root, err := d.dict(d.trailer["Root"])
+	if err != nil {
+		return nil, fmt.Errorf("document catalog: %w", err)
+	}
+
+	tree, err := d.dict(root["Pages"])
+	if err != nil {
+		return nil, fmt.Errorf("page tree: %w", err)
+	}
+
+	info := &Info{}
+	if count, ok := d.int(tree["Count"]); ok {
+		info.PageCount = int(count)
+	}
+
+	visited := make(map[ref]bool)
+	if err := d.walkPages(tree, page{}, visited, info, allPages, 0); err != nil {
+		return nil, err
+	}
+
+	if len(info.Pages) == 0 {
+		return nil, fmt.Errorf("document has no pages")
+	}
+
+	return info, nil
+}
+
+// page holds the attributes pages inherit from the page tree.
+type page struct {
+	mediaBox *Box
+	rotate   int
+}
+
+func (d *document) walkPages(node dict, inherited page, visited map[ref]bool, info *Info, allPages bool, depth int) error {
+	if depth > maxDepth {
+		return fmt.Errorf("page tree is too deep")
+	}
+
+	if o, ok := node["MediaBox"]; ok {
+		box, err := d.box(o)
+		if err != nil {
+			return fmt.Errorf("media box: %w", err)
+		}
+
+		inherited.mediaBox = box
+	}
+
+	if rotate, ok := d.int(node["Rotate"]); ok {
+		inherited.rotate = int(((rotate % 360) + 360) % 360)
+	}
+
+	kids, err := d.resolve(node["Kids"])
+	if err != nil {
+		return err
+	}
+
+	if typ, _ := node["Type"].(name); typ == "Page" || kids == nil {
+		if inherited.mediaBox == nil {
+			return fmt.Errorf("page %d has no media box", len(info.Pages)+1)
+		}
+
+		p := Page{MediaBox: *inherited.mediaBox, Rotate: inherited.rotate}
+		if o, ok := node["TrimBox"]; ok {
+			if p.TrimBox, err = d.box(o); err != nil {
+				return fmt.Errorf("trim box: %w", err)
+			}
+		}
+
+		info.Pages = append(info.Pages, p)
+
+		return nil
+	}
+
+	list, ok := kids.(array)
+	if !ok {
+		return fmt.Errorf("page tree kids are %T, not an array", kids)
+	}
+
+	for _, kid := range list {
+		if !allPages && len(info.Pages) > 0 {
+			return nil
+		}
+
+		if len(info.Pages) >= maxPages {
+			return fmt.Errorf("page tree has too many pages")
+		}
+
+		if r, ok := kid.(ref); ok {
+			if visited[r] {
+				return fmt.Errorf("page tree has a cycle")
+			}
+
+			visited[r] = true
+		}
+
+		child, err := d.dict(kid)
+		if err != nil {
+			return fmt.Errorf("page tree node: %w", err)
+		}
+
+		if err := d.walkPages(child, inherited, visited, info, allPages, depth+1); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (d *document) box(o object) (*Box, error) {
+	o, err := d.resolve(o)
+	if err != nil {
+		return nil, err
+	}
+
+	a, ok := o.(array)
+	if !ok || len(a) != 4 {
+		return nil, fmt.Errorf("expected array of 4 numbers, got %v", o)
+	}
+
+	var box Box
+	for i := range a {
+		v, ok := d.number(a[i])
+		if !ok {
+			return nil, fmt.Errorf("expected array of 4 numbers, got %v", a)
+		}
+
+		box[i] = v
+	}
+
+	if box.Width() == 0 || box.Height() == 0 {
+		return nil, fmt.Errorf("box %v is empty", box)
+	}
+
+	return &box, nil
+}
+
+// resolve returns the object referred to, if o is a reference.
+func (d *document) resolve(o object) (object, error) {
+	r, ok := o.(ref)
+	if !ok {
+		return o, nil
+	}
+
+	if cached, ok := d.cache[r.num]; ok {
+		return cached, nil
+	}
+
+	if d.depth++; d.depth > maxDepth {
+		return nil, fmt.Errorf("references nested too deeply")
+	}
+	defer func() { d.depth-- }()
+
+	entry, ok := d.xref[r.num]
+	if !ok {
+		// References to missing objects are null.
+		return nil, nil
+	}
+
+	var (
+		num int64
+		obj object
+		err error
+	)
+
+	if entry.stream != 0 {
+		obj, err = d.compressedObject(entry)
+		num = r.num
+	} else {
+		num, obj, err = d.objectAt(entry.offset)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("object %d: %w", r.num, err)
+	}
+
+	if num != r.num {
+		return nil, fmt.Errorf("object %d: found object %d at its offset", r.num, num)
+	}
+
+	d.cache[r.num] = obj
+
+	return obj, nil
+}
+
+func (d *document) dict(o object) (dict, error) {
+	o, err := d.resolve(o)
+	if err != nil {
+		return nil, err
+	}
+
+	switch o := o.(type) {
+	case dict:
+		return o, nil
+	case *stream:
+		return o.hdr, nil
+	default:
+		return nil, fmt.Errorf("expected dictionary, got %T", o)
+	}
+}
+
+func (d *document) int(o object) (int64, bool) {
+	o, err := d.resolve(o)
+	if err != nil {
+		return 0, false
+	}
+
+	i, ok := o.(int64)
+
+	return i, ok
+}
+
+func (d *document) number(o object) (float64, bool) {
+	o, err := d.resolve(o)
+	if err != nil {
+		return 0, false
+	}
+
+	switch v := o.(type) {
+	case int64:
+		return float64(v), true
+	case float64:
+		return v, true
+	default:
+		return 0, false
+	}
+}
+
+func (d *document) objectAt(offset int64) (int64, object, error) {
+	if offset <= 0 || offset >= d.size {
+		return 0, nil, fmt.Errorf("offset %d is outside the file", offset)
+	}
+
+	return newLexer(io.NewSectionReader(d.r, offset, d.size-offset), offset).indirect()
+}
+
+// compressedObject reads an object stored in an object stream.
+func (d *document) compressedObject(entry xrefEntry) (object, error) {
+	o, err := d.resolve(ref{num: entry.stream})
+	if err != nil {
+		return nil, err
+	}
+
+	s, ok := o.(*stream)
+	if !ok {
+		return nil, fmt.Errorf("object stream %d is %T, not a stream", entry.stream, o)
+	}
+
+	data, err := d.streamData(s)
+	if err != nil {
+		return nil, fmt.Errorf("object stream %d: %w", entry.stream, err)
+	}
+
+	n, _ := d.int(s.hdr["N"])
+	first, _ := d.int(s.hdr["First"])
+	if int64(entry.index) >= n || first <= 0 || first > int64(len(data)) {
+		return nil, fmt.Errorf("object stream %d: invalid index %d", entry.stream, entry.index)
+	}
+
+	// The stream starts with pairs of object numbers and offsets.
+	l := newLexer(bytes.NewReader(data), 0)
+	var offset int64 = -1
+	for i := 0; i <= entry.index; i++ {
+		_, errNum := l.next()
+		t, errOffset := l.next()
+		if errNum != nil || errOffset != nil {
+			return nil, fmt.Errorf("object stream %d: truncated index", entry.stream)
+		}
+
+		offset, ok = t.(int64)
+		if !ok {
+			return nil, fmt.Errorf("object stream %d: invalid index", entry.stream)
+		}
+	}
+
+	if offset < 0 || first+offset < 0 || first+offset >= int64(len(data)) {
+		return nil, fmt.Errorf("object stream %d: offset %d is outside the stream", entry.stream, offset)
+	}
+
+	return newLexer(bytes.NewReader(data[first+offset:]), 0).object()
+}
+
+// streamData returns the decoded data of the stream.
+func (d *document) streamData(s *stream) ([]byte, error) {
+	length, ok := d.int(s.hdr["Length"])
+	if !ok || length < 0 || length > maxStreamSize || s.offset+length > d.size {
+		return nil, fmt.Errorf("invalid stream length %v", s.hdr["Length"])
+	}
+
+	data := make([]byte, length)
+	if _, err := d.r.ReadAt(data, s.offset); err != nil {
+		return nil, err
+	}
+
+	filter, err := d.resolve(s.hdr["Filter"])
+	if err != nil {
+		return nil, err
+	}
+
+	params, _ := d.dict(s.hdr["DecodeParms"])
+
+	switch f := filter.(type) {
+	case nil:
+		return data, nil
+	case array:
+		if len(f) == 0 {
+			return data, nil
+		} else if len(f) > 1 {
+			return nil, fmt.Errorf("unsupported filters %v", f)
+		}
+
+		filter = f[0]
+		if p, ok := s.hdr["DecodeParms"].(array); ok && len(p) > 0 {
+			params, _ = d.dict(p[0])
+		}
+	}
+
+	if filter != name("FlateDecode") {
+		return nil, fmt.Errorf("unsupported filter %v", filter)
+	}
+
+	zr, err := zlib.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	decoded, err := io.ReadAll(io.LimitReader(zr, maxStreamSize))
+	if err != nil {
+		return nil, err
+	}
+
+	return d.unpredict(decoded, params)
+}
+
+// unpredict reverses the PNG predictors which may be applied to compressed
+// streams.
+func (d *document) unpredict(data []byte, params dict) ([]byte, error) {
+	predictor, _ := d.int(params["Predictor"])
+	if predictor <= 1 {
+		return data, nil
+	} else if predictor < 10 {
+		return nil, fmt.Errorf("unsupported predictor %d", predictor)
+	}
+
+	columns, colors, bits := int64(1), int64(1), int64(8)
+	if v, ok := d.int(params["Columns"]); ok {
+		columns = v
+	}
+
+	if v, ok := d.int(params["Colors"]); ok {
+		colors = v
+	}
+
+	if v, ok := d.int(params["BitsPerComponent"]); ok {
+		bits = v
+	}
+
+	// Bounding each parameter first keeps the products below from
+	// overflowing.
+	switch {
+	case colors < 1 || colors > 32:
+		return nil, fmt.Errorf("invalid predictor colors %d", colors)
+	case bits != 1 && bits != 2 && bits != 4 && bits != 8 && bits != 16:
+		return nil, fmt.Errorf("invalid predictor bits per component %d", bits)
+	case columns < 1 || columns > maxStreamSize*8/(colors*bits):
+		return nil, fmt.Errorf("invalid predictor columns %d", columns)
+	}
+
+	rowSize := int((columns*colors*bits + 7) / 8)
+	pixelSize := int((colors*bits + 7) / 8)
+
+	out := make([]byte, 0, len(data))
+	prev := make([]byte, rowSize)
+
+	for len(data) > 0 {
+		if len(data) < rowSize+1 {
+			return nil, fmt.Errorf("truncated predictor row")
+		}
+
+		filter, row := data[0], append([]byte{}, data[1:rowSize+1]...)
+		data = data[rowSize+1:]
+
+		for i := range row {
+			var left, upLeft byte
+			if i >= pixelSize {
+				left, upLeft = row[i-pixelSize], prev[i-pixelSize]
+			}
+
+			switch filter {
+			case 0:
+			case 1:
+				row[i] += left
+			case 2:
+				row[i] += prev[i]
+			case 3:
+				row[i] += byte((int(left) + int(prev[i])) / 2)
+			case 4:
+				row[i] += paeth(left, prev[i], upLeft)
+			default:
+				return nil, fmt.Errorf("invalid png filter %d", filter)
+			}
+		}
+
+		out = append(out, row...)
+		prev = row
+	}
+
+	return out, nil
+}
+
+func paeth(a, b, c byte) byte {
+	p := int(a) + int(b) - int(c)
+	pa, pb, pc := abs(p-int(a)), abs(p-int(b)), abs(p-int(c))
+
+	switch {
+	case pa <= pb && pa <= pc:
+		return a
+	case pb <= pc:
+		return b
+	default:
+		return c
+	}
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+
+	return x
+}
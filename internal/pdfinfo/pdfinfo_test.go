@@ -0,0 +1,157 @@
+package pdfinfo
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"testing"
+)
+
+// testObjects are a catalog, a page tree with an inherited 8x10" media box,
+// and two pages; the second is an 11x14" page with bleed, rotated to
+// landscape.
+var testObjects = []string{
+	"<< /Type /Catalog /Pages 2 0 R >>",
+	"<< /Type /Pages /MediaBox [0 0 576 720] /Kids [3 0 R 4 0 R] /Count 2 >>",
+	"<< /Type /Page /Parent 2 0 R >>",
+	"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 828 1044] /TrimBox [18 18 810 1026] /Rotate 90 >>",
+}
+
+// classicPDF returns a document with a cross-reference table.
+func classicPDF(objects []string, trailer string) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	offsets := make([]int, len(objects))
+	for i, o := range objects {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, o)
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f\r\n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n\r\n", offset)
+	}
+
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R %s >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, trailer, xref)
+
+	return buf.Bytes()
+}
+
+func deflate(data []byte) []byte {
+	var buf bytes.Buffer
+	zw := zlib.NewWriter(&buf)
+	zw.Write(data)
+	zw.Close()
+
+	return buf.Bytes()
+}
+
+// compressedPDF returns a document with the objects in an object stream, and a
+// cross-reference stream encoded with the PNG up predictor.
+func compressedPDF(objects []string, indexShift int, decodeParms string) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.5\n")
+
+	var index, body bytes.Buffer
+	for i, o := range objects {
+		fmt.Fprintf(&index, "%d %d ", i+1, body.Len()+indexShift)
+		body.WriteString(o + "\n")
+	}
+
+	objStm := len(objects) + 1
+	data := deflate(append(index.Bytes(), body.Bytes()...))
+
+	objStmOffset := buf.Len()
+	fmt.Fprintf(&buf, "%d 0 obj\n<< /Type /ObjStm /N %d /First %d /Length %d /Filter /FlateDecode >>\nstream\n", objStm, len(objects), index.Len(), len(data))
+	buf.Write(data)
+	buf.WriteString("\nendstream\nendobj\n")
+
+	// Entries are a type byte, a 4 byte offset or object stream number, and a
+	// 2 byte index.
+	entry := func(typ byte, field uint32, idx uint16) []byte {
+		e := []byte{typ, 0, 0, 0, 0, 0, 0}
+		binary.BigEndian.PutUint32(e[1:5], field)
+		binary.BigEndian.PutUint16(e[5:7], idx)
+		return e
+	}
+
+	xrefStm := objStm + 1
+	xrefOffset := buf.Len()
+
+	rows := [][]byte{entry(0, 0, 65535)}
+	for i := range objects {
+		rows = append(rows, entry(2, uint32(objStm), uint16(i)))
+	}
+
+	rows = append(rows, entry(1, uint32(objStmOffset), 0), entry(1, uint32(xrefOffset), 0))
+
+	var predicted []byte
+	prev := make([]byte, 7)
+	for _, row := range rows {
+		predicted = append(predicted, 2)
+		for i := range row {
+			predicted = append(predicted, row[i]-prev[i])
+		}
+
+		prev = row
+	}
+
+	data = deflate(predicted)
+	fmt.Fprintf(&buf, "%d 0 obj\n<< /Type /XRef /Size %d /Root 1 0 R /W [1 4 2] /Filter /FlateDecode /DecodeParms << %s >> /Length %d >>\nstream\r\n", xrefStm, xrefStm+1, decodeParms, len(data))
+	buf.Write(data)
+	fmt.Fprintf(&buf, "\nendstream\nendobj\nstartxref\n%d\n%%%%EOF\n", xrefOffset)
+
+	return buf.Bytes()
+}
+
+func TestRead(t *testing.T) {
+	classic := classicPDF(testObjects, "")
+
+	tests := []struct {
+		name      string
+		data      []byte
+		allPages  bool
+		wantSizes [][2]float64
+		wantErr   error
+	}{
+		{name: "first page", data: classic, wantSizes: [][2]float64{{8, 10}}},
+		{name: "all pages", data: classic, allPages: true, wantSizes: [][2]float64{{8, 10}, {14, 11}}},
+		{name: "object streams", data: compressedPDF(testObjects, 0, "/Columns 7 /Predictor 12"), allPages: true, wantSizes: [][2]float64{{8, 10}, {14, 11}}},
+		{name: "negative object offset", data: compressedPDF(testObjects, -1000, "/Columns 7 /Predictor 12"), wantErr: ErrMalformed},
+		{name: "negative predictor colors", data: compressedPDF(testObjects, 0, "/Columns -1 /Colors -2 /Predictor 12"), wantErr: ErrMalformed},
+		{name: "invalid predictor bits", data: compressedPDF(testObjects, 0, "/Columns 7 /BitsPerComponent 3 /Predictor 12"), wantErr: ErrMalformed},
+		{name: "oversized predictor columns", data: compressedPDF(testObjects, 0, "/Columns 4611686018427387904 /Colors 32 /BitsPerComponent 16 /Predictor 12"), wantErr: ErrMalformed},
+		{name: "encrypted", data: classicPDF(append(testObjects, "<< /Filter /Standard /V 2 >>"), "/Encrypt 5 0 R"), wantErr: ErrEncrypted},
+		{name: "truncated", data: classic[:len(classic)-200], wantErr: ErrMalformed},
+		{name: "missing media box", data: classicPDF([]string{testObjects[0], "<< /Type /Pages /Kids [3 0 R] /Count 1 >>", testObjects[2]}, ""), wantErr: ErrMalformed},
+		{name: "not a pdf", data: []byte("GIF89a"), wantErr: ErrUnsupported},
+	}
+
+	for _, tc := range tests {
+		info, err := Read(bytes.NewReader(tc.data), int64(len(tc.data)), tc.allPages)
+		if tc.wantErr != nil || err != nil {
+			if !errors.Is(err, tc.wantErr) || tc.wantErr == nil {
+				t.Errorf("%s: got unexpected error, want=%v, got=%v", tc.name, tc.wantErr, err)
+			}
+
+			continue
+		}
+
+		if info.PageCount != 2 || len(info.Pages) != len(tc.wantSizes) {
+			t.Errorf("%s: got unexpected pages, want=%d of 2, got=%d of %d", tc.name, len(tc.wantSizes), len(info.Pages), info.PageCount)
+			continue
+		}
+
+		for i, page := range info.Pages {
+			w, h := page.Size()
+			if math.Abs(w-tc.wantSizes[i][0]) > 1e-9 || math.Abs(h-tc.wantSizes[i][1]) > 1e-9 {
+				t.Errorf("%s: got unexpected size of page %d, want=%vx%v, got=%vx%v", tc.name, i+1, tc.wantSizes[i][0], tc.wantSizes[i][1], w, h)
+			}
+		}
+	}
+}
@@ -0,0 +1,256 @@
+// Copyright (2023 -- present) Shahruk Hossain <shahruk10@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//		 http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// ==============================================================================
+
+package pdfinfo
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// startxrefSize is how much of the end of the file is searched for the
+// startxref keyword.
+const startxrefSize = 1024
+
+// maxXrefSections guards against cross-reference sections which form a loop.
+const maxXrefSections = 256
+
+// readXref reads the cross-reference sections of the document, starting with
+// the last one and following the chain of previous sections. Entries in later
+// sections replace those in earlier ones.
+func (d *document) readXref() error {
+	offset, err := d.startxref()
+	if err != nil {
+		return err
+	}
+
+	seen := make(map[int64]bool)
+	for {
+		if seen[offset] || len(seen) == maxXrefSections {
+			return fmt.Errorf("cross-reference sections form a loop")
+		}
+
+		seen[offset] = true
+
+		trailer, err := d.readXrefSection(offset)
+		if err != nil {
+			return fmt.Errorf("cross-reference section at %d: %w", offset, err)
+		}
+
+		if d.trailer == nil {
+			d.trailer = trailer
+		}
+
+		// Hybrid files have a cross-reference stream alongside the table.
+		if stm, ok := trailer["XRefStm"].(int64); ok && !seen[stm] {
+			seen[stm] = true
+			if _, err := d.readXrefSection(stm); err != nil {
+				return fmt.Errorf("cross-reference stream at %d: %w", stm, err)
+			}
+		}
+
+		prev, ok := trailer["Prev"].(int64)
+		if !ok {
+			return nil
+		}
+
+		offset = prev
+	}
+}
+
+// startxref returns the offset of the last cross-reference section.
+func (d *document) startxref() (int64, error) {
+	n := int64(startxrefSize)
+	if d.size < n {
+		n = d.size
+	}
+
+	tail := make([]byte, n)
+	if _, err := d.r.ReadAt(tail, d.size-n); err != nil && err != io.EOF {
+		return 0, err
+	}
+
+	i := bytes.LastIndex(tail, []byte("startxref"))
+	if i < 0 {
+		return 0, fmt.Errorf("startxref not found, file is truncated")
+	}
+
+	fields := bytes.Fields(tail[i+len("startxref"):])
+	if len(fields) == 0 {
+		return 0, fmt.Errorf("startxref offset missing")
+	}
+
+	offset, err := strconv.ParseInt(string(fields[0]), 10, 64)
+	if err != nil || offset <= 0 || offset >= d.size {
+		return 0, fmt.Errorf("invalid startxref offset %q", fields[0])
+	}
+
+	return offset, nil
+}
+
+// readXrefSection reads the cross-reference table or stream at offset, and
+// returns its trailer.
+func (d *document) readXrefSection(offset int64) (dict, error) {
+	if offset <= 0 || offset >= d.size {
+		return nil, fmt.Errorf("offset is outside the file")
+	}
+
+	l := newLexer(io.NewSectionReader(d.r, offset, d.size-offset), offset)
+
+	t, err := l.next()
+	if err != nil {
+		return nil, err
+	}
+
+	if t != keyword("xref") {
+		l.back(t)
+		return d.readXrefStream(l)
+	}
+
+	for {
+		t, err := l.next()
+		if err != nil {
+			return nil, err
+		}
+
+		if t == keyword("trailer") {
+			o, err := l.object()
+			if err != nil {
+				return nil, fmt.Errorf("trailer: %w", err)
+			}
+
+			trailer, ok := o.(dict)
+			if !ok {
+				return nil, fmt.Errorf("trailer is %T, not a dictionary", o)
+			}
+
+			return trailer, nil
+		}
+
+		start, ok := t.(int64)
+		c, err := l.next()
+		count, isCount := c.(int64)
+		if !ok || err != nil || !isCount || start < 0 || count < 0 {
+			return nil, fmt.Errorf("invalid subsection header")
+		}
+
+		for i := int64(0); i < count; i++ {
+			var entry [3]interface{}
+			for j := range entry {
+				if entry[j], err = l.next(); err != nil {
+					return nil, err
+				}
+			}
+
+			entryOffset, ok := entry[0].(int64)
+			if !ok || (entry[2] != keyword("n") && entry[2] != keyword("f")) {
+				return nil, fmt.Errorf("invalid entry for object %d", start+i)
+			}
+
+			if _, exists := d.xref[start+i]; !exists && entry[2] == keyword("n") {
+				d.xref[start+i] = xrefEntry{offset: entryOffset}
+			}
+		}
+	}
+}
+
+// readXrefStream reads a cross-reference stream, whose stream dictionary is
+// also the trailer.
+func (d *document) readXrefStream(l *lexer) (dict, error) {
+	_, o, err := l.indirect()
+	if err != nil {
+		return nil, err
+	}
+
+	s, ok := o.(*stream)
+	if !ok || s.hdr["Type"] != name("XRef") {
+		return nil, fmt.Errorf("expected xref table or stream")
+	}
+
+	data, err := d.streamData(s)
+	if err != nil {
+		return nil, err
+	}
+
+	w, ok := s.hdr["W"].(array)
+	if !ok || len(w) != 3 {
+		return nil, fmt.Errorf("invalid field widths %v", s.hdr["W"])
+	}
+
+	var widths [3]int
+	entrySize := 0
+	for i := range w {
+		v, ok := w[i].(int64)
+		if !ok || v < 0 || v > 8 {
+			return nil, fmt.Errorf("invalid field widths %v", w)
+		}
+
+		widths[i] = int(v)
+		entrySize += int(v)
+	}
+
+	if entrySize == 0 {
+		return nil, fmt.Errorf("invalid field widths %v", w)
+	}
+
+	index, ok := s.hdr["Index"].(array)
+	if !ok {
+		size, _ := s.hdr["Size"].(int64)
+		index = array{int64(0), size}
+	}
+
+	for i := 0; i+1 < len(index); i += 2 {
+		start, okStart := index[i].(int64)
+		count, okCount := index[i+1].(int64)
+		if !okStart || !okCount || start < 0 || count < 0 {
+			return nil, fmt.Errorf("invalid index %v", index)
+		}
+
+		for j := int64(0); j < count; j++ {
+			if len(data) < entrySize {
+				return nil, fmt.Errorf("stream is too short for its index")
+			}
+
+			var fields [3]int64
+			for k, width := range widths {
+				for _, b := range data[:width] {
+					fields[k] = fields[k]<<8 | int64(b)
+				}
+
+				data = data[width:]
+			}
+
+			// The type defaults to 1 if its field is missing.
+			if widths[0] == 0 {
+				fields[0] = 1
+			}
+
+			if _, exists := d.xref[start+j]; exists {
+				continue
+			}
+
+			switch fields[0] {
+			case 1:
+				d.xref[start+j] = xrefEntry{offset: fields[1]}
+			case 2:
+				d.xref[start+j] = xrefEntry{stream: fields[1], index: int(fields[2])}
+			}
+		}
+	}
+
+	return s.hdr, nil
+}
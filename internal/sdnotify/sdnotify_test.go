@@ -0,0 +1,128 @@
+package sdnotify
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// listen sets NOTIFY_SOCKET to a new socket, returning it to read the states
+// sent from.
+func listen(t *testing.T) *net.UnixConn {
+	if runtime.GOOS == "windows" {
+		t.Skip("unixgram sockets not supported")
+	}
+
+	path := filepath.Join(t.TempDir(), "notify.sock")
+
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { conn.Close() })
+	t.Setenv("NOTIFY_SOCKET", path)
+
+	return conn
+}
+
+func receive(t *testing.T, conn *net.UnixConn) string {
+	if err := conn.SetReadDeadline(time.Now().Add(5 * time.Second)); err != nil {
+		t.Fatal(err)
+	}
+
+	buf := make([]byte, 256)
+	n, err := conn.Read(buf)
+	if err != nil {
+		t.Fatalf("got no state from the socket: %v", err)
+	}
+
+	return string(buf[:n])
+}
+
+func TestNotify(t *testing.T) {
+	t.Setenv("NOTIFY_SOCKET", "")
+
+	if sent, err := Notify(Ready); sent || err != nil {
+		t.Errorf("got unexpected notification without a socket, want=false, got=%v (%v)", sent, err)
+	}
+
+	conn := listen(t)
+
+	for _, state := range []string{Ready, Stopping} {
+		if sent, err := Notify(state); !sent || err != nil {
+			t.Fatalf("got unexpected notification, want=true, got=%v (%v)", sent, err)
+		}
+
+		if got := receive(t, conn); got != state {
+			t.Errorf("got unexpected state, want=%q, got=%q", state, got)
+		}
+	}
+
+	t.Setenv("NOTIFY_SOCKET", filepath.Join(t.TempDir(), "missing.sock"))
+
+	if _, err := Notify(Ready); err == nil {
+		t.Errorf("got unexpected error notifying a missing socket, want=error, got=%v", err)
+	}
+}
+
+func TestWatchdogInterval(t *testing.T) {
+	tests := []struct {
+		usec   string
+		pid    string
+		want   time.Duration
+		wantOK bool
+	}{
+		{usec: ""},
+		{usec: "soon"},
+		{usec: "0"},
+		{usec: "-5"},
+		{usec: "30000000", want: 30 * time.Second, wantOK: true},
+		{usec: "30000000", pid: strconv.Itoa(os.Getpid()), want: 30 * time.Second, wantOK: true},
+		{usec: "30000000", pid: strconv.Itoa(os.Getpid() + 1)},
+	}
+
+	for _, tc := range tests {
+		t.Setenv("WATCHDOG_USEC", tc.usec)
+		t.Setenv("WATCHDOG_PID", tc.pid)
+
+		if got, ok := WatchdogInterval(); got != tc.want || ok != tc.wantOK {
+			t.Errorf("usec=%q pid=%q: got unexpected interval, want=%v (%v), got=%v (%v)", tc.usec, tc.pid, tc.want, tc.wantOK, got, ok)
+		}
+	}
+}
+
+func TestRunWatchdog(t *testing.T) {
+	conn := listen(t)
+
+	t.Setenv("WATCHDOG_PID", "")
+	t.Setenv("WATCHDOG_USEC", "")
+
+	// Without a watchdog, it returns right away.
+	if err := RunWatchdog(context.Background()); err != nil {
+		t.Errorf("got unexpected error without a watchdog, want=nil, got=%v", err)
+	}
+
+	t.Setenv("WATCHDOG_USEC", "20000")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- RunWatchdog(ctx) }()
+
+	for i := 0; i < 2; i++ {
+		if got := receive(t, conn); got != Watchdog {
+			t.Errorf("got unexpected ping, want=%q, got=%q", Watchdog, got)
+		}
+	}
+
+	cancel()
+
+	if err := <-done; err != context.Canceled {
+		t.Errorf("got unexpected error after cancelling, want=%v, got=%v", context.Canceled, err)
+	}
+}
@@ -18,6 +18,7 @@ package watcher
 import (
 	"context"
 	"fmt"
+	"os"
 	"time"
 
 	"github.com/fsnotify/fsnotify"
@@ -27,6 +28,10 @@ import (
 type Config struct {
 	IncludeFolders []string `yaml:"include_folders"`
 	ExcludeFolders []string `yaml:"exclude_folders"`
+	// Settle holds back create and write events until the file's size and
+	// modification time have stopped changing for this long, so that files
+	// still being copied aren't validated. Zero dispatches events immediately.
+	Settle time.Duration `yaml:"settle"`
 }
 
 func (cfg *Config) Validate() error {
@@ -34,6 +39,10 @@ func (cfg *Config) Validate() error {
 		return fmt.Errorf("no folders to watch specified")
 	}
 
+	if cfg.Settle < 0 {
+		return fmt.Errorf("settle duration must not be negative")
+	}
+
 	return nil
 }
 
@@ -116,8 +125,35 @@ func (w *FSNotifyWatcher) AddCallbacks(callbacks ...Callback) error {
 	return nil
 }
 
+// settling is a create or write event being held back until the file stops
+// changing.
+type settling struct {
+	event   fsnotify.Event
+	size    int64
+	modTime time.Time
+	timer   *time.Timer
+}
+
 func (w *FSNotifyWatcher) Watch(ctx context.Context) error {
 	eventLog := make(map[string]*Event)
+	pending := make(map[string]*settling)
+	settled := make(chan string)
+
+	// arm waits for the file to settle before checking on it again.
+	arm := func(name string) *time.Timer {
+		return time.AfterFunc(w.cfg.Settle, func() {
+			select {
+			case settled <- name:
+			case <-ctx.Done():
+			}
+		})
+	}
+
+	defer func() {
+		for _, p := range pending {
+			p.timer.Stop()
+		}
+	}()
 
 	for {
 		t0 := time.Now()
@@ -141,11 +177,38 @@ func (w *FSNotifyWatcher) Watch(ctx context.Context) error {
 				return nil
 			}
 
+			w.logger.Debugf("received event: %s", e)
+
+			if w.cfg.Settle > 0 {
+				if e.Has(fsnotify.Create) || e.Has(fsnotify.Write) {
+					if p, ok := pending[e.Name]; ok {
+						p.event.Op |= e.Op
+						continue
+					}
+
+					info, err := os.Stat(e.Name)
+					if err != nil || info.IsDir() {
+						w.dispatch(ctx, Event{Event: &e, time: time.Now()})
+						continue
+					}
+
+					pending[e.Name] = &settling{event: e, size: info.Size(), modTime: info.ModTime(), timer: arm(e.Name)}
+					continue
+				}
+
+				// The file is gone, so there's nothing left to settle.
+				if p, ok := pending[e.Name]; ok && (e.Has(fsnotify.Remove) || e.Has(fsnotify.Rename)) {
+					p.timer.Stop()
+					delete(pending, e.Name)
+				}
+
+				w.dispatch(ctx, Event{Event: &e, time: time.Now()})
+				continue
+			}
+
 			newEvent := Event{Event: &e, time: time.Now()}
 			ignore := false
 
-			w.logger.Debugf("received event: %s", e)
-
 			prevEvent, ok := eventLog[e.Name]
 			if ok {
 				ignore = newEvent.IsSameWriteEventAs(prevEvent)
@@ -158,13 +221,30 @@ func (w *FSNotifyWatcher) Watch(ctx context.Context) error {
 				continue
 			}
 
-			go func() {
-				for i, callback := range w.callbacks {
-					if err := callback(ctx, w.logger, newEvent); err != nil {
-						w.logger.Errorf("applying callback[%d]: %v", i, err)
-					}
-				}
-			}()
+			w.dispatch(ctx, newEvent)
+
+		case name := <-settled:
+			p, ok := pending[name]
+			if !ok {
+				continue
+			}
+
+			info, err := os.Stat(name)
+			if err != nil {
+				w.logger.Debugf("dropping event for %q, file is gone: %v", name, err)
+				delete(pending, name)
+				continue
+			}
+
+			if info.Size() != p.size || !info.ModTime().Equal(p.modTime) {
+				w.logger.Debugf("waiting for %q to settle", name)
+				p.size, p.modTime, p.timer = info.Size(), info.ModTime(), arm(name)
+				continue
+			}
+
+			delete(pending, name)
+			e := p.event
+			w.dispatch(ctx, Event{Event: &e, time: time.Now()})
 
 		case err, ok := <-w.Errors:
 			if !ok {
@@ -178,6 +258,17 @@ func (w *FSNotifyWatcher) Watch(ctx context.Context) error {
 	}
 }
 
+// dispatch applies the callbacks to the event in the background.
+func (w *FSNotifyWatcher) dispatch(ctx context.Context, e Event) {
+	go func() {
+		for i, callback := range w.callbacks {
+			if err := callback(ctx, w.logger, e); err != nil {
+				w.logger.Errorf("applying callback[%d]: %v", i, err)
+			}
+		}
+	}()
+}
+
 func (w *FSNotifyWatcher) Close() error {
 	w.logger.Info("Closing watcher")
 	return w.Watcher.Close()
@@ -0,0 +1,80 @@
+package watcher
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestWatchSettle(t *testing.T) {
+	dir := t.TempDir()
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	w, err := New(logger, Config{IncludeFolders: []string{dir}, Settle: 200 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	if err := w.AddFolders(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	events := make(chan Event, 10)
+	if err := w.AddCallbacks(func(ctx context.Context, logger *logrus.Logger, e Event) error {
+		events <- e
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go w.Watch(ctx)
+
+	// Writes spread over longer than the settle duration are held back until
+	// the file stops changing, and then dispatched once.
+	path := filepath.Join(dir, "a.jpg")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		if _, err := f.Write([]byte("chunk")); err != nil {
+			t.Fatal(err)
+		}
+
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	lastWrite := time.Now()
+
+	select {
+	case e := <-events:
+		if e.Name != path || !e.HasOp(CreateOp) {
+			t.Errorf("got unexpected event, got=%s", e)
+		}
+
+		if time.Since(lastWrite) < 100*time.Millisecond {
+			t.Errorf("got event before file settled, after=%s", time.Since(start))
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for settled event")
+	}
+
+	select {
+	case e := <-events:
+		t.Errorf("got unexpected second event, got=%s", e)
+	case <-time.After(500 * time.Millisecond):
+	}
+}
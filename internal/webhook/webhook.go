@@ -0,0 +1,395 @@
+// Copyright (2023 -- present) Shahruk Hossain <shahruk10@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//		 http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// ==============================================================================
+
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shahruk10/watcher/internal/fileutil"
+	"github.com/sirupsen/logrus"
+)
+
+// SignatureHeader holds the hex encoded HMAC-SHA256 of the request body,
+// prefixed with "sha256=", when a secret is configured.
+const SignatureHeader = "X-Watcher-Signature"
+
+// failedDir is the folder in the outbox that documents rejected by their
+// endpoint are moved to, instead of being retried.
+const failedDir = "failed"
+
+type Config struct {
+	URLs []string `yaml:"urls"`
+	// Secret used to sign request bodies; requests are not signed if empty.
+	Secret string `yaml:"secret"`
+	// OutboxDir holds documents until they have been delivered, so that none
+	// are lost while an endpoint is down or the watcher is restarted.
+	// Documents rejected by their endpoint are moved to its failed folder.
+	OutboxDir string `yaml:"outbox_dir"`
+	// Timeout of each delivery attempt.
+	Timeout time.Duration `yaml:"timeout"`
+	// Failed deliveries are retried after InitialBackoff, doubling every
+	// attempt up to MaxBackoff.
+	InitialBackoff time.Duration `yaml:"initial_backoff"`
+	MaxBackoff     time.Duration `yaml:"max_backoff"`
+}
+
+func (cfg *Config) setDefaults() {
+	if cfg.OutboxDir == "" {
+		cfg.OutboxDir = "watcher-outbox"
+	}
+
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+
+	if cfg.InitialBackoff <= 0 {
+		cfg.InitialBackoff = time.Second
+	}
+
+	if cfg.MaxBackoff <= 0 {
+		cfg.MaxBackoff = 5 * time.Minute
+	}
+}
+
+// message is a document waiting in the outbox to be delivered to a URL.
+type message struct {
+	URL      string          `json:"url"`
+	Body     json.RawMessage `json:"body"`
+	Created  time.Time       `json:"created"`
+	Attempts int             `json:"attempts"`
+}
+
+// Sender delivers JSON documents to webhook endpoints. Documents are written to
+// the outbox first and delivered in the background by Run.
+type Sender struct {
+	logger *logrus.Logger
+	cfg    Config
+	client *http.Client
+
+	mu    sync.Mutex
+	seq   int
+	retry map[string]time.Time
+	wake  chan struct{}
+}
+
+func New(logger *logrus.Logger, cfg Config) (*Sender, error) {
+	cfg.setDefaults()
+
+	if err := os.MkdirAll(cfg.OutboxDir, 0o755); err != nil {
+		return nil, fmt.Errorf("create webhook outbox: %w", err)
+	}
+
+	s := &Sender{
+		logger: logger,
+		cfg:    cfg,
+		client: &http.Client{Timeout: cfg.Timeout},
+		retry:  make(map[string]time.Time),
+		wake:   make(chan struct{}, 1),
+	}
+
+	return s, nil
+}
+
+// URLs returns the configured endpoints.
+func (s *Sender) URLs() []string {
+	return s.cfg.URLs
+}
+
+// Send queues the document for delivery to the given URLs, or to the
+// configured URLs if none are given.
+func (s *Sender) Send(doc interface{}, urls ...string) error {
+	body, err := json.Marshal(doc)
+	if err != nil {
+		return fmt.Errorf("encode webhook document: %w", err)
+	}
+
+	if len(urls) == 0 {
+		urls = s.cfg.URLs
+	}
+
+	for _, url := range urls {
+		if err := s.enqueue(message{URL: url, Body: body, Created: time.Now()}); err != nil {
+			return err
+		}
+	}
+
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+
+	return nil
+}
+
+func (s *Sender) enqueue(msg message) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("encode outbox message: %w", err)
+	}
+
+	s.mu.Lock()
+	s.seq++
+	name := fmt.Sprintf("%020d-%06d.json", msg.Created.UnixNano(), s.seq)
+	s.mu.Unlock()
+
+	return fileutil.WriteFile(filepath.Join(s.cfg.OutboxDir, name), data, 0o644)
+}
+
+// Run delivers queued documents until the context is cancelled. Documents
+// left in the outbox by an earlier run are delivered too.
+func (s *Sender) Run(ctx context.Context) error {
+	for {
+		next := s.Flush(ctx)
+
+		wait := time.Until(next)
+		if next.IsZero() {
+			wait = time.Hour
+		}
+
+		timer := time.NewTimer(wait)
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-s.wake:
+		case <-timer.C:
+		}
+
+		timer.Stop()
+	}
+}
+
+// Flush attempts delivery of every document in the outbox which is due,
+// returning the time at which the next failed delivery should be retried
+// (zero if there are none). The pass stops at the first endpoint which can't
+// be reached, leaving the remaining documents for the next one.
+func (s *Sender) Flush(ctx context.Context) time.Time {
+	entries, err := os.ReadDir(s.cfg.OutboxDir)
+	if err != nil {
+		s.logger.Errorf("read webhook outbox: %v", err)
+		return time.Now().Add(s.cfg.InitialBackoff)
+	}
+
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasSuffix(e.Name(), ".json") {
+			names = append(names, e.Name())
+		}
+	}
+
+	sort.Strings(names)
+
+	var next time.Time
+
+	for _, name := range names {
+		if ctx.Err() != nil {
+			return next
+		}
+
+		s.mu.Lock()
+		retryAt, waiting := s.retry[name]
+		s.mu.Unlock()
+
+		if waiting && time.Now().Before(retryAt) {
+			if next.IsZero() || retryAt.Before(next) {
+				next = retryAt
+			}
+
+			continue
+		}
+
+		retryAt, err := s.deliver(ctx, name)
+		if err == nil {
+			continue
+		}
+
+		if next.IsZero() || retryAt.Before(next) {
+			next = retryAt
+		}
+
+		if errors.Is(err, errUnreachable) {
+			return next
+		}
+	}
+
+	return next
+}
+
+// errUnreachable is returned for deliveries which failed without a response.
+var errUnreachable = errors.New("endpoint unreachable")
+
+// statusError is returned for deliveries answered with a status other than
+// 2xx.
+type statusError struct {
+	code   int
+	status string
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("unexpected status %s", e.status)
+}
+
+// permanent reports whether retrying the delivery is pointless: the endpoint
+// rejected the document with a client error other than a timeout or rate
+// limit.
+func (e *statusError) permanent() bool {
+	return e.code/100 == 4 && e.code != http.StatusRequestTimeout && e.code != http.StatusTooManyRequests
+}
+
+// deliver attempts delivery of a single message from the outbox. If delivery
+// fails, the time of the next attempt is returned along with the error.
+func (s *Sender) deliver(ctx context.Context, name string) (time.Time, error) {
+	path := filepath.Join(s.cfg.OutboxDir, name)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		s.logger.Errorf("read webhook outbox message %q: %v", name, err)
+		return time.Now().Add(s.cfg.MaxBackoff), err
+	}
+
+	var msg message
+	if err := json.Unmarshal(data, &msg); err != nil {
+		s.logger.Errorf("dropping invalid webhook outbox message %q: %v", name, err)
+		os.Remove(path)
+		return time.Time{}, nil
+	}
+
+	msg.Attempts++
+
+	err = s.post(ctx, msg)
+	if status := (*statusError)(nil); errors.As(err, &status) && status.permanent() {
+		s.logger.Errorf("webhook delivery to %q rejected (attempt %d), moving it to %q: %v", msg.URL, msg.Attempts, failedDir, err)
+		s.fail(name, msg)
+
+		return time.Time{}, nil
+	} else if err != nil {
+		backoff := s.cfg.InitialBackoff
+		for i := 1; i < msg.Attempts && backoff < s.cfg.MaxBackoff; i++ {
+			backoff *= 2
+		}
+
+		if backoff > s.cfg.MaxBackoff {
+			backoff = s.cfg.MaxBackoff
+		}
+
+		retryAt := time.Now().Add(backoff)
+		s.logger.Warnf("webhook delivery to %q failed (attempt %d), retrying in %s: %v", msg.URL, msg.Attempts, backoff, err)
+
+		// Remember the attempt count across restarts.
+		if data, err := json.Marshal(msg); err == nil {
+			if err := fileutil.WriteFile(path, data, 0o644); err != nil {
+				s.logger.Errorf("update webhook outbox message %q: %v", name, err)
+			}
+		}
+
+		s.mu.Lock()
+		s.retry[name] = retryAt
+		s.mu.Unlock()
+
+		return retryAt, err
+	}
+
+	s.logger.Debugf("delivered webhook to %q after %d attempt(s)", msg.URL, msg.Attempts)
+
+	s.mu.Lock()
+	delete(s.retry, name)
+	s.mu.Unlock()
+
+	if err := os.Remove(path); err != nil {
+		s.logger.Errorf("remove delivered webhook outbox message %q: %v", name, err)
+	}
+
+	return time.Time{}, nil
+}
+
+// fail moves a rejected message out of the outbox into its failed folder.
+func (s *Sender) fail(name string, msg message) {
+	s.mu.Lock()
+	delete(s.retry, name)
+	s.mu.Unlock()
+
+	dir := filepath.Join(s.cfg.OutboxDir, failedDir)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		s.logger.Errorf("create failed webhook folder: %v", err)
+		return
+	}
+
+	data, err := json.Marshal(msg)
+	if err == nil {
+		err = fileutil.WriteFile(filepath.Join(dir, name), data, 0o644)
+	}
+
+	if err == nil {
+		err = os.Remove(filepath.Join(s.cfg.OutboxDir, name))
+	}
+
+	if err != nil {
+		s.logger.Errorf("move rejected webhook outbox message %q: %v", name, err)
+	}
+}
+
+func (s *Sender) post(ctx context.Context, msg message) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, msg.URL, bytes.NewReader(msg.Body))
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	if s.cfg.Secret != "" {
+		req.Header.Set(SignatureHeader, Sign(s.cfg.Secret, msg.Body))
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: %v", errUnreachable, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		return &statusError{code: resp.StatusCode, status: resp.Status}
+	}
+
+	return nil
+}
+
+// Sign returns the signature header value for the body.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify reports whether the signature header value matches the body.
+func Verify(secret string, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, body)), []byte(signature))
+}
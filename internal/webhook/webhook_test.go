@@ -0,0 +1,239 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestSenderRetriesAndSigns(t *testing.T) {
+	const secret = "s3cr3t"
+
+	var (
+		mu       sync.Mutex
+		failures = 2
+		bodies   []string
+	)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		if !Verify(secret, body, r.Header.Get(SignatureHeader)) {
+			t.Errorf("got invalid signature %q for body %s", r.Header.Get(SignatureHeader), body)
+		}
+
+		if failures > 0 {
+			failures--
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		bodies = append(bodies, string(body))
+	}))
+	defer srv.Close()
+
+	cfg := Config{
+		URLs:           []string{srv.URL},
+		Secret:         secret,
+		OutboxDir:      t.TempDir(),
+		InitialBackoff: 10 * time.Millisecond,
+		MaxBackoff:     20 * time.Millisecond,
+	}
+
+	s, err := New(logrus.New(), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	go s.Run(ctx)
+
+	if err := s.Send(map[string]string{"file": "a.jpg"}); err != nil {
+		t.Fatalf("got unexpected error sending document, want=nil, got=%v", err)
+	}
+
+	for ctx.Err() == nil {
+		entries, _ := os.ReadDir(cfg.OutboxDir)
+		if len(entries) == 0 {
+			break
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if len(bodies) != 1 || bodies[0] != `{"file":"a.jpg"}` {
+		t.Errorf("got unexpected delivered documents, want=[{\"file\":\"a.jpg\"}], got=%v", bodies)
+	}
+}
+
+func TestSenderKeepsOutboxWhileEndpointDown(t *testing.T) {
+	up := false
+	delivered := 0
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !up {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+
+		delivered++
+	}))
+	defer srv.Close()
+
+	cfg := Config{URLs: []string{srv.URL}, OutboxDir: t.TempDir()}
+
+	s, err := New(logrus.New(), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.Send(map[string]int{"n": 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	if next := s.Flush(context.Background()); next.IsZero() {
+		t.Errorf("got no retry scheduled after failed delivery")
+	}
+
+	if entries, _ := os.ReadDir(cfg.OutboxDir); len(entries) != 1 {
+		t.Fatalf("got unexpected outbox size after failed delivery, want=1, got=%d", len(entries))
+	}
+
+	// A new sender, as after a restart, should pick up the queued document.
+	up = true
+
+	s, err = New(logrus.New(), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if next := s.Flush(context.Background()); !next.IsZero() {
+		t.Errorf("got retry scheduled after successful delivery at %s", next)
+	}
+
+	if entries, _ := os.ReadDir(cfg.OutboxDir); len(entries) != 0 || delivered != 1 {
+		t.Errorf("got unexpected outbox state, want=0 queued and 1 delivered, got=%d queued and %d delivered", len(entries), delivered)
+	}
+}
+
+func TestSenderMovesRejectedToFailed(t *testing.T) {
+	status := map[string]int{"/rejected": http.StatusBadRequest, "/limited": http.StatusTooManyRequests, "/slow": http.StatusRequestTimeout}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status[r.URL.Path])
+	}))
+	defer srv.Close()
+
+	cfg := Config{OutboxDir: t.TempDir()}
+
+	s, err := New(logrus.New(), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.Send(map[string]int{"n": 1}, srv.URL+"/rejected", srv.URL+"/limited", srv.URL+"/slow"); err != nil {
+		t.Fatal(err)
+	}
+
+	if next := s.Flush(context.Background()); next.IsZero() {
+		t.Errorf("got no retry scheduled for rate limited and timed out deliveries")
+	}
+
+	// Rejected documents are moved out of the way; the rest are retried.
+	failed, _ := os.ReadDir(filepath.Join(cfg.OutboxDir, failedDir))
+	if len(failed) != 1 {
+		t.Fatalf("got unexpected failed documents, want=1, got=%d", len(failed))
+	}
+
+	data, err := os.ReadFile(filepath.Join(cfg.OutboxDir, failedDir, failed[0].Name()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var msg message
+	if err := json.Unmarshal(data, &msg); err != nil || msg.URL != srv.URL+"/rejected" || msg.Attempts != 1 {
+		t.Errorf("got unexpected failed document, got=%+v (%v)", msg, err)
+	}
+
+	if queued := outbox(t, cfg.OutboxDir); len(queued) != 2 {
+		t.Errorf("got unexpected queued documents, want=2, got=%+v", queued)
+	}
+}
+
+func TestSenderStopsWhenUnreachable(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	srv.Close()
+
+	cfg := Config{URLs: []string{srv.URL}, OutboxDir: t.TempDir()}
+
+	s, err := New(logrus.New(), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 3; i++ {
+		if err := s.Send(map[string]int{"n": i}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if next := s.Flush(context.Background()); next.IsZero() {
+		t.Errorf("got no retry scheduled after failed delivery")
+	}
+
+	// Only the first document is attempted before giving up on the pass.
+	var attempts []int
+	for _, msg := range outbox(t, cfg.OutboxDir) {
+		attempts = append(attempts, msg.Attempts)
+	}
+
+	if len(attempts) != 3 || attempts[0] != 1 || attempts[1] != 0 || attempts[2] != 0 {
+		t.Errorf("got unexpected delivery attempts, want=[1 0 0], got=%v", attempts)
+	}
+}
+
+// outbox returns the messages queued in the outbox, oldest first.
+func outbox(t *testing.T, dir string) []message {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var msgs []message
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join(dir, e.Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var msg message
+		if err := json.Unmarshal(data, &msg); err != nil {
+			t.Fatal(err)
+		}
+
+		msgs = append(msgs, msg)
+	}
+
+	return msgs
+}